@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/bmatcuk/doublestar/v4 v4.7.1
 	github.com/fsnotify/fsnotify v1.8.0
+	github.com/klauspost/compress v1.17.11
 	github.com/pelletier/go-toml/v2 v2.2.2
 	github.com/sashabaranov/go-openai v1.41.2
 	github.com/spf13/cobra v1.9.1
@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample is one measurement of a named metric.
+type Sample struct {
+	Name  string
+	Unit  string
+	Value float64
+}
+
+// Summary aggregates the samples of a single (name, unit) metric.
+type Summary struct {
+	Name   string
+	Unit   string
+	Values []float64
+	Mean   float64
+	Median float64
+	Min    float64
+	Max    float64
+}
+
+var goBenchProcSuffix = regexp.MustCompile(`-\d+$`)
+
+// ParseGoBench extracts samples from `go test -bench` output. Every value/unit
+// pair on a result line becomes a sample; the -GOMAXPROCS suffix is dropped so
+// names are stable across machines.
+func ParseGoBench(output string) []Sample {
+	out := make([]Sample, 0)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := goBenchProcSuffix.ReplaceAllString(fields[0], "")
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			out = append(out, Sample{Name: name, Unit: fields[i+1], Value: value})
+		}
+	}
+	return out
+}
+
+// ParseMetricsJSON reads a generic metrics file. Two shapes are accepted:
+//
+//	{"metrics": [{"name": "p95_latency", "unit": "ms", "value": 12.5}]}
+//	{"p95_latency": 12.5, "throughput": [410, 415, 409]}
+func ParseMetricsJSON(data []byte) ([]Sample, error) {
+	var wrapped struct {
+		Metrics []struct {
+			Name  string  `json:"name"`
+			Unit  string  `json:"unit"`
+			Value float64 `json:"value"`
+		} `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Metrics != nil {
+		out := make([]Sample, 0, len(wrapped.Metrics))
+		for _, m := range wrapped.Metrics {
+			name := strings.TrimSpace(m.Name)
+			if name == "" {
+				return nil, fmt.Errorf("metric name cannot be empty")
+			}
+			out = append(out, Sample{Name: name, Unit: strings.TrimSpace(m.Unit), Value: m.Value})
+		}
+		return out, nil
+	}
+
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return nil, fmt.Errorf("parse metrics json: %w", err)
+	}
+	names := make([]string, 0, len(flat))
+	for name := range flat {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]Sample, 0, len(flat))
+	for _, name := range names {
+		raw := flat[name]
+		var single float64
+		if err := json.Unmarshal(raw, &single); err == nil {
+			out = append(out, Sample{Name: name, Value: single})
+			continue
+		}
+		var many []float64
+		if err := json.Unmarshal(raw, &many); err != nil {
+			return nil, fmt.Errorf("metric %s: expected number or array of numbers", name)
+		}
+		for _, v := range many {
+			out = append(out, Sample{Name: name, Value: v})
+		}
+	}
+	return out, nil
+}
+
+// Summarize groups samples by (name, unit), preserving first-seen order.
+func Summarize(samples []Sample) []Summary {
+	type key struct{ name, unit string }
+	index := map[key]int{}
+	out := make([]Summary, 0)
+	for _, s := range samples {
+		k := key{s.Name, s.Unit}
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, Summary{Name: s.Name, Unit: s.Unit})
+		}
+		out[i].Values = append(out[i].Values, s.Value)
+	}
+	for i := range out {
+		fillStats(&out[i])
+	}
+	return out
+}
+
+func fillStats(s *Summary) {
+	if len(s.Values) == 0 {
+		return
+	}
+	sorted := append([]float64(nil), s.Values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	s.Mean = sum / float64(len(sorted))
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		s.Median = (sorted[mid-1] + sorted[mid]) / 2
+	} else {
+		s.Median = sorted[mid]
+	}
+}
+
+// Spread is the largest deviation from the mean as a fraction of the mean,
+// the "±" figure benchstat prints.
+func (s Summary) Spread() float64 {
+	if s.Mean == 0 {
+		return 0
+	}
+	return math.Max(s.Max-s.Mean, s.Mean-s.Min) / math.Abs(s.Mean)
+}
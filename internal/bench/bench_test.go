@@ -0,0 +1,108 @@
+package bench
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseGoBench(t *testing.T) {
+	out := `goos: linux
+pkg: example.com/m
+BenchmarkParse-8   	  120000	      9876 ns/op	     512 B/op	       3 allocs/op
+BenchmarkParse-8   	  120000	      9912 ns/op	     512 B/op	       3 allocs/op
+BenchmarkEncode/small-16 	 5000000	       250.5 ns/op
+PASS
+ok  	example.com/m	3.2s
+`
+	samples := ParseGoBench(out)
+	if len(samples) != 7 {
+		t.Fatalf("expected 7 samples, got %d: %+v", len(samples), samples)
+	}
+	if samples[0].Name != "BenchmarkParse" || samples[0].Unit != "ns/op" || samples[0].Value != 9876 {
+		t.Fatalf("unexpected first sample: %+v", samples[0])
+	}
+	last := samples[len(samples)-1]
+	if last.Name != "BenchmarkEncode/small" || last.Value != 250.5 {
+		t.Fatalf("unexpected last sample: %+v", last)
+	}
+
+	summaries := Summarize(samples)
+	if len(summaries) != 4 {
+		t.Fatalf("expected 4 metrics, got %+v", summaries)
+	}
+	if summaries[0].Mean != 9894 || len(summaries[0].Values) != 2 {
+		t.Fatalf("unexpected ns/op summary: %+v", summaries[0])
+	}
+}
+
+func TestParseMetricsJSON(t *testing.T) {
+	samples, err := ParseMetricsJSON([]byte(`{"metrics": [{"name": "p95", "unit": "ms", "value": 12.5}]}`))
+	if err != nil {
+		t.Fatalf("parse wrapped metrics: %v", err)
+	}
+	if len(samples) != 1 || samples[0].Name != "p95" || samples[0].Unit != "ms" || samples[0].Value != 12.5 {
+		t.Fatalf("unexpected wrapped samples: %+v", samples)
+	}
+
+	samples, err = ParseMetricsJSON([]byte(`{"throughput": [410, 415], "latency": 3}`))
+	if err != nil {
+		t.Fatalf("parse flat metrics: %v", err)
+	}
+	if len(samples) != 3 || samples[0].Name != "latency" || samples[2].Value != 415 {
+		t.Fatalf("unexpected flat samples: %+v", samples)
+	}
+
+	if _, err := ParseMetricsJSON([]byte(`{"bad": "x"}`)); err == nil {
+		t.Fatalf("expected non-numeric metric to fail")
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	separated := MannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if math.Abs(separated-2.0/252.0) > 1e-9 {
+		t.Fatalf("exact p for separated samples = %v, want %v", separated, 2.0/252.0)
+	}
+
+	overlapping := MannWhitneyU([]float64{1, 3, 5, 7, 9}, []float64{2, 4, 6, 8, 10})
+	if overlapping < 0.5 {
+		t.Fatalf("expected overlapping samples to be insignificant, got p=%v", overlapping)
+	}
+
+	tied := MannWhitneyU([]float64{1, 1, 1, 2}, []float64{1, 1, 1, 2})
+	if tied < 0.9 {
+		t.Fatalf("expected identical tied samples to give p~1, got %v", tied)
+	}
+
+	if p := MannWhitneyU(nil, []float64{1}); p != 1 {
+		t.Fatalf("expected p=1 for empty sample, got %v", p)
+	}
+}
+
+func TestCompareSummaries(t *testing.T) {
+	old := Summarize([]Sample{
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 100},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 101},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 99},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 100.5},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 99.5},
+		{Name: "BenchmarkGone", Unit: "ns/op", Value: 1},
+	})
+	cur := Summarize([]Sample{
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 80},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 81},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 79},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 80.5},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 79.5},
+	})
+	comparisons := CompareSummaries(old, cur)
+	if len(comparisons) != 1 {
+		t.Fatalf("expected 1 comparison, got %+v", comparisons)
+	}
+	c := comparisons[0]
+	if math.Abs(c.DeltaPct+20) > 1e-9 {
+		t.Fatalf("expected -20%% delta, got %v", c.DeltaPct)
+	}
+	if !c.Significant {
+		t.Fatalf("expected significant change, got p=%v", c.P)
+	}
+}
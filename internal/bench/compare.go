@@ -0,0 +1,164 @@
+package bench
+
+import (
+	"math"
+	"sort"
+)
+
+// Alpha is the significance level used to decide whether a change is real.
+const Alpha = 0.05
+
+// Comparison describes how a metric moved between two sets of samples.
+type Comparison struct {
+	Name        string
+	Unit        string
+	Old         Summary
+	New         Summary
+	DeltaPct    float64
+	P           float64
+	Significant bool
+}
+
+// CompareSummaries pairs metrics present in both old and cur and tests each
+// pair with a two-sided Mann-Whitney U test. Metrics missing on either side
+// are omitted.
+func CompareSummaries(old, cur []Summary) []Comparison {
+	type key struct{ name, unit string }
+	byKey := make(map[key]Summary, len(cur))
+	for _, s := range cur {
+		byKey[key{s.Name, s.Unit}] = s
+	}
+	out := make([]Comparison, 0, len(old))
+	for _, o := range old {
+		n, ok := byKey[key{o.Name, o.Unit}]
+		if !ok {
+			continue
+		}
+		c := Comparison{Name: o.Name, Unit: o.Unit, Old: o, New: n, P: 1}
+		if o.Mean != 0 {
+			c.DeltaPct = (n.Mean - o.Mean) * 100 / math.Abs(o.Mean)
+		}
+		c.P = MannWhitneyU(o.Values, n.Values)
+		c.Significant = c.P < Alpha
+		out = append(out, c)
+	}
+	return out
+}
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test for
+// samples x and y. Small samples without ties use the exact distribution of U;
+// otherwise the tie-corrected normal approximation is used. It returns 1 when
+// either sample is empty.
+func MannWhitneyU(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type obs struct {
+		v     float64
+		group int
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, obs{v, 0})
+	}
+	for _, v := range y {
+		all = append(all, obs{v, 1})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Assign mid-ranks to ties and collect the tie correction term.
+	ranks := make([]float64, len(all))
+	tieSum := 0.0
+	hasTies := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			ranks[k] = rank
+		}
+		if t := float64(j - i); t > 1 {
+			hasTies = true
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+
+	r1 := 0.0
+	for i, o := range all {
+		if o.group == 0 {
+			r1 += ranks[i]
+		}
+	}
+	u1 := r1 - float64(n1*(n1+1))/2
+	u2 := float64(n1*n2) - u1
+	u := math.Min(u1, u2)
+
+	if !hasTies && n1+n2 <= 50 {
+		return exactUPValue(n1, n2, u)
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	// Continuity correction toward the mean.
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	p := math.Erfc(z / math.Sqrt2)
+	return math.Min(p, 1)
+}
+
+// exactUPValue computes P(U <= u) * 2 under the null hypothesis by counting
+// rank arrangements with a dynamic program over (n1, n2).
+func exactUPValue(n1, n2 int, u float64) float64 {
+	maxU := n1 * n2
+	// counts[i][j][k] = arrangements of i x-values and j y-values with U == k.
+	// Only two layers of i are needed at a time.
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		for j := range cur {
+			cur[j] = make([]float64, maxU+1)
+		}
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			for k := 0; k <= maxU; k++ {
+				// The largest observation is either an x (contributes j to U)
+				// or a y (contributes nothing).
+				v := cur[j-1][k]
+				if k >= j {
+					v += prev[j][k-j]
+				}
+				cur[j][k] = v
+			}
+		}
+		prev = cur
+	}
+
+	total := 0.0
+	tail := 0.0
+	limit := int(math.Floor(u))
+	for k := 0; k <= maxU; k++ {
+		total += prev[n2][k]
+		if k <= limit {
+			tail += prev[n2][k]
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return math.Min(2*tail/total, 1)
+}
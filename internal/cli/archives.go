@@ -2,13 +2,18 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/prit3010/converge/internal/core"
 	"github.com/spf13/cobra"
 )
 
 func newArchivesCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "archives",
 		Short: "List archived converge graph states",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -19,6 +24,78 @@ func newArchivesCmd() *cobra.Command {
 			return runArchives(cwd)
 		},
 	}
+	cmd.AddCommand(newArchivesImportCmd())
+	cmd.AddCommand(newArchivesPruneCmd())
+	cmd.AddCommand(newArchivesCompactCmd())
+	cmd.AddCommand(newArchivesExportCmd())
+	return cmd
+}
+
+func newArchivesImportCmd() *cobra.Command {
+	var branch string
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "import <archive>[:<cell>] | <file.tar.zst>",
+		Short: "Recreate an archived branch lineage, or add an exported archive",
+		Long:  "Copies an archived cell and its ancestors, with their blobs, into the active state on a new branch. Without a cell, the head of the archive's active branch is imported. Imported cells get new IDs and record their archive origin.\n\nGiven a file written by `converge archives export`, adds that archive to this project's archives instead.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runArchivesImport(cwd, args[0], branch, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&branch, "branch", "", "Name of the new branch (default archive-<archive>)")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runArchivesImport(projectDir, ref, branch string, outputJSON bool, out io.Writer) error {
+	if info, err := os.Stat(ref); err == nil && info.Mode().IsRegular() {
+		if branch != "" {
+			return validationErrorf("--branch cannot be used when importing an archive file")
+		}
+		return runArchivesImportFile(projectDir, ref, outputJSON, out)
+	}
+	archiveID, cellID := core.SplitCellRef(ref)
+	if archiveID == "" {
+		archiveID, cellID = cellID, ""
+	}
+	if archiveID == "" || archiveID == "current" {
+		return validationErrorf("archive id is required")
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	result, err := svc.ImportArchiveLineage(archiveID, cellID, branch)
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		imported := make([]string, 0, len(result.Imported))
+		for _, cell := range result.Imported {
+			imported = append(imported, cell.ID)
+		}
+		return writeCommandSuccessJSON(out, "archives import", map[string]any{
+			"archive_id":   result.ArchiveID,
+			"branch":       result.Branch,
+			"head_cell_id": result.Head.ID,
+			"origin":       *result.Head.Origin,
+			"imported":     imported,
+		})
+	}
+	for _, cell := range result.Imported {
+		fmt.Fprintf(out, "Imported %s as %s\n", *cell.Origin, cell.ID)
+	}
+	fmt.Fprintf(out, "Created branch %s at %s (%d new cells)\n", result.Branch, result.Head.ID, len(result.Imported))
+	fmt.Fprintf(out, "Switch to it with: converge switch %s\n", result.Branch)
+	return nil
 }
 
 func runArchives(projectDir string) error {
@@ -50,3 +127,234 @@ func runArchives(projectDir string) error {
 	}
 	return nil
 }
+
+func runArchivesImportFile(projectDir, path string, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	meta, err := svc.ImportArchiveBundle(file)
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "archives import", map[string]any{
+			"archive_id": meta.ArchiveID,
+			"archive":    meta,
+			"file":       path,
+		})
+	}
+	fmt.Fprintf(out, "Imported archive %s (%d cells) from %s\n", meta.ArchiveID, meta.CellCount, path)
+	return nil
+}
+
+func newArchivesPruneCmd() *cobra.Command {
+	var keep int
+	var olderThan string
+	var dryRun bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old archived states",
+		Long:  "Deletes archives beyond the newest --keep that are also older than --older-than (e.g. 30d, 2w, 12h), then removes shared blobs no remaining archive uses.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runArchivesPrune(cwd, keep, olderThan, dryRun, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().IntVar(&keep, "keep", 0, "Number of newest archives to always keep")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Only prune archives at least this old (e.g. 30d, 2w, 12h)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List archives that would be pruned without deleting them")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runArchivesPrune(projectDir string, keep int, olderThan string, dryRun bool, outputJSON bool, out io.Writer) error {
+	if keep < 0 {
+		return validationErrorf("--keep cannot be negative")
+	}
+	age, err := parseAge(olderThan)
+	if err != nil {
+		return validationErrorf("invalid --older-than %q: %v", olderThan, err)
+	}
+	if keep == 0 && age == 0 {
+		return validationErrorf("prune requires --keep or --older-than")
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	result, err := svc.PruneArchives(core.PruneOptions{Keep: keep, OlderThan: age, DryRun: dryRun})
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		removed := make([]string, 0, len(result.Removed))
+		for _, archive := range result.Removed {
+			removed = append(removed, archive.ArchiveID)
+		}
+		return writeCommandSuccessJSON(out, "archives prune", map[string]any{
+			"dry_run":     result.DryRun,
+			"removed":     removed,
+			"blobs_freed": result.BlobsFreed,
+			"bytes_freed": result.BytesFreed,
+		})
+	}
+	if len(result.Removed) == 0 {
+		fmt.Fprintln(out, "No archives to prune")
+		return nil
+	}
+	verb := "Pruned"
+	if result.DryRun {
+		verb = "Would prune"
+	}
+	for _, archive := range result.Removed {
+		fmt.Fprintf(out, "%s %s\tcommit=%s\tarchived_at=%s\n", verb, archive.ArchiveID, shortID(archive.CommitSHA), archive.ArchivedAt)
+	}
+	if !result.DryRun {
+		fmt.Fprintf(out, "Freed %d shared blobs (%d bytes)\n", result.BlobsFreed, result.BytesFreed)
+	}
+	return nil
+}
+
+// parseAge parses a time.Duration, also accepting whole days (30d) and
+// weeks (2w).
+func parseAge(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[raw[len(raw)-1]]; ok {
+		value, err := strconv.Atoi(raw[:len(raw)-1])
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("expected a whole number before %q", raw[len(raw)-1:])
+		}
+		return time.Duration(value) * unit, nil
+	}
+	age, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("age cannot be negative")
+	}
+	return age, nil
+}
+
+func newArchivesCompactCmd() *cobra.Command {
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Deduplicate archived blobs into a shared store",
+		Long:  "Moves blobs from each archive into a store shared by all archives, dropping copies it already holds. New archives are compacted when they are created; this migrates older ones.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runArchivesCompact(cwd, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runArchivesCompact(projectDir string, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	result, err := svc.CompactArchives()
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "archives compact", map[string]any{
+			"archives":     result.Archives,
+			"blobs_moved":  result.BlobsMoved,
+			"deduplicated": result.Deduplicated,
+			"bytes_freed":  result.BytesFreed,
+		})
+	}
+	fmt.Fprintf(out, "Compacted %d archives: %d blobs moved, %d duplicates removed (%d bytes freed)\n",
+		result.Archives, result.BlobsMoved, result.Deduplicated, result.BytesFreed)
+	return nil
+}
+
+func newArchivesExportCmd() *cobra.Command {
+	var output string
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "export <archive>",
+		Short: "Write an archive to a portable .tar.zst file",
+		Long:  "Writes the archive's metadata, database, and every blob it uses to a compressed tar that `converge archives import` accepts on another machine. The output is zstd-compressed unless its name ends in .gz or .tgz.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runArchivesExport(cwd, args[0], output, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default <archive>.tar.zst)")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runArchivesExport(projectDir, archiveID, output string, outputJSON bool, out io.Writer) error {
+	archiveID = strings.TrimSpace(archiveID)
+	if output == "" {
+		output = archiveID + ".tar.zst"
+	}
+	compression := core.ArchiveCompressionFor(output)
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return conflictErrorf("%s already exists", output)
+		}
+		return err
+	}
+	if err := svc.ExportArchive(archiveID, file, compression); err != nil {
+		file.Close()
+		_ = os.Remove(output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "archives export", map[string]any{
+			"archive_id":  archiveID,
+			"file":        output,
+			"compression": compression,
+		})
+	}
+	fmt.Fprintf(out, "Exported %s to %s\n", archiveID, output)
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/prit3010/converge/internal/bench"
+	"github.com/spf13/cobra"
+)
+
+type benchComparisonJSON struct {
+	Name        string    `json:"name"`
+	Unit        string    `json:"unit"`
+	OldMean     float64   `json:"old_mean"`
+	NewMean     float64   `json:"new_mean"`
+	OldValues   []float64 `json:"old_values"`
+	NewValues   []float64 `json:"new_values"`
+	DeltaPct    float64   `json:"delta_pct"`
+	P           float64   `json:"p"`
+	Significant bool      `json:"significant"`
+}
+
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Inspect benchmark metrics recorded by eval",
+	}
+	cmd.AddCommand(newBenchCompareCmd())
+	return cmd
+}
+
+func newBenchCompareCmd() *cobra.Command {
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "compare <cellA> <cellB>",
+		Short: "Compare benchmark metrics between two cells with a significance test",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runBenchCompare(cwd, args[0], args[1], outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runBenchCompare(projectDir, cellA, cellB string, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	if cellA, err = resolveCellID(svc.DB, cellA); err != nil {
+		return err
+	}
+	if cellB, err = resolveCellID(svc.DB, cellB); err != nil {
+		return err
+	}
+	comparisons, err := svc.CompareBench(cellA, cellB)
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		rows := make([]benchComparisonJSON, 0, len(comparisons))
+		for _, c := range comparisons {
+			rows = append(rows, benchComparisonJSON{
+				Name:        c.Name,
+				Unit:        c.Unit,
+				OldMean:     c.Old.Mean,
+				NewMean:     c.New.Mean,
+				OldValues:   c.Old.Values,
+				NewValues:   c.New.Values,
+				DeltaPct:    c.DeltaPct,
+				P:           c.P,
+				Significant: c.Significant,
+			})
+		}
+		return writeCommandSuccessJSON(out, "bench compare", map[string]any{
+			"cell_a":      cellA,
+			"cell_b":      cellB,
+			"alpha":       bench.Alpha,
+			"comparisons": rows,
+		})
+	}
+
+	if len(comparisons) == 0 {
+		fmt.Fprintf(out, "No shared benchmark metrics between %s and %s.\n", cellA, cellB)
+		fmt.Fprintln(out, "Configure [eval] bench commands in .converge/config.toml and run 'converge eval <cell>'.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "name\tunit\t%s\t%s\tdelta\n", cellA, cellB)
+	for _, c := range comparisons {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Name, c.Unit, formatBenchSummary(c.Old), formatBenchSummary(c.New), formatBenchDelta(c))
+	}
+	return tw.Flush()
+}
+
+func formatBenchSummary(s bench.Summary) string {
+	return fmt.Sprintf("%s ± %.0f%%", formatBenchValue(s.Mean), s.Spread()*100)
+}
+
+func formatBenchDelta(c bench.Comparison) string {
+	detail := fmt.Sprintf("(p=%.3f n=%d+%d)", c.P, len(c.Old.Values), len(c.New.Values))
+	if !c.Significant {
+		return "~ " + detail
+	}
+	return fmt.Sprintf("%+.2f%% %s", c.DeltaPct, detail)
+}
+
+func formatBenchValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return fmt.Sprintf("%.3gG", v/1e9)
+	case abs >= 1e6:
+		return fmt.Sprintf("%.3gM", v/1e6)
+	case abs >= 1e3:
+		return fmt.Sprintf("%.3gk", v/1e3)
+	default:
+		return fmt.Sprintf("%.3g", v)
+	}
+}
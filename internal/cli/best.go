@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/spf13/cobra"
+)
+
+func newBestCmd() *cobra.Command {
+	var branch string
+	var rescore bool
+	var noColor bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "best",
+		Short: "Show the best cell by score (or the eval heuristic when no score is configured)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runBest(cwd, branch, rescore, noColor, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&branch, "branch", "", "Only consider cells on this branch")
+	cmd.Flags().BoolVar(&rescore, "rescore", false, "Recompute every cell's score from the current [score] config first")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in output")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runBest(projectDir string, branch string, rescore bool, noColor bool, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	if rescore {
+		if _, err := svc.RescoreCells(); err != nil {
+			return err
+		}
+	}
+
+	all, err := svc.DB.ListAllCells()
+	if err != nil {
+		return err
+	}
+	branch = strings.TrimSpace(branch)
+	cells := make([]db.Cell, 0, len(all))
+	for _, cell := range all {
+		if branch == "" || cell.Branch == branch {
+			cells = append(cells, cell)
+		}
+	}
+	if len(cells) == 0 {
+		if branch != "" {
+			return notFoundErrorf("no cells on branch %q", branch)
+		}
+		return notFoundErrorf("no cells yet")
+	}
+
+	winner, basis := core.PickWinner(cells)
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "best", map[string]any{
+			"branch":     branch,
+			"basis":      basis,
+			"candidates": len(cells),
+			"cell":       winner,
+		})
+	}
+
+	palette := newLogPalette(noColor)
+	if basis == core.WinnerBasisScore {
+		fmt.Fprintf(out, "Best of %d cells by score\n\n", len(cells))
+	} else {
+		fmt.Fprintf(out, "Best of %d cells by eval heuristic (configure [score] to rank by score)\n\n", len(cells))
+	}
+	headCellID := ""
+	if v, err := svc.DB.GetMeta("head_cell"); err == nil {
+		headCellID = strings.TrimSpace(v)
+	}
+	printCell(out, *winner, parentCoverage(svc.DB, *winner, nil), winner.ID == headCellID, palette)
+	return nil
+}
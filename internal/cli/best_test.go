@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestRunBestAndLogSortByScore(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	configPath := filepath.Join(projectDir, config.StateDirName, config.ConfigFileName)
+	if err := os.WriteFile(configPath, []byte("[score]\nexpression = \"-total_loc\"\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	for _, contents := range []string{"a\nb\n", "a\n", "a\nb\nc\n"} {
+		if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte(contents), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		if err := runSnap(projectDir, "attempt", "", "", false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runBest(projectDir, "", false, true, true, &out); err != nil {
+		t.Fatalf("run best: %v", err)
+	}
+	var payload struct {
+		Data struct {
+			Basis string `json:"basis"`
+			Cell  struct {
+				ID    string
+				Score *float64
+			} `json:"cell"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode best json: %v\nraw=%s", err, out.String())
+	}
+	if payload.Data.Basis != "score" || payload.Data.Cell.ID != "c_000002" {
+		t.Fatalf("expected c_000002 by score, got %+v", payload.Data)
+	}
+
+	out.Reset()
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "score"}, &out); err != nil {
+		t.Fatalf("run log: %v", err)
+	}
+	text := out.String()
+	first := strings.Index(text, "[c_000002]")
+	second := strings.Index(text, "[c_000001]")
+	third := strings.Index(text, "[c_000003]")
+	if first < 0 || !(first < second && second < third) {
+		t.Fatalf("expected cells ordered by score, got:\n%s", text)
+	}
+	if !strings.Contains(text, "score : -") {
+		t.Fatalf("expected score line in log output:\n%s", text)
+	}
+
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "size"}, &out); err == nil {
+		t.Fatalf("expected invalid --sort to fail")
+	}
+
+	// Changing the policy rescores stored cells without --rescore.
+	if err := os.WriteFile(configPath, []byte("[score]\nexpression = \"total_loc\"\n"), 0o644); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	out.Reset()
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "score"}, &out); err != nil {
+		t.Fatalf("run log after policy change: %v", err)
+	}
+	text = out.String()
+	if first, last := strings.Index(text, "[c_000003]"), strings.Index(text, "[c_000002]"); first < 0 || first > last {
+		t.Fatalf("expected cells reordered by the new score, got:\n%s", text)
+	}
+	out.Reset()
+	if err := runBest(projectDir, "", false, true, true, &out); err != nil {
+		t.Fatalf("run best after policy change: %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode best json: %v\nraw=%s", err, out.String())
+	}
+	if payload.Data.Cell.ID != "c_000003" {
+		t.Fatalf("expected c_000003 after policy change, got %+v", payload.Data)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/spf13/cobra"
+)
+
+type bisectOptions struct {
+	Good    string
+	Bad     string
+	Command string
+}
+
+func newBisectCmd() *cobra.Command {
+	var opts bisectOptions
+	var noColor bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "bisect --good <cell> [--bad <cell>] [--run <cmd>]",
+		Short: "Find the first bad cell between a good and a bad cell",
+		Long:  "Binary-searches the parent chain from --bad (HEAD by default) back to --good. Each candidate is materialized in a temporary directory and judged by --run (exit 0 is good) or, without it, the configured eval (failing tests are bad). Every verdict is recorded on its cell.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runBisect(cwd, opts, noColor, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&opts.Good, "good", "", "Cell known to be good")
+	cmd.Flags().StringVar(&opts.Bad, "bad", core.HeadRevision, "Cell known to be bad")
+	cmd.Flags().StringVar(&opts.Command, "run", "", "Shell command judging each candidate; exit 0 means good")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in output")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runBisect(projectDir string, opts bisectOptions, noColor bool, outputJSON bool, out io.Writer) error {
+	if strings.TrimSpace(opts.Good) == "" {
+		return validationErrorf("--good is required")
+	}
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	goodID, err := resolveCellID(svc.DB, opts.Good)
+	if err != nil {
+		return err
+	}
+	badRef := strings.TrimSpace(opts.Bad)
+	if badRef == "" {
+		badRef = core.HeadRevision
+	}
+	badID, err := resolveCellID(svc.DB, badRef)
+	if err != nil {
+		return err
+	}
+	report, err := svc.Bisect(context.Background(), core.BisectOptions{
+		Good:    goodID,
+		Bad:     badID,
+		Command: strings.TrimSpace(opts.Command),
+	})
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "bisect", map[string]any{
+			"good":       goodID,
+			"bad":        badID,
+			"command":    strings.TrimSpace(opts.Command),
+			"first_bad":  report.FirstBad,
+			"last_good":  report.LastGood,
+			"candidates": report.Candidates,
+			"steps":      report.Steps,
+		})
+	}
+
+	palette := newLogPalette(noColor)
+	fmt.Fprintf(out, "Bisecting %d cells between %s (good) and %s (bad)\n", report.Candidates, goodID, badID)
+	for _, step := range report.Steps {
+		verdict := palette.red("bad ")
+		if step.Good {
+			verdict = palette.green("good")
+		}
+		detail := lastLine(step.Output)
+		if step.ExitCode != nil {
+			detail = fmt.Sprintf("exit %d", *step.ExitCode)
+		}
+		fmt.Fprintf(out, "  %s %s  %s\n", palette.cyan(step.CellID), verdict, palette.dim(detail))
+	}
+	firstBad, err := svc.DB.GetCell(report.FirstBad)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "First bad cell: %s", palette.bold(firstBad.ID))
+	if firstBad.Agent != nil {
+		fmt.Fprintf(out, " [%s]", *firstBad.Agent)
+	}
+	if firstBad.Message != "" {
+		fmt.Fprintf(out, " %s", firstBad.Message)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
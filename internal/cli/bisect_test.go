@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBisectWithCommand(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	status := filepath.Join(projectDir, "status.txt")
+	for i, content := range []string{"ok\n", "ok\n1\n", "fail\n", "fail\n3\n"} {
+		if err := os.WriteFile(status, []byte(content), 0o644); err != nil {
+			t.Fatalf("write status.txt: %v", err)
+		}
+		agent := ""
+		if i == 2 {
+			agent = "codex"
+		}
+		if err := runSnap(projectDir, "step", "", agent, false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runBisect(projectDir, bisectOptions{Good: "c_000001", Command: "grep -q ok status.txt"}, true, false, &out); err != nil {
+		t.Fatalf("run bisect: %v", err)
+	}
+	for _, want := range []string{"Bisecting 2 cells between c_000001 (good) and c_000004 (bad)", "First bad cell: c_000003 [codex] step"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in bisect output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runShow(projectDir, "c_000003", true, true, false, &out); err != nil {
+		t.Fatalf("run show: %v", err)
+	}
+	if !strings.Contains(out.String(), "Bisect (1):\n  bad  grep -q ok status.txt") {
+		t.Fatalf("expected bisect verdict in show output:\n%s", out.String())
+	}
+
+	err := runBisect(projectDir, bisectOptions{Good: "c_000004", Bad: "c_000001", Command: "true"}, true, false, &bytes.Buffer{})
+	if classifyCommandError(err).Code != ErrorCodeValidation {
+		t.Fatalf("expected validation error for reversed range, got %v", err)
+	}
+}
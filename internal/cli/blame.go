@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/spf13/cobra"
+)
+
+const blameMessageWidth = 28
+
+func newBlameCmd() *cobra.Command {
+	var noColor bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "blame <path> [<cell>]",
+		Short: "Show which cell introduced each line of a file",
+		Long:  "Attributes each line of a file at a cell (HEAD by default) to the cell, agent, and message that introduced it, walking the cell's parents.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			ref := core.HeadRevision
+			if len(args) == 2 {
+				ref = args[1]
+			}
+			return runBlame(cwd, args[0], ref, noColor, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in output")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runBlame(projectDir, path, ref string, noColor bool, outputJSON bool, out io.Writer) error {
+	path = filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+	if path == "." || path == "" {
+		return validationErrorf("path is required")
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+	lines, err := svc.Blame(cellID, path)
+	if errors.Is(err, db.ErrNotFound) {
+		return notFoundErrorf("file %s not found in %s", path, cellID)
+	}
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "blame", map[string]any{
+			"cell_id": cellID,
+			"path":    path,
+			"lines":   lines,
+		})
+	}
+
+	agentWidth, numberWidth := 0, len(fmt.Sprint(len(lines)))
+	for _, line := range lines {
+		agentWidth = max(agentWidth, len(blameAgent(line)))
+	}
+	palette := newLogPalette(noColor)
+	previous := ""
+	for _, line := range lines {
+		// Like git blame, only the first line of each run names the cell.
+		label := strings.Repeat(" ", len(line.CellID)+agentWidth+blameMessageWidth+2)
+		if line.CellID != previous {
+			label = fmt.Sprintf("%s %-*s %-*s", palette.cyan(line.CellID), agentWidth, blameAgent(line), blameMessageWidth, truncateText(line.Message, blameMessageWidth))
+		}
+		previous = line.CellID
+		fmt.Fprintf(out, "%s %s %s\n", label, palette.dim(fmt.Sprintf("%*d|", numberWidth, line.Line)), line.Text)
+	}
+	return nil
+}
+
+func blameAgent(line core.BlameLine) string {
+	if line.Agent == nil {
+		return "-"
+	}
+	return *line.Agent
+}
+
+func truncateText(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-3]) + "..."
+}
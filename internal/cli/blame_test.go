@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBlameAndFileLog(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	for _, step := range []struct{ file, content, message string }{
+		{"main.go", "package main\n", "scaffold"},
+		{"README.md", "# demo\n", "docs"},
+		{"main.go", "package main\n\nfunc main() {}\n", "add entrypoint"},
+	} {
+		if err := os.WriteFile(filepath.Join(projectDir, step.file), []byte(step.content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", step.file, err)
+		}
+		if err := runSnap(projectDir, step.message, "", "codex", false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, Format: "{{.ID}}", Paths: []string{"main.go"}}, &out); err != nil {
+		t.Fatalf("run log -- main.go: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "c_000003\nc_000001" {
+		t.Fatalf("unexpected file log %q", got)
+	}
+
+	out.Reset()
+	if err := runBlame(projectDir, "./main.go", "HEAD", true, false, &out); err != nil {
+		t.Fatalf("run blame: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "c_000001 codex scaffold") || !strings.HasPrefix(lines[1], "c_000003 codex add entrypoint") {
+		t.Fatalf("unexpected blame output:\n%s", out.String())
+	}
+	if !strings.HasSuffix(lines[2], "3| func main() {}") {
+		t.Fatalf("expected line numbers and text in blame output:\n%s", out.String())
+	}
+	err := runBlame(projectDir, "README.md", "c_000001", true, false, &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeNotFound {
+		t.Fatalf("expected NOT_FOUND for a file missing at the cell, got %v", err)
+	}
+}
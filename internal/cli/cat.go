@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newCatCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cat <cell> <path>",
+		Short: "Print a file's contents as of a cell",
+		Long:  "Writes the stored contents of path at the given cell to stdout without restoring. The cell may be WORKTREE or an archived <archive>:<cell>.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runCat(cwd, args[0], args[1], cmd.OutOrStdout())
+		},
+	}
+}
+
+func runCat(projectDir, ref, path string, out io.Writer) error {
+	path = filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+	if path == "." || path == "" {
+		return validationErrorf("path is required")
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	views, err := loadCellViews(svc, ref)
+	if err != nil {
+		return err
+	}
+	view := views[0]
+	for _, entry := range view.Manifest {
+		if entry.Path != path {
+			continue
+		}
+		blob, err := view.Blobs.Open(entry.Hash)
+		if err != nil {
+			return err
+		}
+		defer blob.Close()
+		_, err = io.Copy(out, blob)
+		return err
+	}
+	return notFoundErrorf("file %s not found in %s", path, ref)
+}
@@ -14,46 +14,75 @@ import (
 
 const compareTimeout = 45 * time.Second
 
+// tokensPerDiffLine converts the deprecated --max-diff-lines to a token
+// budget.
+const tokensPerDiffLine = 10
+
 func newCompareCmd() *cobra.Command {
 	var model string
+	var maxTokens int
 	var maxDiffLines int
+	var refresh bool
+	var showPrompt bool
 	var outputJSON bool
 	cmd := &cobra.Command{
 		Use:   "compare <cellA> <cellB>",
 		Short: "Use AI to summarize semantic differences between two cells",
+		Long:  "Asks the configured model to compare cellA with cellB. Either side may be WORKTREE for the uncommitted working tree, or <archive>:<cell> for an archived cell; those results are not stored.",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cwd, err := os.Getwd()
 			if err != nil {
 				return err
 			}
-			return runCompare(cwd, args[0], args[1], model, maxDiffLines, outputJSON, cmd.OutOrStdout())
+			if maxTokens == 0 && cmd.Flags().Changed("max-diff-lines") {
+				maxTokens = maxDiffLines * tokensPerDiffLine
+			}
+			return runCompare(cwd, args[0], args[1], model, maxTokens, refresh, showPrompt, outputJSON, cmd.OutOrStdout())
 		},
 	}
-	cmd.Flags().StringVar(&model, "model", "", "OpenAI model to use (default gpt-4o-mini)")
-	cmd.Flags().IntVar(&maxDiffLines, "max-diff-lines", 800, "Maximum diff lines to send to the model")
+	cmd.Flags().StringVar(&model, "model", "", "Model to use (default from [llm] config or the provider default)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Prompt token budget (default derived from the model's context window)")
+	cmd.Flags().IntVar(&maxDiffLines, "max-diff-lines", 0, "Maximum diff lines to send to the model")
+	_ = cmd.Flags().MarkDeprecated("max-diff-lines", "use --max-tokens")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Ignore a stored result for this pair and call the model again")
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the exact prompt sent to the model")
 	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
 	return cmd
 }
 
-func runCompare(projectDir, cellA, cellB, model string, maxDiffLines int, outputJSON bool, out io.Writer) error {
+func runCompare(projectDir, cellA, cellB, model string, maxTokens int, refresh bool, showPrompt bool, outputJSON bool, out io.Writer) error {
 	svc, err := openService(projectDir)
 	if err != nil {
 		return err
 	}
 	defer svc.DB.Close()
 
-	comparer := llm.NewComparer(svc.DB, svc.Store)
+	comparer := llm.NewComparer(svc.DB, svc.Store, svc.Policy.LLM)
+	views, err := loadCellViews(svc, cellA, cellB)
+	if err != nil {
+		return err
+	}
+	for _, view := range views {
+		if view.External {
+			comparer.AddCell(view.Cell, view.Manifest, view.Blobs)
+		}
+	}
 	if !outputJSON {
 		fmt.Fprintf(out, "Comparing %s -> %s ...\n\n", cellA, cellB)
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), compareTimeout)
 	defer cancel()
 
-	result, err := comparer.Compare(ctx, cellA, cellB, llm.CompareOptions{
-		Model:        strings.TrimSpace(model),
-		MaxDiffLines: maxDiffLines,
+	result, err := comparer.Compare(ctx, views[0].Cell.ID, views[1].Cell.ID, llm.CompareOptions{
+		Model:      strings.TrimSpace(model),
+		MaxTokens:  maxTokens,
+		ShowPrompt: showPrompt,
+		Refresh:    refresh,
 	})
+	if showPrompt && !outputJSON && result != nil {
+		writeSentPrompt(out, result.Prompt)
+	}
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("compare timed out after %s", compareTimeout)
@@ -65,11 +94,12 @@ func runCompare(projectDir, cellA, cellB, model string, maxDiffLines int, output
 	}
 	if outputJSON {
 		return writeCommandSuccessJSON(out, "compare", map[string]any{
-			"cell_a":         cellA,
-			"cell_b":         cellB,
-			"model":          strings.TrimSpace(model),
-			"max_diff_lines": maxDiffLines,
-			"result":         result,
+			"cell_a":     cellA,
+			"cell_b":     cellB,
+			"provider":   result.Provider,
+			"model":      result.Model,
+			"max_tokens": maxTokens,
+			"result":     result,
 		})
 	}
 
@@ -81,8 +111,59 @@ func runCompare(projectDir, cellA, cellB, model string, maxDiffLines int, output
 		}
 		fmt.Fprintln(out)
 	}
+	if len(result.Risks) > 0 {
+		fmt.Fprintln(out, "Risks:")
+		for _, risk := range result.Risks {
+			fmt.Fprintf(out, "  - %s\n", risk)
+		}
+		fmt.Fprintln(out)
+	}
+	if len(result.Files) > 0 {
+		fmt.Fprintln(out, "Files:")
+		for _, f := range result.Files {
+			fmt.Fprintf(out, "  %s: %s\n", f.Path, f.Note)
+		}
+		fmt.Fprintln(out)
+	}
 	if strings.TrimSpace(result.Winner) != "" {
-		fmt.Fprintf(out, "Winner: %s\n", result.Winner)
+		if result.WinnerReason != "" {
+			fmt.Fprintf(out, "Winner: %s - %s\n", result.Winner, result.WinnerReason)
+		} else {
+			fmt.Fprintf(out, "Winner: %s\n", result.Winner)
+		}
+	}
+	fmt.Fprintf(out, "\nModel: %s (%s)%s\n", result.Model, result.Provider, formatUsage(result.Usage))
+	if result.Cached {
+		fmt.Fprintf(out, "Stored result from %s (use --refresh to re-run)\n", result.CreatedAt)
 	}
 	return nil
 }
+
+func formatUsage(usage *llm.Usage) string {
+	if usage == nil {
+		return ""
+	}
+	parts := make([]string, 0, 2)
+	if usage.PromptTokens != nil {
+		parts = append(parts, fmt.Sprintf("%d prompt", *usage.PromptTokens))
+	}
+	if usage.CompletionTokens != nil {
+		parts = append(parts, fmt.Sprintf("%d completion", *usage.CompletionTokens))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " | tokens " + strings.Join(parts, ", ")
+}
+
+// writeSentPrompt prints the request exactly as sent, for --show-prompt.
+func writeSentPrompt(out io.Writer, prompt *llm.SentPrompt) {
+	if prompt == nil {
+		return
+	}
+	fmt.Fprintf(out, "=== prompt (~%d of %d tokens) ===\n", prompt.EstimatedTokens, prompt.BudgetTokens)
+	fmt.Fprintf(out, "--- system ---\n%s\n", prompt.System)
+	fmt.Fprintf(out, "--- schema ---\n%s\n", prompt.Schema)
+	fmt.Fprintf(out, "--- user ---\n%s\n", strings.TrimRight(prompt.User, "\n"))
+	fmt.Fprintf(out, "=== end prompt ===\n\n")
+}
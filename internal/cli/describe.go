@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+func newDescribeCmd() *cobra.Command {
+	var model string
+	var quiet bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "describe [cell...]",
+		Short: "Use AI to summarize a cell's diff into a one-line message",
+		Long:  "Summarizes each cell's diff against its parent and stores it as the cell summary. The cell's own message is never changed. Defaults to the head cell.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runDescribe(cwd, args, model, quiet, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&model, "model", "", "Model to use (default from [llm] config or the provider default)")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Print nothing; used by background auto_message runs")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runDescribe(projectDir string, cellIDs []string, model string, quiet bool, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	if len(cellIDs) == 0 {
+		head, err := svc.DB.GetMeta("head_cell")
+		if err != nil || strings.TrimSpace(head) == "" {
+			return validationErrorf("no head cell to describe; pass a cell ID")
+		}
+		cellIDs = []string{strings.TrimSpace(head)}
+	}
+
+	comparer := llm.NewComparer(svc.DB, svc.Store, svc.Policy.LLM)
+	results := make([]*llm.DescribeResult, 0, len(cellIDs))
+	for _, id := range cellIDs {
+		id, err := resolveCellID(svc.DB, id)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), compareTimeout)
+		result, err := comparer.Describe(ctx, id, llm.DescribeOptions{Model: strings.TrimSpace(model)})
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+		if err != nil {
+			if timedOut {
+				return fmt.Errorf("describe timed out after %s", compareTimeout)
+			}
+			if result != nil && result.Error != "" {
+				return externalErrorf("describe %s failed: %s", id, result.Error)
+			}
+			return err
+		}
+		results = append(results, result)
+	}
+
+	switch {
+	case quiet:
+		return nil
+	case outputJSON:
+		return writeCommandSuccessJSON(out, "describe", map[string]any{"results": results})
+	}
+	for _, result := range results {
+		fmt.Fprintf(out, "%s: %s\n", result.CellID, result.Summary)
+	}
+	return nil
+}
+
+// autoDescribe starts a detached `converge describe` for new cells when
+// [llm] auto_message is on, so capture never waits on the model. Failures
+// only cost the summary, so they are ignored.
+func autoDescribe(svc *core.Service, cellIDs ...string) {
+	if !svc.Policy.LLM.AutoMessage || len(cellIDs) == 0 {
+		return
+	}
+	args := append([]string{"describe", "--quiet"}, cellIDs...)
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = svc.ProjectDir
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	_ = cmd.Process.Release()
+}
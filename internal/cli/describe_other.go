@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cli
+
+import "os/exec"
+
+// detachProcess is a no-op where sessions are not available.
+func detachProcess(cmd *exec.Cmd) {}
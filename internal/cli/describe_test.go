@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestRunDescribeStoresSummaryShownByLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": `{"summary":"Add greeting notes"}`},
+		})
+	}))
+	defer server.Close()
+
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	configPath := filepath.Join(projectDir, config.StateDirName, config.ConfigFileName)
+	if err := os.WriteFile(configPath, []byte("[llm]\nprovider = \"ollama\"\nbase_url = \""+server.URL+"\"\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+	if err := runSnap(projectDir, "wip", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runDescribe(projectDir, nil, "", false, false, &out); err != nil {
+		t.Fatalf("run describe: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "c_000001: Add greeting notes" {
+		t.Fatalf("unexpected describe output %q", out.String())
+	}
+
+	out.Reset()
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "time"}, &out); err != nil {
+		t.Fatalf("run log: %v", err)
+	}
+	if !strings.Contains(out.String(), `message : "wip"`) || !strings.Contains(out.String(), "summary : Add greeting notes") {
+		t.Fatalf("expected message kept and summary shown:\n%s", out.String())
+	}
+}
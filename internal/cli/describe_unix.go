@@ -0,0 +1,14 @@
+//go:build unix
+
+package cli
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in a new session, which keeps Ctrl+C on
+// `converge watch` from killing in-flight summaries.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
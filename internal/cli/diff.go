@@ -1,13 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
 	"github.com/prit3010/converge/internal/diff"
 	"github.com/prit3010/converge/internal/snapshot"
+	"github.com/prit3010/converge/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +19,7 @@ type diffFileJSON struct {
 	Path   string `json:"path"`
 	Status string `json:"status"`
 	Patch  string `json:"patch,omitempty"`
+	Binary bool   `json:"binary,omitempty"`
 }
 
 func newDiffCmd() *cobra.Command {
@@ -23,6 +28,7 @@ func newDiffCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "diff <cellA> <cellB>",
 		Short: "Show differences between two cells",
+		Long:  "Shows file and line differences from cellA to cellB. Either side may be WORKTREE for the uncommitted working tree, or <archive>:<cell> for a cell archived at a git commit.",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cwd, err := os.Getwd()
@@ -44,47 +50,12 @@ func runDiff(projectDir, cellA, cellB string, noColor bool, outputJSON bool, out
 	}
 	defer svc.DB.Close()
 
-	if _, err := svc.DB.GetCell(cellA); err != nil {
-		return notFoundErrorf("cell %s not found", cellA)
-	}
-	if _, err := svc.DB.GetCell(cellB); err != nil {
-		return notFoundErrorf("cell %s not found", cellB)
-	}
-
-	manifestAEntries, err := svc.DB.GetManifest(cellA)
+	views, err := loadCellViews(svc, cellA, cellB)
 	if err != nil {
 		return err
 	}
-	manifestBEntries, err := svc.DB.GetManifest(cellB)
-	if err != nil {
-		return err
-	}
-	mapA := make(map[string]string, len(manifestAEntries))
-	for _, entry := range manifestAEntries {
-		mapA[entry.Path] = entry.Hash
-	}
-	mapB := make(map[string]string, len(manifestBEntries))
-	for _, entry := range manifestBEntries {
-		mapB[entry.Path] = entry.Hash
-	}
-
-	result := diff.CompareManifests(mapA, mapB)
-	files := make([]diffFileJSON, 0, len(result.Added)+len(result.Modified)+len(result.Removed))
-	for _, path := range result.Added {
-		files = append(files, diffFileJSON{Path: path, Status: "added"})
-	}
-	for _, path := range result.Removed {
-		files = append(files, diffFileJSON{Path: path, Status: "removed"})
-	}
-	for _, path := range result.Modified {
-		file := diffFileJSON{Path: path, Status: "modified"}
-		oldData, errOld := svc.Store.Read(mapA[path])
-		newData, errNew := svc.Store.Read(mapB[path])
-		if errOld == nil && errNew == nil && snapshot.IsText(oldData) && snapshot.IsText(newData) {
-			file.Patch = diff.UnifiedDiff(path, string(oldData), string(newData))
-		}
-		files = append(files, file)
-	}
+	blobs := store.Chain{views[0].Blobs, views[1].Blobs}
+	result, files := diffManifests(views[0].Manifest, views[1].Manifest, blobs)
 
 	totalChanged := len(result.Added) + len(result.Modified) + len(result.Removed)
 	if outputJSON {
@@ -137,26 +108,7 @@ func runDiff(projectDir, cellA, cellB string, noColor bool, outputJSON bool, out
 		}
 		fmt.Fprintln(out)
 
-		for _, path := range result.Modified {
-			oldData, err := svc.Store.Read(mapA[path])
-			if err != nil {
-				continue
-			}
-			newData, err := svc.Store.Read(mapB[path])
-			if err != nil {
-				continue
-			}
-			if !snapshot.IsText(oldData) || !snapshot.IsText(newData) {
-				fmt.Fprintf(out, "%s %s\n", palette.dim("binary diff skipped for"), path)
-				continue
-			}
-			unified := diff.UnifiedDiff(path, string(oldData), string(newData))
-			if unified != "" {
-				fmt.Fprintf(out, "%s %s\n", palette.cyan("Patch:"), palette.bold(path))
-				fmt.Fprintln(out, colorizeUnifiedDiff(unified, palette))
-				fmt.Fprintln(out)
-			}
-		}
+		writePatches(out, files, palette)
 	}
 	if len(result.Added) == 0 && len(result.Modified) == 0 && len(result.Removed) == 0 {
 		fmt.Fprintln(out, palette.green("No differences."))
@@ -164,6 +116,57 @@ func runDiff(projectDir, cellA, cellB string, noColor bool, outputJSON bool, out
 	return nil
 }
 
+// diffManifests compares two manifests and builds per-file entries, with a
+// unified patch for each modified text file.
+func diffManifests(from, to []db.ManifestEntry, blobs store.Reader) (diff.Result, []diffFileJSON) {
+	mapA := make(map[string]string, len(from))
+	for _, entry := range from {
+		mapA[entry.Path] = entry.Hash
+	}
+	mapB := make(map[string]string, len(to))
+	for _, entry := range to {
+		mapB[entry.Path] = entry.Hash
+	}
+
+	result := diff.CompareManifests(mapA, mapB)
+	files := make([]diffFileJSON, 0, len(result.Added)+len(result.Modified)+len(result.Removed))
+	for _, path := range result.Added {
+		files = append(files, diffFileJSON{Path: path, Status: "added"})
+	}
+	for _, path := range result.Removed {
+		files = append(files, diffFileJSON{Path: path, Status: "removed"})
+	}
+	for _, path := range result.Modified {
+		file := diffFileJSON{Path: path, Status: "modified"}
+		oldData, errOld := blobs.Read(mapA[path])
+		newData, errNew := blobs.Read(mapB[path])
+		if errOld == nil && errNew == nil {
+			if snapshot.IsText(oldData) && snapshot.IsText(newData) {
+				file.Patch = diff.UnifiedDiff(path, string(oldData), string(newData))
+			} else {
+				file.Binary = true
+			}
+		}
+		files = append(files, file)
+	}
+	return result, files
+}
+
+// writePatches prints the patch of each modified file.
+func writePatches(out io.Writer, files []diffFileJSON, palette diffPalette) {
+	for _, file := range files {
+		if file.Binary {
+			fmt.Fprintf(out, "%s %s\n", palette.dim("binary diff skipped for"), file.Path)
+			continue
+		}
+		if file.Patch != "" {
+			fmt.Fprintf(out, "%s %s\n", palette.cyan("Patch:"), palette.bold(file.Path))
+			fmt.Fprintln(out, colorizeUnifiedDiff(file.Patch, palette))
+			fmt.Fprintln(out)
+		}
+	}
+}
+
 func colorizeUnifiedDiff(unified string, palette diffPalette) string {
 	lines := strings.Split(unified, "\n")
 	out := make([]string, 0, len(lines))
@@ -238,3 +241,27 @@ func (p diffPalette) yellow(text string) string {
 func (p diffPalette) cyan(text string) string {
 	return p.wrap("36", text)
 }
+
+// loadCellViews resolves each ref: a cell ID, WORKTREE for the uncommitted
+// working tree, or "<archive>:<cell>" for an archived cell.
+func loadCellViews(svc *core.Service, refs ...string) ([]*core.CellView, error) {
+	views := make([]*core.CellView, 0, len(refs))
+	for _, ref := range refs {
+		view, err := svc.LoadCellView(ref)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return nil, notFoundErrorf("cell %s not found", ref)
+			}
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// isExternalRef reports whether ref names a cell outside the active
+// database.
+func isExternalRef(ref string) bool {
+	archiveID, _ := core.SplitCellRef(ref)
+	return ref == core.WorktreeRef || archiveID != ""
+}
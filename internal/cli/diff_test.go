@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDiffAgainstWorktree(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+	if err := runSnap(projectDir, "first", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatalf("rewrite notes.txt: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runDiff(projectDir, "c_000001", "WORKTREE", true, true, &out); err != nil {
+		t.Fatalf("run diff: %v", err)
+	}
+	var payload struct {
+		Data struct {
+			Files []diffFileJSON `json:"files"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode diff json: %v\n%s", err, out.String())
+	}
+	if len(payload.Data.Files) != 1 || payload.Data.Files[0].Status != "modified" || !strings.Contains(payload.Data.Files[0].Patch, "+world") {
+		t.Fatalf("unexpected worktree diff: %s", out.String())
+	}
+
+	out.Reset()
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "time", OutputJSON: true}, &out); err != nil {
+		t.Fatalf("run log: %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("c_000002")) {
+		t.Fatalf("diff against WORKTREE should not create a cell:\n%s", out.String())
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/eval"
 	"github.com/spf13/cobra"
 )
 
@@ -35,6 +37,10 @@ func runEval(projectDir, cellID string, outputJSON bool, out io.Writer) error {
 	}
 	defer svc.DB.Close()
 
+	cellID, err = resolveCellID(svc.DB, cellID)
+	if err != nil {
+		return err
+	}
 	result, err := svc.EvaluateCell(context.Background(), cellID)
 	if err != nil {
 		return err
@@ -51,10 +57,16 @@ func runEval(projectDir, cellID string, outputJSON bool, out io.Writer) error {
 			"has_types":     result.HasTypes,
 			"skipped":       result.Skipped,
 			"used_override": svc.Policy.Eval.HasOverrides(),
+			"scope":         result.Scope,
+			"coverage_pct":  result.CoveragePctPtr(),
+			"projects":      evalProjectsJSON(result.Projects),
 		})
 	}
 
 	fmt.Fprintf(out, "Eval updated for %s\n", cellID)
+	if result.Scope == config.EvalScopeChanged {
+		fmt.Fprintln(out, "  Scope: changed (only packages affected by this cell's diff)")
+	}
 	if result.HasTests {
 		fmt.Fprintf(out, "  Tests: %d passed, %d failed\n", result.TestsPassed, result.TestsFailed)
 	}
@@ -64,8 +76,50 @@ func runEval(projectDir, cellID string, outputJSON bool, out io.Writer) error {
 	if result.HasTypes {
 		fmt.Fprintf(out, "  Type errors: %d\n", result.TypeErrors)
 	}
+	if result.Coverage != nil {
+		fmt.Fprintf(out, "  Coverage: %.1f%% (%d/%d across %d files)\n", result.Coverage.Percent(), result.Coverage.Covered, result.Coverage.Total, len(result.Coverage.Files))
+	}
 	if len(result.Skipped) > 0 {
 		fmt.Fprintf(out, "  Skipped: %s\n", strings.Join(result.Skipped, ", "))
 	}
+	if len(result.Projects) > 1 || (len(result.Projects) == 1 && result.Projects[0].Dir != ".") {
+		fmt.Fprintln(out, "  Projects:")
+		for _, project := range result.Projects {
+			fmt.Fprintf(out, "    %-24s %s\n", project.Dir, formatProjectEval(project))
+		}
+	}
 	return nil
 }
+
+func formatProjectEval(project eval.ProjectResult) string {
+	parts := []string{eval.ProjectTypesString(project.Types)}
+	if project.HasTests {
+		parts = append(parts, fmt.Sprintf("tests %d/%d", project.TestsPassed, project.TestsPassed+project.TestsFailed))
+	}
+	if project.HasLint {
+		parts = append(parts, fmt.Sprintf("lint %d", project.LintErrors))
+	}
+	if project.HasTypes {
+		parts = append(parts, fmt.Sprintf("types %d", project.TypeErrors))
+	}
+	if !project.HasTests && !project.HasLint && !project.HasTypes {
+		parts = append(parts, "no checks ran")
+	}
+	return strings.Join(parts, "  ")
+}
+
+func evalProjectsJSON(projects []eval.ProjectResult) []map[string]any {
+	out := make([]map[string]any, 0, len(projects))
+	for _, project := range projects {
+		out = append(out, map[string]any{
+			"dir":          project.Dir,
+			"types":        project.Types,
+			"tests_passed": project.TestsPassedPtr(),
+			"tests_failed": project.TestsFailedPtr(),
+			"lint_errors":  project.LintErrorsPtr(),
+			"type_errors":  project.TypeErrorsPtr(),
+			"skipped":      project.Skipped,
+		})
+	}
+	return out
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportFormatPatch = "patch"
+	exportFormatGitAm = "git-am"
+	exportFormatMbox  = "mbox"
+)
+
+var patchFileSlugPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func newExportCmd() *cobra.Command {
+	var format string
+	var output string
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "export <cellA>..<cellB> | <cell>",
+		Short: "Export cells as git-applicable patches",
+		Long:  "Writes the changes from cellA to cellB for review. --format patch prints one combined diff for git apply; mbox prints one mail per cell for git am; git-am writes those mails as numbered .patch files like git format-patch. A single cell exports its change against its parent. File modes are kept and binary files use git's binary patch format.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runExport(cwd, args[0], format, output, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", exportFormatPatch, "Output format: patch, git-am, or mbox")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write (patch, mbox) or directory for .patch files (git-am); defaults to stdout or the current directory")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runExport(projectDir, rangeRef, format, output string, outputJSON bool, out io.Writer) error {
+	format = strings.TrimSpace(format)
+	switch format {
+	case exportFormatPatch, exportFormatGitAm, exportFormatMbox:
+	default:
+		return validationErrorf("invalid --format %q (expected patch, git-am, or mbox)", format)
+	}
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	fromID, toID, err := resolveExportRange(svc, rangeRef)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	var text string
+	var patchCount int
+	switch format {
+	case exportFormatPatch:
+		text, err = svc.DiffCells(fromID, toID)
+		if err != nil {
+			return err
+		}
+		if text != "" {
+			patchCount = 1
+		}
+	default:
+		patches, err := svc.FormatPatches(fromID, toID)
+		if err != nil {
+			return err
+		}
+		patchCount = len(patches)
+		if format == exportFormatMbox {
+			for _, p := range patches {
+				text += p.Text
+			}
+			break
+		}
+		dir := output
+		if dir == "" {
+			dir = "."
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create %s: %w", dir, err)
+		}
+		for n, p := range patches {
+			path := filepath.Join(dir, patchFileName(n+1, p.Subject))
+			if err := os.WriteFile(path, []byte(p.Text), 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			files = append(files, path)
+		}
+	}
+	if patchCount == 0 {
+		return validationErrorf("invalid range %s: no changes to export", rangeRef)
+	}
+
+	if format != exportFormatGitAm && output != "" {
+		if err := os.WriteFile(output, []byte(text), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", output, err)
+		}
+		files = []string{output}
+	}
+
+	if outputJSON {
+		payload := map[string]any{
+			"from":    fromID,
+			"to":      toID,
+			"format":  format,
+			"patches": patchCount,
+			"files":   files,
+		}
+		if len(files) == 0 {
+			payload["content"] = text
+		}
+		return writeCommandSuccessJSON(out, "export", payload)
+	}
+	if len(files) == 0 {
+		_, err := io.WriteString(out, text)
+		return err
+	}
+	for _, path := range files {
+		fmt.Fprintln(out, path)
+	}
+	return nil
+}
+
+// resolveExportRange reads "A..B", or a single cell meaning its parent..cell.
+func resolveExportRange(svc *core.Service, rangeRef string) (string, string, error) {
+	fromRef, toRef, isRange := strings.Cut(strings.TrimSpace(rangeRef), "..")
+	if isRange {
+		if strings.TrimSpace(fromRef) == "" || strings.TrimSpace(toRef) == "" {
+			return "", "", validationErrorf("invalid range %q (expected <cellA>..<cellB>)", rangeRef)
+		}
+		fromID, err := resolveCellID(svc.DB, fromRef)
+		if err != nil {
+			return "", "", err
+		}
+		toID, err := resolveCellID(svc.DB, toRef)
+		if err != nil {
+			return "", "", err
+		}
+		return fromID, toID, nil
+	}
+	toID, err := resolveCellID(svc.DB, fromRef)
+	if err != nil {
+		return "", "", err
+	}
+	cell, err := svc.DB.GetCell(toID)
+	if err != nil {
+		return "", "", err
+	}
+	if cell.ParentID == nil {
+		return "", "", validationErrorf("invalid range: %s has no parent; name both ends as <cellA>..<cellB>", toID)
+	}
+	return *cell.ParentID, toID, nil
+}
+
+// patchFileName names a series file the way git format-patch does.
+func patchFileName(n int, subject string) string {
+	slug := strings.Trim(patchFileSlugPattern.ReplaceAllString(subject, "-"), "-")
+	if len(slug) > 52 {
+		slug = strings.TrimRight(slug[:52], "-")
+	}
+	if slug == "" {
+		slug = "cell"
+	}
+	return fmt.Sprintf("%04d-%s.patch", n, slug)
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunExportAndImportPatch(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	main := filepath.Join(projectDir, "main.go")
+	for i, content := range []string{"package main\n", "package main\n\nfunc main() {}\n", "package main\n\nfunc main() {\n\trun()\n}\n"} {
+		if err := os.WriteFile(main, []byte(content), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		if err := runSnap(projectDir, []string{"base", "add main", "call run"}[i], "", "codex", false, false, io.Discard); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runExport(projectDir, "HEAD", exportFormatPatch, "", false, &out); err != nil {
+		t.Fatalf("run export patch: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "diff --git a/main.go b/main.go\n") || !strings.Contains(out.String(), "+\trun()\n") {
+		t.Fatalf("unexpected patch output:\n%s", out.String())
+	}
+
+	dir := filepath.Join(t.TempDir(), "series")
+	out.Reset()
+	if err := runExport(projectDir, "c_000001..c_000003", exportFormatGitAm, dir, false, &out); err != nil {
+		t.Fatalf("run export git-am: %v", err)
+	}
+	want := filepath.Join(dir, "0001-add-main.patch") + "\n" + filepath.Join(dir, "0002-call-run.patch") + "\n"
+	if out.String() != want {
+		t.Fatalf("unexpected git-am files:\n%s", out.String())
+	}
+
+	mbox := filepath.Join(t.TempDir(), "series.mbox")
+	if err := runExport(projectDir, "c_000001..HEAD", exportFormatMbox, mbox, false, io.Discard); err != nil {
+		t.Fatalf("run export mbox: %v", err)
+	}
+	out.Reset()
+	if err := runImportPatch(projectDir, mbox, importPatchOptions{Onto: "c_000001"}, true, nil, &out); err != nil {
+		t.Fatalf("run import-patch: %v", err)
+	}
+	var payload struct {
+		OK   bool `json:"ok"`
+		Data struct {
+			Branch  string   `json:"branch"`
+			CellIDs []string `json:"cell_ids"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode import-patch json: %v\n%s", err, out.String())
+	}
+	if payload.Data.Branch != "import/series" || len(payload.Data.CellIDs) != 2 {
+		t.Fatalf("unexpected import-patch payload: %s", out.String())
+	}
+	out.Reset()
+	if err := runExport(projectDir, "c_000003.."+payload.Data.CellIDs[1], exportFormatPatch, "", false, &out); err == nil {
+		t.Fatalf("expected unrelated range to fail, got:\n%s", out.String())
+	}
+	got, err := os.ReadFile(main)
+	if err != nil || !strings.Contains(string(got), "run()") {
+		t.Fatalf("expected working tree untouched, got %q (err %v)", got, err)
+	}
+
+	if err := runExport(projectDir, "HEAD", "zip", "", false, io.Discard); err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Fatalf("expected invalid format error, got %v", err)
+	}
+}
@@ -179,6 +179,17 @@ func ensureProjectHookScript(projectDir, scriptName string, out io.Writer) (stri
 	return scriptPath, nil
 }
 
+// managedPostCommitInstalled reports whether the project's git post-commit
+// hook is the Converge-managed one.
+func managedPostCommitInstalled(projectDir string) bool {
+	hooksDir, err := resolveGitHooksDir(projectDir)
+	if err != nil {
+		return false
+	}
+	content, err := os.ReadFile(filepath.Join(hooksDir, "post-commit"))
+	return err == nil && isManagedPostCommit(content)
+}
+
 func isManagedPostCommit(content []byte) bool {
 	return strings.Contains(string(content), managedPostCommitMarker)
 }
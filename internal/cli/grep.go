@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/prit3010/converge/internal/core"
+	"github.com/spf13/cobra"
+)
+
+type grepOptions struct {
+	Branch     string
+	Path       string
+	IgnoreCase bool
+	First      bool
+	Last       bool
+}
+
+func newGrepCmd() *cobra.Command {
+	var opts grepOptions
+	var noColor bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "grep <regex>",
+		Short: "Search file contents across every stored cell",
+		Long:  "Searches the files of every cell (or one branch) for a regex, reading each distinct blob once. --first shows the earliest cell containing a match; --last shows the latest one and the child cell where the text disappeared.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runGrep(cwd, args[0], opts, noColor, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&opts.Branch, "branch", "", "Only search cells on this branch")
+	cmd.Flags().StringVar(&opts.Path, "path", "", "Only search paths matching this glob (directories match their contents)")
+	cmd.Flags().BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "Match case-insensitively")
+	cmd.Flags().BoolVar(&opts.First, "first", false, "Show only the earliest cell containing a match")
+	cmd.Flags().BoolVar(&opts.Last, "last", false, "Show only the latest cell containing a match and where it was removed")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in output")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runGrep(projectDir, pattern string, opts grepOptions, noColor bool, outputJSON bool, out io.Writer) error {
+	if opts.First && opts.Last {
+		return validationErrorf("cannot use --first and --last together")
+	}
+	if strings.TrimSpace(pattern) == "" {
+		return validationErrorf("a search pattern is required")
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return validationErrorf("invalid regex: %v", err)
+	}
+	pathGlob := strings.TrimSpace(opts.Path)
+	if pathGlob != "" && !doublestar.ValidatePattern(pathGlob) {
+		return validationErrorf("invalid --path glob %q", opts.Path)
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	branch := strings.TrimSpace(opts.Branch)
+	if branch != "" {
+		if _, err := svc.DB.GetBranch(branch); err != nil {
+			return notFoundErrorf("branch %q not found", branch)
+		}
+	}
+	result, err := svc.Grep(core.GrepOptions{Pattern: re, Branch: branch, PathGlob: pathGlob})
+	if err != nil {
+		return err
+	}
+
+	matches := result.Matches
+	cellID, removedIn := "", ""
+	if len(result.Cells) > 0 && (opts.First || opts.Last) {
+		if opts.First {
+			cellID = result.Cells[0]
+		} else {
+			cellID = result.Cells[len(result.Cells)-1]
+			removedIn = result.RemovedIn(cellID)
+		}
+		matches = result.MatchesIn(cellID)
+	}
+
+	if outputJSON {
+		payload := map[string]any{
+			"pattern":        re.String(),
+			"branch":         branch,
+			"path":           opts.Path,
+			"matches":        matches,
+			"cells":          result.Cells,
+			"searched_cells": result.SearchedCells,
+			"searched_blobs": result.SearchedBlobs,
+			"warnings":       result.Warnings,
+		}
+		if opts.First {
+			payload["first"] = cellID
+		}
+		if opts.Last {
+			payload["last"] = cellID
+			payload["removed_in"] = removedIn
+		}
+		return writeCommandSuccessJSON(out, "grep", payload)
+	}
+
+	palette := newLogPalette(noColor)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(out, "%s %s\n", palette.yellow("warning:"), warning)
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(out, "No matches in %d cells\n", result.SearchedCells)
+		return nil
+	}
+	switch {
+	case opts.First:
+		fmt.Fprintf(out, "First appears in %s\n", palette.bold(cellID))
+	case opts.Last && removedIn != "":
+		fmt.Fprintf(out, "Last present in %s, removed in %s\n", palette.bold(cellID), palette.bold(removedIn))
+	case opts.Last:
+		fmt.Fprintf(out, "Last present in %s\n", palette.bold(cellID))
+	}
+	for _, m := range matches {
+		fmt.Fprintf(out, "%s %s:%s: %s\n", palette.cyan(m.CellID), m.Path, palette.dim(fmt.Sprint(m.Line)), m.Text)
+	}
+	return nil
+}
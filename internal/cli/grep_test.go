@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestRunGrepFirstAndLast(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	notes := filepath.Join(projectDir, "notes.txt")
+	for _, content := range []string{"alpha\n", "alpha\nbeta\n", "alpha\n"} {
+		if err := os.WriteFile(notes, []byte(content), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		if err := runSnap(projectDir, "edit", "", "", false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runGrep(projectDir, "BETA", grepOptions{IgnoreCase: true, Last: true}, true, false, &out); err != nil {
+		t.Fatalf("run grep: %v", err)
+	}
+	for _, want := range []string{"Last present in c_000002, removed in c_000003", "c_000002 notes.txt:2: beta"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in grep output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runGrep(projectDir, "alpha", grepOptions{First: true}, true, false, &out); err != nil {
+		t.Fatalf("run grep --first: %v", err)
+	}
+	if !strings.Contains(out.String(), "First appears in c_000001") || strings.Contains(out.String(), "c_000003 ") {
+		t.Fatalf("unexpected grep --first output:\n%s", out.String())
+	}
+	if err := runGrep(projectDir, "(", grepOptions{}, true, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected invalid regex to fail")
+	}
+}
+
+func TestRunGrepErrorCodes(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("alpha\n"), 0o644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+	if err := runSnap(projectDir, "edit", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	err := runGrep(projectDir, "alpha", grepOptions{Path: "[notes"}, true, false, &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeValidation {
+		t.Fatalf("expected validation error for a bad glob, got %v", err)
+	}
+
+	// A missing blob skips the file with a warning instead of failing the search.
+	objects := filepath.Join(projectDir, config.StateDirName, config.ObjectsDirName)
+	if err := os.RemoveAll(objects); err != nil {
+		t.Fatalf("remove objects: %v", err)
+	}
+	var out bytes.Buffer
+	if err := runGrep(projectDir, "alpha", grepOptions{}, true, false, &out); err != nil {
+		t.Fatalf("expected grep to skip a missing blob, got %v", err)
+	}
+	if !strings.Contains(out.String(), "warning: skipped notes.txt in c_000001") || !strings.Contains(out.String(), "No matches") {
+		t.Fatalf("expected a skip warning, got:\n%s", out.String())
+	}
+}
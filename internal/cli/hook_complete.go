@@ -66,6 +66,9 @@ func runHookComplete(projectDir string, flags hookCompleteFlags, out io.Writer)
 			return err
 		}
 	}
+	if hookErr == nil && result.Status == core.AgentCompletionStatusCreated && result.CellID != nil {
+		autoDescribe(svc, *result.CellID)
+	}
 	if hookErr != nil {
 		if result.Error != "" {
 			return fmt.Errorf(result.Error)
@@ -84,22 +84,28 @@ func runHookGitCommit(projectDir string, flags hookGitCommitFlags, out io.Writer
 		CommittedAt: committedAt,
 	})
 	if rotateErr != nil {
-		replay := fmt.Sprintf(
-			"converge hook git-commit --sha %s --branch %s --subject %s",
-			strconv.Quote(sha),
-			strconv.Quote(branch),
-			strconv.Quote(subject),
-		)
-		return fmt.Errorf("git-commit hook failed: %w\nreplay: %s", rotateErr, replay)
+		return fmt.Errorf("git-commit hook failed: %w\nreplay: %s", rotateErr, gitCommitReplay(sha, branch, subject))
 	}
+	printRotation(out, result, sha, branch)
+	return nil
+}
 
+func gitCommitReplay(sha, branch, subject string) string {
+	return fmt.Sprintf(
+		"converge hook git-commit --sha %s --branch %s --subject %s",
+		strconv.Quote(sha),
+		strconv.Quote(branch),
+		strconv.Quote(subject),
+	)
+}
+
+func printRotation(out io.Writer, result *core.ArchiveRotationResult, sha, branch string) {
 	if result.Archive == nil {
 		fmt.Fprintf(out, "archive_skipped=empty commit=%s branch=%s\n", shortID(sha), branch)
 	} else {
 		fmt.Fprintf(out, "archived=%s commit=%s branch=%s cells=%d\n", result.Archive.ArchiveID, shortID(sha), branch, result.Archive.CellCount)
 	}
 	fmt.Fprintf(out, "baseline=%s source=%s\n", result.BaselineCell.ID, result.BaselineCell.Source)
-	return nil
 }
 
 func shortID(in string) string {
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/spf13/cobra"
+)
+
+type importPatchOptions struct {
+	Onto    string
+	Branch  string
+	Message string
+	Agent   string
+}
+
+func newImportPatchCmd() *cobra.Command {
+	var opts importPatchOptions
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "import-patch <file>",
+		Short: "Record a patch as new cells without touching the working tree",
+		Long:  "Applies a unified diff, git diff, or mbox series (\"-\" reads stdin) to the files of --onto and records each message as a cell on a new branch. The working tree, the active branch, and HEAD stay as they are; use switch or restore to check the result out.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runImportPatch(cwd, args[0], opts, outputJSON, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&opts.Onto, "onto", core.HeadRevision, "Cell whose files the patch applies to")
+	cmd.Flags().StringVar(&opts.Branch, "branch", "", "Branch to create for the imported cells (defaults to a name from the patch file)")
+	cmd.Flags().StringVarP(&opts.Message, "message", "m", "", "Cell message (defaults to each patch subject)")
+	cmd.Flags().StringVar(&opts.Agent, "agent", "", "Agent to record (defaults to the Converge-Agent trailer)")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runImportPatch(projectDir, file string, opts importPatchOptions, outputJSON bool, in io.Reader, out io.Writer) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(in)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("read patch: %w", err)
+	}
+	messages, err := core.ParsePatchMessages(string(data))
+	if err != nil {
+		return validationErrorf("invalid patch: %v", err)
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	onto := strings.TrimSpace(opts.Onto)
+	if onto == "" {
+		onto = core.HeadRevision
+	}
+	baseID, err := resolveCellID(svc.DB, onto)
+	if err != nil {
+		return err
+	}
+	branch := strings.TrimSpace(opts.Branch)
+	if branch == "" {
+		branch = importBranchName(file)
+	}
+	cells, err := svc.ImportPatches(context.Background(), messages, core.ImportPatchOptions{
+		Base:    baseID,
+		Branch:  branch,
+		Message: opts.Message,
+		Agent:   opts.Agent,
+	})
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		ids := make([]string, 0, len(cells))
+		for _, cell := range cells {
+			ids = append(ids, cell.ID)
+		}
+		return writeCommandSuccessJSON(out, "import-patch", map[string]any{
+			"base":     baseID,
+			"branch":   branch,
+			"cell_ids": ids,
+		})
+	}
+	fmt.Fprintf(out, "Created branch %s at %s (%d new cells onto %s)\n", branch, cells[len(cells)-1].ID, len(cells), baseID)
+	for _, cell := range cells {
+		fmt.Fprintf(out, "  %s  %s\n", cell.ID, importedCellSummary(cell))
+	}
+	return nil
+}
+
+// importBranchName derives a branch name from the patch file, e.g.
+// "0001-fix-parser.patch" becomes "import/0001-fix-parser".
+func importBranchName(file string) string {
+	if file == "-" {
+		return "import/stdin"
+	}
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	name = patchFileSlugPattern.ReplaceAllString(name, "-")
+	if name = strings.Trim(name, "-"); name == "" {
+		name = "patch"
+	}
+	return "import/" + name
+}
+
+func importedCellSummary(cell *db.Cell) string {
+	if cell.Agent != nil && *cell.Agent != "" {
+		return fmt.Sprintf("[%s] %s", *cell.Agent, cell.Message)
+	}
+	return cell.Message
+}
@@ -1,50 +1,333 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/core"
 	"github.com/prit3010/converge/internal/db"
 	"github.com/spf13/cobra"
 )
 
+// logOptions holds converge log's scope, filters, and output settings.
+type logOptions struct {
+	Limit   int
+	Branch  string
+	ShowAll bool
+	SortBy  string
+
+	Source      string
+	Agent       string
+	Tag         string
+	Since       string
+	Until       string
+	Grep        string
+	Touches     string
+	Paths       []string
+	Failing     bool
+	Passing     bool
+	MinLOCDelta *int
+
+	Format     string
+	Graph      bool
+	ShowNotes  bool
+	NoColor    bool
+	OutputJSON bool
+}
+
 func newLogCmd() *cobra.Command {
-	var limit int
-	var noColor bool
-	var branch string
-	var showAll bool
-	var outputJSON bool
+	opts := logOptions{}
+	var minLOCDelta int
 	cmd := &cobra.Command{
-		Use:   "log",
+		Use:   "log [-- <path>...]",
 		Short: "Show cell history",
+		Long:  "Lists cells newest first. Filters combine with AND; --since and --until take a date, an RFC3339 time, or an age such as 3d or 12h. Paths after -- limit the log to cells that added, changed, or removed one of those files.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Paths = args
 			cwd, err := os.Getwd()
 			if err != nil {
 				return err
 			}
-			return runLog(cwd, limit, noColor, branch, showAll, outputJSON, cmd.OutOrStdout())
+			if cmd.Flags().Changed("min-loc-delta") {
+				opts.MinLOCDelta = &minLOCDelta
+			}
+			return runLog(cwd, opts, cmd.OutOrStdout())
 		},
 	}
-	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of cells to print")
-	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in log output")
-	cmd.Flags().StringVar(&branch, "branch", "", "Show history for a specific branch")
-	cmd.Flags().BoolVar(&showAll, "all", false, "Show history across all branches")
-	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 20, "Maximum number of cells to print")
+	cmd.Flags().BoolVar(&opts.NoColor, "no-color", false, "Disable ANSI colors in log output")
+	cmd.Flags().StringVar(&opts.Branch, "branch", "", "Show history for a specific branch")
+	cmd.Flags().BoolVar(&opts.ShowAll, "all", false, "Show history across all branches")
+	cmd.Flags().StringVar(&opts.SortBy, "sort", "time", "Order cells by time or score")
+	cmd.Flags().StringVar(&opts.Source, "source", "", "Only show cells from this source (manual, watch, agent, ...)")
+	cmd.Flags().StringVar(&opts.Agent, "agent", "", "Only show cells from this agent")
+	cmd.Flags().StringVar(&opts.Tag, "tag", "", "Only show cells carrying this tag")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only show cells at or after this time")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only show cells before this time")
+	cmd.Flags().StringVar(&opts.Grep, "grep", "", "Only show cells whose message or summary matches this regex")
+	cmd.Flags().StringVar(&opts.Touches, "touches", "", "Only show cells that change a path matching this glob")
+	cmd.Flags().BoolVar(&opts.Failing, "failing", false, "Only show evaluated cells with failing tests or an eval error")
+	cmd.Flags().BoolVar(&opts.Passing, "passing", false, "Only show evaluated cells with no failing tests")
+	cmd.Flags().IntVar(&minLOCDelta, "min-loc-delta", 0, "Only show cells whose LOC delta is at least this")
+	cmd.Flags().StringVar(&opts.Format, "format", "", "Print each cell with a Go text/template over the cell (e.g. '{{.ID}} {{.Message}}')")
+	cmd.Flags().BoolVar(&opts.Graph, "graph", false, "Draw the parent lineage next to the log")
+	cmd.Flags().BoolVar(&opts.ShowNotes, "notes", false, "Show review notes under each cell")
+	cmd.Flags().BoolVar(&opts.OutputJSON, "json", false, "Print machine-readable JSON output")
 	return cmd
 }
 
-func runLog(projectDir string, limit int, noColor bool, branch string, showAll bool, outputJSON bool, out io.Writer) error {
+// logFilter is a compiled set of log filters.
+type logFilter struct {
+	source  string
+	agent   string
+	tagged  map[string]bool
+	since   *time.Time
+	until   *time.Time
+	grep    *regexp.Regexp
+	touches string
+	paths   map[string]bool
+	failing bool
+	passing bool
+	minLOC  *int
+}
+
+func compileLogFilter(database *db.DB, opts logOptions, now time.Time) (*logFilter, error) {
+	f := &logFilter{
+		source:  strings.TrimSpace(opts.Source),
+		agent:   strings.TrimSpace(opts.Agent),
+		touches: strings.TrimSpace(opts.Touches),
+		failing: opts.Failing,
+		passing: opts.Passing,
+		minLOC:  opts.MinLOCDelta,
+	}
+	if f.failing && f.passing {
+		return nil, validationErrorf("cannot use --failing and --passing together")
+	}
+	if tag := strings.TrimSpace(opts.Tag); tag != "" {
+		ids, err := database.ListCellIDsWithTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		f.tagged = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			f.tagged[id] = true
+		}
+	}
+	var err error
+	if f.since, err = parseLogTime(opts.Since, now, false); err != nil {
+		return nil, validationErrorf("invalid --since %q: %v", opts.Since, err)
+	}
+	if f.until, err = parseLogTime(opts.Until, now, true); err != nil {
+		return nil, validationErrorf("invalid --until %q: %v", opts.Until, err)
+	}
+	if pattern := strings.TrimSpace(opts.Grep); pattern != "" {
+		if f.grep, err = regexp.Compile(pattern); err != nil {
+			return nil, validationErrorf("invalid --grep %q: %v", pattern, err)
+		}
+	}
+	for _, path := range opts.Paths {
+		path = filepath.ToSlash(filepath.Clean(strings.TrimSpace(path)))
+		if path == "." || path == "" {
+			return nil, validationErrorf("invalid path %q", path)
+		}
+		if f.paths == nil {
+			f.paths = map[string]bool{}
+		}
+		f.paths[path] = true
+	}
+	if f.touches != "" && !doublestar.ValidatePattern(f.touches) {
+		return nil, validationErrorf("invalid --touches glob %q", f.touches)
+	}
+	return f, nil
+}
+
+// active reports whether any filter beyond branch scope is set.
+func (f *logFilter) active() bool {
+	return f.source != "" || f.agent != "" || f.tagged != nil || f.since != nil || f.until != nil ||
+		f.grep != nil || f.touches != "" || f.paths != nil || f.failing || f.passing || f.minLOC != nil
+}
+
+func (f *logFilter) matches(database *db.DB, cell db.Cell) (bool, error) {
+	switch {
+	case f.source != "" && cell.Source != f.source:
+		return false, nil
+	case f.agent != "" && (cell.Agent == nil || *cell.Agent != f.agent):
+		return false, nil
+	case f.tagged != nil && !f.tagged[cell.ID]:
+		return false, nil
+	case f.minLOC != nil && cell.LOCDelta < *f.minLOC:
+		return false, nil
+	case f.failing && !cellFailing(cell):
+		return false, nil
+	case f.passing && (!cell.EvalRan || cellFailing(cell)):
+		return false, nil
+	}
+	if f.since != nil || f.until != nil {
+		ts, err := time.Parse(time.RFC3339Nano, cell.Timestamp)
+		if err != nil {
+			return false, nil
+		}
+		if (f.since != nil && ts.Before(*f.since)) || (f.until != nil && !ts.Before(*f.until)) {
+			return false, nil
+		}
+	}
+	if f.grep != nil {
+		text := cell.Message
+		if cell.Summary != nil {
+			text += "\n" + *cell.Summary
+		}
+		if !f.grep.MatchString(text) {
+			return false, nil
+		}
+	}
+	if f.paths != nil {
+		ok, err := cellTouches(database, cell, func(path string) bool { return f.paths[path] })
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if f.touches != "" {
+		return cellTouches(database, cell, func(path string) bool { return core.MatchPathGlob(f.touches, path) })
+	}
+	return true, nil
+}
+
+func cellFailing(cell db.Cell) bool {
+	if !cell.EvalRan {
+		return false
+	}
+	return (cell.TestsFailed != nil && *cell.TestsFailed > 0) || cell.EvalError != nil
+}
+
+// cellTouches reports whether cell added, modified, or removed a path
+// accepted by match.
+func cellTouches(database *db.DB, cell db.Cell, match func(string) bool) (bool, error) {
+	manifest, err := database.GetManifest(cell.ID)
+	if err != nil {
+		return false, err
+	}
+	parent := map[string]string{}
+	if cell.ParentID != nil {
+		entries, err := database.GetManifest(*cell.ParentID)
+		if err != nil {
+			return false, err
+		}
+		for _, entry := range entries {
+			parent[entry.Path] = entry.Hash
+		}
+	}
+	for _, entry := range manifest {
+		hash, ok := parent[entry.Path]
+		delete(parent, entry.Path)
+		if (!ok || hash != entry.Hash) && match(entry.Path) {
+			return true, nil
+		}
+	}
+	for path := range parent {
+		if match(path) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseLogTime accepts an RFC3339 time, a YYYY-MM-DD date, or an age such as
+// 3d. A date used as an upper bound covers the whole day.
+func parseLogTime(raw string, now time.Time, upper bool) (*time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, nil
+	}
+	age, err := parseAge(raw)
+	if err != nil {
+		return nil, fmt.Errorf("expected a date, RFC3339 time, or age like 3d")
+	}
+	t := now.Add(-age)
+	return &t, nil
+}
+
+var logTemplateFuncs = template.FuncMap{
+	"value": func(v any) any {
+		switch p := v.(type) {
+		case *string:
+			if p != nil {
+				return *p
+			}
+		case *int:
+			if p != nil {
+				return *p
+			}
+		case *float64:
+			if p != nil {
+				return *p
+			}
+		default:
+			return v
+		}
+		return ""
+	},
+	"score": func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return formatScore(*v)
+	},
+}
+
+func runLog(projectDir string, opts logOptions, out io.Writer) error {
 	svc, err := openService(projectDir)
 	if err != nil {
 		return err
 	}
 	defer svc.DB.Close()
 
-	if showAll && strings.TrimSpace(branch) != "" {
+	if opts.ShowAll && strings.TrimSpace(opts.Branch) != "" {
 		return validationErrorf("cannot use --branch and --all together")
 	}
+	sortBy := strings.TrimSpace(strings.ToLower(opts.SortBy))
+	if sortBy == "" {
+		sortBy = "time"
+	}
+	if sortBy != "time" && sortBy != "score" {
+		return validationErrorf("invalid --sort %q (expected time|score)", sortBy)
+	}
+	if opts.OutputJSON && (opts.Format != "" || opts.Graph) {
+		return validationErrorf("--format and --graph cannot be combined with --json")
+	}
+	if opts.Graph && sortBy == "score" {
+		return validationErrorf("--graph requires --sort time")
+	}
+	var tmpl *template.Template
+	if opts.Format != "" {
+		if tmpl, err = template.New("format").Funcs(logTemplateFuncs).Parse(opts.Format); err != nil {
+			return validationErrorf("invalid --format template: %v", err)
+		}
+	}
+	filter, err := compileLogFilter(svc.DB, opts, time.Now())
+	if err != nil {
+		return err
+	}
 
 	activeBranch, err := svc.ActiveBranch()
 	if err != nil {
@@ -56,58 +339,260 @@ func runLog(projectDir string, limit int, noColor bool, branch string, showAll b
 		headCellID = strings.TrimSpace(v)
 	}
 
-	targetBranch := strings.TrimSpace(branch)
-	if targetBranch == "" && !showAll {
+	targetBranch := strings.TrimSpace(opts.Branch)
+	if targetBranch == "" && !opts.ShowAll {
 		targetBranch = activeBranch
 	}
 
+	limit := opts.Limit
 	var cells []db.Cell
-	if showAll {
+	switch {
+	case sortBy == "score" || filter.active():
+		cells, err = listFilteredCells(svc.DB, targetBranch, opts.ShowAll, filter, sortBy, limit)
+	case opts.ShowAll:
 		cells, err = svc.DB.ListCells(limit)
-	} else {
+	default:
 		cells, err = svc.DB.ListCellsByBranch(targetBranch, limit)
 	}
 	if err != nil {
 		return err
 	}
-	if outputJSON {
-		return writeCommandSuccessJSON(out, "log", map[string]any{
+	notes := map[string][]db.Note{}
+	if opts.ShowNotes {
+		for _, cell := range cells {
+			cellNotes, err := svc.DB.ListNotes(cell.ID)
+			if err != nil {
+				return err
+			}
+			if len(cellNotes) > 0 {
+				notes[cell.ID] = cellNotes
+			}
+		}
+	}
+	if opts.OutputJSON {
+		payload := map[string]any{
 			"active_branch": activeBranch,
 			"head_cell_id":  headCellID,
 			"scope": map[string]any{
-				"all":    showAll,
-				"branch": targetBranch,
-				"limit":  limit,
+				"all":     opts.ShowAll,
+				"branch":  targetBranch,
+				"limit":   limit,
+				"sort":    sortBy,
+				"filters": logFiltersJSON(opts),
 			},
 			"cells": cells,
-		})
+		}
+		if opts.ShowNotes {
+			payload["notes"] = notes
+		}
+		return writeCommandSuccessJSON(out, "log", payload)
 	}
 
 	if len(cells) == 0 {
-		if showAll {
+		switch {
+		case tmpl != nil:
+		case filter.active():
+			fmt.Fprintln(out, "No cells match the given filters.")
+		case opts.ShowAll:
 			fmt.Fprintln(out, "No cells yet. Run 'converge snap -m \"message\"' to create one.")
-		} else {
+		default:
 			fmt.Fprintf(out, "No cells on branch %q yet. Run 'converge snap -m \"message\"' to create one.\n", targetBranch)
 		}
 		return nil
 	}
 
-	palette := newLogPalette(noColor)
-	if showAll {
-		fmt.Fprintf(out, "Showing %d most recent cells across all branches (active: %s)\n\n", len(cells), activeBranch)
-	} else {
-		fmt.Fprintf(out, "Showing %d most recent cells on branch %s\n\n", len(cells), targetBranch)
+	palette := newLogPalette(opts.NoColor)
+	if tmpl == nil {
+		order := "most recent"
+		if sortBy == "score" {
+			order = "highest scoring"
+		}
+		matching := ""
+		if filter.active() {
+			matching = " matching filters"
+		}
+		if opts.ShowAll {
+			fmt.Fprintf(out, "Showing %d %s cells%s across all branches (active: %s)\n\n", len(cells), order, matching, activeBranch)
+		} else {
+			fmt.Fprintf(out, "Showing %d %s cells%s on branch %s\n\n", len(cells), order, matching, targetBranch)
+		}
+	}
+	var graph *logGraph
+	if opts.Graph {
+		graph = newLogGraph(cells)
 	}
+	known := make(map[string]*db.Cell, len(cells))
+	for i := range cells {
+		known[cells[i].ID] = &cells[i]
+	}
+	cont := ""
 	for i, cell := range cells {
-		if i > 0 {
-			fmt.Fprintln(out)
+		if i > 0 && tmpl == nil {
+			fmt.Fprintln(out, strings.TrimRight(cont, " "))
+		}
+		var block bytes.Buffer
+		if tmpl != nil {
+			if err := tmpl.Execute(&block, cell); err != nil {
+				return validationErrorf("render --format for %s: %v", cell.ID, err)
+			}
+			block.WriteString("\n")
+		} else {
+			printCell(&block, cell, parentCoverage(svc.DB, cell, known), cell.ID == headCellID, palette)
+		}
+		printNotes(&block, notes[cell.ID], "  "+palette.dim("note")+" : ", palette)
+		if graph == nil {
+			_, _ = out.Write(block.Bytes())
+			continue
+		}
+		before, row, next, after := graph.next(cell)
+		cont = next
+		for _, line := range before {
+			fmt.Fprintln(out, palette.dim(line))
+		}
+		lines := strings.Split(strings.TrimSuffix(block.String(), "\n"), "\n")
+		for j, line := range lines {
+			prefix := cont
+			if j == 0 {
+				prefix = row
+			}
+			fmt.Fprintln(out, palette.dim(prefix)+line)
+		}
+		for _, line := range after {
+			fmt.Fprintln(out, palette.dim(line))
 		}
-		printCell(out, cell, cell.ID == headCellID, palette)
 	}
 	return nil
 }
 
-func printCell(out io.Writer, cell db.Cell, isHead bool, palette logPalette) {
+func logFiltersJSON(opts logOptions) map[string]any {
+	filters := map[string]any{}
+	for key, value := range map[string]string{
+		"source":  opts.Source,
+		"agent":   opts.Agent,
+		"tag":     opts.Tag,
+		"since":   opts.Since,
+		"until":   opts.Until,
+		"grep":    opts.Grep,
+		"touches": opts.Touches,
+	} {
+		if value = strings.TrimSpace(value); value != "" {
+			filters[key] = value
+		}
+	}
+	if opts.Failing {
+		filters["failing"] = true
+	}
+	if opts.Passing {
+		filters["passing"] = true
+	}
+	if opts.MinLOCDelta != nil {
+		filters["min_loc_delta"] = *opts.MinLOCDelta
+	}
+	if len(opts.Paths) > 0 {
+		filters["paths"] = opts.Paths
+	}
+	return filters
+}
+
+// listFilteredCells returns cells in scope that pass filter, newest first or
+// by score with unscored cells last.
+func listFilteredCells(database *db.DB, branch string, showAll bool, filter *logFilter, sortBy string, limit int) ([]db.Cell, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	all, err := database.ListAllCells()
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]db.Cell, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		cell := all[i]
+		if !showAll && cell.Branch != branch {
+			continue
+		}
+		ok, err := filter.matches(database, cell)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		cells = append(cells, cell)
+		// Newest-first listings can stop early; score order needs every match.
+		if sortBy != "score" && len(cells) == limit {
+			break
+		}
+	}
+	if sortBy == "score" {
+		sort.SliceStable(cells, func(i, j int) bool {
+			return core.ScorePreferred(cells[i], cells[j])
+		})
+	}
+	if len(cells) > limit {
+		cells = cells[:limit]
+	}
+	return cells, nil
+}
+
+// parentCoverage returns the coverage recorded on cell's parent, looking it up
+// in known first and falling back to the database.
+func parentCoverage(database *db.DB, cell db.Cell, known map[string]*db.Cell) *float64 {
+	if cell.ParentID == nil {
+		return nil
+	}
+	if parent, ok := known[*cell.ParentID]; ok {
+		return comparableCoverage(cell, parent)
+	}
+	parent, err := database.GetCell(*cell.ParentID)
+	if err != nil {
+		return nil
+	}
+	if known != nil {
+		known[parent.ID] = parent
+	}
+	return comparableCoverage(cell, parent)
+}
+
+// comparableCoverage returns parent's coverage when it was measured with the
+// same eval scope as cell; a changed-only run covers a different set of tests,
+// so a delta against a full run would be noise.
+func comparableCoverage(cell db.Cell, parent *db.Cell) *float64 {
+	if parent == nil || evalScopeOf(cell) != evalScopeOf(*parent) {
+		return nil
+	}
+	return parent.CoveragePct
+}
+
+// evalScopeOf treats cells evaluated before scopes were recorded as full runs.
+func evalScopeOf(cell db.Cell) string {
+	if cell.EvalScope == nil {
+		return string(config.EvalScopeFull)
+	}
+	return *cell.EvalScope
+}
+
+func formatCoverage(pct *float64, parentPct *float64, palette logPalette) string {
+	if pct == nil {
+		return ""
+	}
+	label := fmt.Sprintf("%.1f%%", *pct)
+	if parentPct == nil {
+		return label
+	}
+	delta := *pct - *parentPct
+	deltaLabel := fmt.Sprintf("(%+.1f)", delta)
+	switch {
+	case delta > 0.05:
+		deltaLabel = palette.green(deltaLabel)
+	case delta < -0.05:
+		deltaLabel = palette.red(deltaLabel)
+	default:
+		deltaLabel = palette.yellow(deltaLabel)
+	}
+	return label + " " + deltaLabel
+}
+
+func printCell(out io.Writer, cell db.Cell, parentCoveragePct *float64, isHead bool, palette logPalette) {
 	headLabel := ""
 	if isHead {
 		headLabel = "  " + palette.green("HEAD")
@@ -119,6 +604,9 @@ func printCell(out io.Writer, cell db.Cell, isHead bool, palette logPalette) {
 	fmt.Fprintf(out, "[%s]%s%s\n", palette.bold(cell.ID), headLabel, branchLabel)
 	fmt.Fprintf(out, "  %s : %s\n", palette.dim("time"), cell.Timestamp)
 	fmt.Fprintf(out, "  %s : %q\n", palette.dim("message"), cell.Message)
+	if cell.Summary != nil && strings.TrimSpace(*cell.Summary) != "" {
+		fmt.Fprintf(out, "  %s : %s\n", palette.dim("summary"), *cell.Summary)
+	}
 
 	fmt.Fprintf(out, "  %s : source=%s", palette.dim("metadata"), palette.cyan(cell.Source))
 	if cell.Agent != nil {
@@ -187,6 +675,9 @@ func printCell(out io.Writer, cell db.Cell, isHead bool, palette logPalette) {
 			}
 			parts = append(parts, typesLabel)
 		}
+		if cell.EvalScope != nil && *cell.EvalScope == string(config.EvalScopeChanged) {
+			parts = append(parts, palette.yellow("scope changed"))
+		}
 		if cell.EvalSkipped != nil {
 			parts = append(parts, palette.yellow(fmt.Sprintf("skipped %s", *cell.EvalSkipped)))
 		}
@@ -201,6 +692,16 @@ func printCell(out io.Writer, cell db.Cell, isHead bool, palette logPalette) {
 	default:
 		fmt.Fprintf(out, "  %s : %s\n", palette.dim("eval"), "not requested")
 	}
+	if coverage := formatCoverage(cell.CoveragePct, parentCoveragePct, palette); coverage != "" {
+		fmt.Fprintf(out, "  %s : %s\n", palette.dim("coverage"), coverage)
+	}
+	if cell.Score != nil {
+		fmt.Fprintf(out, "  %s : %s\n", palette.dim("score"), palette.cyan(formatScore(*cell.Score)))
+	}
+}
+
+func formatScore(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
 }
 
 type logPalette struct {
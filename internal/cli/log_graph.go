@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+// logGraph draws git-log-style lineage lanes for cells listed newest first.
+// Each lane holds the ID of the cell it is waiting for; a cell's lane moves
+// on to its parent once the cell is printed.
+type logGraph struct {
+	lanes []string
+	shown map[string]bool
+}
+
+func newLogGraph(cells []db.Cell) *logGraph {
+	shown := make(map[string]bool, len(cells))
+	for _, cell := range cells {
+		shown[cell.ID] = true
+	}
+	return &logGraph{shown: shown}
+}
+
+// next returns the connector lines to print before the cell, the prefix for
+// its first line, the prefix for its remaining lines, and the connector lines
+// to print after it.
+func (g *logGraph) next(cell db.Cell) (before []string, row string, cont string, after []string) {
+	col := -1
+	for i, id := range g.lanes {
+		if id == cell.ID {
+			col = i
+			break
+		}
+	}
+	if col < 0 {
+		g.lanes = append(g.lanes, cell.ID)
+		col = len(g.lanes) - 1
+	}
+	// Siblings waiting on the same parent fold into the leftmost lane.
+	for j := len(g.lanes) - 1; j > col; j-- {
+		if g.lanes[j] == cell.ID {
+			before = append(before, g.shiftLine(j, true))
+			g.lanes = append(g.lanes[:j], g.lanes[j+1:]...)
+		}
+	}
+
+	marks := make([]string, len(g.lanes))
+	for i := range g.lanes {
+		marks[i] = "|"
+	}
+	marks[col] = "*"
+	row = strings.Join(marks, " ") + " "
+
+	// Parents outside the listing (filtered out or past --limit) end the lane.
+	if cell.ParentID != nil && g.shown[*cell.ParentID] {
+		g.lanes[col] = *cell.ParentID
+	} else {
+		if col < len(g.lanes)-1 {
+			after = append(after, g.shiftLine(col, false))
+		}
+		g.lanes = append(g.lanes[:col], g.lanes[col+1:]...)
+	}
+	return before, row, g.continuation(), after
+}
+
+// continuation is the prefix for lines between rows.
+func (g *logGraph) continuation() string {
+	if len(g.lanes) == 0 {
+		return ""
+	}
+	return strings.Repeat("| ", len(g.lanes))
+}
+
+// shiftLine draws lanes right of removed shifting one column left; with keep
+// the removed lane is drawn merging into its left neighbor.
+func (g *logGraph) shiftLine(removed int, keep bool) string {
+	line := []byte(strings.Repeat(" ", 2*len(g.lanes)))
+	for i := range g.lanes {
+		switch {
+		case i < removed:
+			line[2*i] = '|'
+		case i == removed && !keep:
+		default:
+			line[2*i-1] = '/'
+		}
+	}
+	return strings.TrimRight(string(line), " ")
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/db"
+)
+
+func TestRunLogFiltersFormatAndGraph(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	snap := func(file, content, message, agent string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(projectDir, file)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(projectDir, file), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", file, err)
+		}
+		if err := runSnap(projectDir, message, "", agent, false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+	snap("main.go", "package main\n", "initial scaffold", "")
+	snap("auth/login.go", "package auth\n\nfunc Login() {}\n", "add login flow", "codex")
+	if err := runFork(projectDir, "alt", true); err != nil {
+		t.Fatalf("fork: %v", err)
+	}
+	snap("auth/token.go", "package auth\n", "token refresh", "claude")
+	if err := runSwitch(projectDir, "main"); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	snap("README.md", "# demo\n", "docs", "codex")
+
+	format := func(opts logOptions) string {
+		t.Helper()
+		opts.Limit, opts.NoColor, opts.ShowAll, opts.Format = 20, true, true, "{{.ID}}"
+		var out bytes.Buffer
+		if err := runLog(projectDir, opts, &out); err != nil {
+			t.Fatalf("run log %+v: %v", opts, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	cases := []struct {
+		name string
+		opts logOptions
+		want string
+	}{
+		{"agent", logOptions{Agent: "codex"}, "c_000005\nc_000002"},
+		{"grep", logOptions{Grep: "^(add|token)"}, "c_000003\nc_000002"},
+		{"touches directory", logOptions{Touches: "auth"}, "c_000003\nc_000002"},
+		{"touches glob", logOptions{Touches: "**/*.md"}, "c_000005"},
+		{"since", logOptions{Since: "1d"}, "c_000005\nc_000004\nc_000003\nc_000002\nc_000001"},
+		{"until", logOptions{Until: "2000-01-01"}, ""},
+	}
+	for _, tc := range cases {
+		if got := format(tc.opts); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	graph := format(logOptions{Graph: true})
+	want := "* c_000005\n| * c_000004\n| * c_000003\n|/\n* c_000002\n* c_000001"
+	if graph != want {
+		t.Fatalf("unexpected graph:\n%s\nwant:\n%s", graph, want)
+	}
+
+	if err := runLog(projectDir, logOptions{Failing: true, Passing: true}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected --failing with --passing to be rejected")
+	}
+	if err := runLog(projectDir, logOptions{Format: "{{.Missing}}"}, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected a template over an unknown field to fail")
+	}
+}
+
+func TestComparableCoverageRequiresMatchingScope(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	scope := func(v config.EvalScope) *string { s := string(v); return &s }
+	full := db.Cell{CoveragePct: pct(80), EvalScope: scope(config.EvalScopeFull)}
+	changed := db.Cell{CoveragePct: pct(40), EvalScope: scope(config.EvalScopeChanged)}
+	legacy := db.Cell{CoveragePct: pct(70)}
+
+	if got := comparableCoverage(changed, &full); got != nil {
+		t.Fatalf("expected no parent coverage across scopes, got %v", *got)
+	}
+	if got := comparableCoverage(full, &legacy); got == nil || *got != 70 {
+		t.Fatalf("expected unscoped parent to count as a full run, got %v", got)
+	}
+	if got := formatCoverage(changed.CoveragePct, comparableCoverage(changed, &full), newLogPalette(true)); got != "40.0%" {
+		t.Fatalf("expected coverage without a delta, got %q", got)
+	}
+}
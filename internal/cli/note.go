@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// noteOptions describes one invocation of converge note. With no Message the
+// command lists the cell's notes.
+type noteOptions struct {
+	Message  string
+	Path     string
+	Line     int
+	EditID   int64
+	DeleteID int64
+}
+
+func newNoteCmd() *cobra.Command {
+	var opts noteOptions
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "note <cell>",
+		Short: "Add, list, edit, or delete review notes on a cell",
+		Long:  "With -m, appends a timestamped note to the cell, optionally anchored to --file and --line. Without -m, lists the cell's notes. --edit and --delete take a note ID from the listing.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runNote(cwd, args[0], opts, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Message, "message", "m", "", "Note text")
+	cmd.Flags().StringVar(&opts.Path, "file", "", "Anchor the note to a file in the cell")
+	cmd.Flags().IntVar(&opts.Line, "line", 0, "Anchor the note to a line of --file")
+	cmd.Flags().Int64Var(&opts.EditID, "edit", 0, "Replace the text of the note with this ID")
+	cmd.Flags().Int64Var(&opts.DeleteID, "delete", 0, "Delete the note with this ID")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runNote(projectDir, ref string, opts noteOptions, outputJSON bool, out io.Writer) error {
+	opts.Message = strings.TrimSpace(opts.Message)
+	opts.Path = strings.TrimSpace(opts.Path)
+	switch {
+	case opts.EditID != 0 && opts.DeleteID != 0:
+		return validationErrorf("cannot use --edit and --delete together")
+	case opts.EditID != 0 && opts.Message == "":
+		return validationErrorf("--edit requires -m with the new text")
+	case opts.DeleteID != 0 && opts.Message != "":
+		return validationErrorf("--delete does not take -m")
+	case (opts.EditID != 0 || opts.DeleteID != 0) && (opts.Path != "" || opts.Line != 0):
+		return validationErrorf("--file and --line only apply to new notes")
+	case opts.Line < 0:
+		return validationErrorf("--line must be positive")
+	case opts.Line > 0 && opts.Path == "":
+		return validationErrorf("--line requires --file")
+	case opts.Message == "" && (opts.Path != "" || opts.Line != 0):
+		return validationErrorf("-m is required when anchoring a note")
+	}
+
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.EditID != 0 || opts.DeleteID != 0:
+		id := opts.EditID + opts.DeleteID
+		note, err := svc.DB.GetNote(id)
+		if errors.Is(err, db.ErrNotFound) || (err == nil && note.CellID != cellID) {
+			return notFoundErrorf("note %d not found on cell %s", id, cellID)
+		}
+		if err != nil {
+			return err
+		}
+		if opts.DeleteID != 0 {
+			if err := svc.DB.DeleteNote(id); err != nil {
+				return err
+			}
+			if outputJSON {
+				return writeCommandSuccessJSON(out, "note", map[string]any{"cell_id": cellID, "deleted": id})
+			}
+			fmt.Fprintf(out, "Deleted note %d on %s\n", id, cellID)
+			return nil
+		}
+		note, err = svc.DB.UpdateNote(id, opts.Message)
+		if err != nil {
+			return err
+		}
+		return writeNoteResult(out, cellID, note, outputJSON, "Updated")
+	case opts.Message != "":
+		note := db.Note{CellID: cellID, Body: opts.Message}
+		if opts.Path != "" {
+			if err := requireManifestPath(svc.DB, cellID, opts.Path); err != nil {
+				return err
+			}
+			note.Path = &opts.Path
+		}
+		if opts.Line > 0 {
+			note.Line = &opts.Line
+		}
+		added, err := svc.DB.AddNote(note)
+		if err != nil {
+			return err
+		}
+		return writeNoteResult(out, cellID, added, outputJSON, "Added")
+	}
+
+	notes, err := svc.DB.ListNotes(cellID)
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "note", map[string]any{"cell_id": cellID, "notes": notes})
+	}
+	if len(notes) == 0 {
+		fmt.Fprintf(out, "No notes on %s. Add one with 'converge note %s -m \"...\"'.\n", cellID, cellID)
+		return nil
+	}
+	printNotes(out, notes, "", newLogPalette(false))
+	return nil
+}
+
+func writeNoteResult(out io.Writer, cellID string, note *db.Note, outputJSON bool, verb string) error {
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "note", map[string]any{"cell_id": cellID, "note": note})
+	}
+	fmt.Fprintf(out, "%s note %d on %s\n", verb, note.ID, cellID)
+	return nil
+}
+
+func requireManifestPath(database *db.DB, cellID, path string) error {
+	manifest, err := database.GetManifest(cellID)
+	if err != nil {
+		return err
+	}
+	for _, entry := range manifest {
+		if entry.Path == path {
+			return nil
+		}
+	}
+	return validationErrorf("file %s is not part of cell %s", path, cellID)
+}
+
+// printNotes writes one note per line, prefixed by indent.
+func printNotes(out io.Writer, notes []db.Note, indent string, palette logPalette) {
+	for _, n := range notes {
+		anchor := ""
+		if n.Path != nil {
+			anchor = *n.Path
+			if n.Line != nil {
+				anchor += fmt.Sprintf(":%d", *n.Line)
+			}
+			anchor = " " + palette.cyan(anchor)
+		}
+		fmt.Fprintf(out, "%s#%d %s%s  %s\n", indent, n.ID, palette.dim(n.CreatedAt), anchor, n.Body)
+	}
+}
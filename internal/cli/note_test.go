@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunNote(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := runSnap(projectDir, "first", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	if err := runNote(projectDir, "HEAD", noteOptions{Message: "rejected: skips auth"}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("add note: %v", err)
+	}
+	if err := runNote(projectDir, "HEAD", noteOptions{Message: "unused import", Path: "main.go", Line: 1}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("add anchored note: %v", err)
+	}
+	if err := runNote(projectDir, "HEAD", noteOptions{Message: "x", Path: "missing.go"}, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected note on a file outside the cell to fail")
+	}
+	if err := runNote(projectDir, "HEAD", noteOptions{Message: "x", Line: 3}, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected --line without --file to fail")
+	}
+	if err := runNote(projectDir, "HEAD", noteOptions{Message: "rejected: skips auth checks", EditID: 1}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("edit note: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runShow(projectDir, "HEAD", true, true, false, &out); err != nil {
+		t.Fatalf("run show: %v", err)
+	}
+	for _, want := range []string{"Notes (2):", "rejected: skips auth checks", "main.go:1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in show output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "time", ShowNotes: true}, &out); err != nil {
+		t.Fatalf("run log --notes: %v", err)
+	}
+	if !strings.Contains(out.String(), "note : #2") {
+		t.Fatalf("expected notes in log output:\n%s", out.String())
+	}
+
+	if err := runNote(projectDir, "HEAD", noteOptions{DeleteID: 2}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+	err := runNote(projectDir, "HEAD", noteOptions{DeleteID: 2}, false, &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeNotFound {
+		t.Fatalf("expected NOT_FOUND deleting twice, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/spf13/cobra"
+)
+
+type promoteOptions struct {
+	Branch     string
+	Message    string
+	NoCheckout bool
+}
+
+func newPromoteCmd() *cobra.Command {
+	var opts promoteOptions
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "promote <cell>",
+		Short: "Commit a cell's files to a git branch",
+		Long:  "Builds a git tree from the cell's manifest with plumbing commands and commits it with Converge-Cell, Converge-Agent, and Converge-Eval trailers. Promoting onto the checked-out branch updates the working tree (or only the index with --no-checkout) and, when the managed post-commit hook is installed, archives converge state just as a git commit would.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runPromote(cwd, args[0], opts, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&opts.Branch, "branch", "", "Git branch to commit onto (defaults to the checked-out branch; created from HEAD if missing)")
+	cmd.Flags().StringVarP(&opts.Message, "message", "m", "", "Commit message (defaults to the cell message)")
+	cmd.Flags().BoolVar(&opts.NoCheckout, "no-checkout", false, "Leave the working tree untouched when promoting onto the checked-out branch")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runPromote(projectDir, ref string, opts promoteOptions, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	// Rotation swaps in a fresh database, so close whichever one is open.
+	defer func() {
+		if svc.DB != nil {
+			_ = svc.DB.Close()
+		}
+	}()
+
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	result, err := svc.Promote(ctx, core.PromoteOptions{
+		CellID:     cellID,
+		Branch:     opts.Branch,
+		Message:    opts.Message,
+		NoCheckout: opts.NoCheckout,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Plumbing never fires git hooks, so run the post-commit rotation the
+	// managed hook would have run for a commit on HEAD.
+	var rotation *core.ArchiveRotationResult
+	if result.CheckedOut && managedPostCommitInstalled(projectDir) {
+		rotation, err = svc.RotateOnGitCommit(ctx, core.GitCommitMetadata{
+			SHA:         result.Commit,
+			Branch:      result.Branch,
+			Subject:     result.Subject,
+			CommittedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return fmt.Errorf("promoted %s as %s but post-commit rotation failed: %w\nreplay: %s", cellID, shortID(result.Commit), err, gitCommitReplay(result.Commit, result.Branch, result.Subject))
+		}
+	}
+
+	if outputJSON {
+		payload := map[string]any{
+			"cell_id":          cellID,
+			"commit":           result.Commit,
+			"tree":             result.Tree,
+			"parent":           result.Parent,
+			"branch":           result.Branch,
+			"subject":          result.Subject,
+			"checked_out":      result.CheckedOut,
+			"updated_worktree": result.UpdatedWorktree,
+			"rotated":          rotation != nil,
+		}
+		if rotation != nil {
+			if rotation.Archive != nil {
+				payload["archive_id"] = rotation.Archive.ArchiveID
+			}
+			payload["baseline_cell_id"] = rotation.BaselineCell.ID
+		}
+		return writeCommandSuccessJSON(out, "promote", payload)
+	}
+
+	fmt.Fprintf(out, "Promoted %s to %s as %s: %s\n", cellID, result.Branch, shortID(result.Commit), result.Subject)
+	switch {
+	case result.UpdatedWorktree:
+		fmt.Fprintln(out, "  Working tree updated to the promoted commit")
+	case result.CheckedOut:
+		fmt.Fprintln(out, "  Working tree left as is; index reset to the promoted commit")
+	default:
+		fmt.Fprintf(out, "  %s is not checked out; working tree untouched\n", result.Branch)
+	}
+	if rotation != nil {
+		printRotation(out, rotation, result.Commit, result.Branch)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunPromoteRotatesWithManagedHook(t *testing.T) {
+	requireGitForCLI(t)
+	projectDir := t.TempDir()
+	initGitRepoForCLI(t, projectDir)
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	if err := runGitHooksInstallGit(projectDir, io.Discard); err != nil {
+		t.Fatalf("install git hooks: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte(".converge/\n"), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+	main := filepath.Join(projectDir, "main.go")
+	if err := os.WriteFile(main, []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	runGitForCLI(t, projectDir, "add", "-A")
+	runGitForCLI(t, projectDir, "commit", "-q", "--no-verify", "-m", "initial")
+
+	if err := os.WriteFile(main, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := runSnap(projectDir, "add main func", "", "codex", false, false, io.Discard); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runPromote(projectDir, "HEAD", promoteOptions{}, false, &out); err != nil {
+		t.Fatalf("run promote: %v", err)
+	}
+	for _, want := range []string{"Promoted c_000001 to ", "Working tree updated", "archived=a_", "baseline=c_000001 source=git_commit_baseline"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in promote output:\n%s", want, out.String())
+		}
+	}
+	message := gitOutputForCLI(t, projectDir, "log", "-1", "--format=%B")
+	if !strings.Contains(message, "add main func\n\nConverge-Cell: c_000001\nConverge-Agent: codex") {
+		t.Fatalf("unexpected commit message:\n%s", message)
+	}
+	if status := gitOutputForCLI(t, projectDir, "status", "--porcelain"); status != "" {
+		t.Fatalf("expected clean status after promote, got:\n%s", status)
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+func newRankCmd() *cobra.Command {
+	var branches string
+	var model string
+	var maxTokens int
+	var maxDiffLines int
+	var refresh bool
+	var showPrompt bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "rank [cell...]",
+		Short: "Use AI and eval metrics to rank several attempts into a leaderboard",
+		Long:  "Ranks the named cells and branch heads. WORKTREE or <archive>:<cell> refs may be named to include the working tree or archived cells; rankings that include them are not stored.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			if maxTokens == 0 && cmd.Flags().Changed("max-diff-lines") {
+				maxTokens = maxDiffLines * tokensPerDiffLine
+			}
+			return runRank(cwd, args, splitList(branches), model, maxTokens, refresh, showPrompt, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&branches, "branches", "", "Comma-separated branches whose head cells are ranked")
+	cmd.Flags().StringVar(&model, "model", "", "Model to use (default from [llm] config or the provider default)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Prompt token budget, split across cells (default derived from the model's context window)")
+	cmd.Flags().IntVar(&maxDiffLines, "max-diff-lines", 0, "Total diff lines to send to the model, split across cells")
+	_ = cmd.Flags().MarkDeprecated("max-diff-lines", "use --max-tokens")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Ignore a stored ranking for these cells and call the model again")
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the exact prompt sent to the model")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runRank(projectDir string, cellIDs, branches []string, model string, maxTokens int, refresh bool, showPrompt bool, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	for i := range cellIDs {
+		cellIDs[i] = strings.TrimSpace(cellIDs[i])
+	}
+	external := map[string]*core.CellView{}
+	for _, id := range cellIDs {
+		if !isExternalRef(id) {
+			continue
+		}
+		views, err := loadCellViews(svc, id)
+		if err != nil {
+			return err
+		}
+		external[id] = views[0]
+	}
+	cells, err := rankCandidates(svc.DB, external, cellIDs, branches)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		ids = append(ids, cell.ID)
+	}
+	metricOrder, metricBasis := core.MetricOrder(cells)
+
+	if !outputJSON {
+		fmt.Fprintf(out, "Ranking %d cells: %s ...\n\n", len(ids), strings.Join(ids, ", "))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), compareTimeout)
+	defer cancel()
+	comparer := llm.NewComparer(svc.DB, svc.Store, svc.Policy.LLM)
+	for _, view := range external {
+		comparer.AddCell(view.Cell, view.Manifest, view.Blobs)
+	}
+	result, err := comparer.Rank(ctx, ids, llm.RankOptions{
+		Model:       strings.TrimSpace(model),
+		MaxTokens:   maxTokens,
+		ShowPrompt:  showPrompt,
+		MetricOrder: metricOrder,
+		MetricBasis: metricBasis,
+		Refresh:     refresh,
+	})
+	if showPrompt && !outputJSON && result != nil {
+		writeSentPrompt(out, result.Prompt)
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("rank timed out after %s", compareTimeout)
+		}
+		if result != nil && result.Error != "" {
+			return externalErrorf("rank failed: %s", result.Error)
+		}
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "rank", map[string]any{
+			"cells":    ids,
+			"provider": result.Provider,
+			"model":    result.Model,
+			"result":   result,
+		})
+	}
+
+	branchOf := make(map[string]string, len(cells))
+	for _, cell := range cells {
+		branchOf[cell.ID] = cell.Branch
+	}
+	if summary := strings.TrimSpace(result.Summary); summary != "" {
+		fmt.Fprintf(out, "Summary:\n  %s\n\n", summary)
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tCELL\tBRANCH\tAI\tMETRICS\tRATIONALE")
+	for _, e := range result.Entries {
+		metric := "-"
+		if e.MetricPosition != nil {
+			metric = fmt.Sprintf("%d", *e.MetricPosition)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n", e.Position, e.CellID, branchOf[e.CellID], e.LLMPosition, metric, e.Rationale)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if result.MetricBasis != "" {
+		fmt.Fprintf(out, "\nPositions average the AI order with the %s order.\n", result.MetricBasis)
+	} else {
+		fmt.Fprintln(out, "\nNo eval metrics to combine; positions follow the AI order.")
+	}
+	fmt.Fprintf(out, "Model: %s (%s)%s\n", result.Model, result.Provider, formatUsage(result.Usage))
+	if result.Cached {
+		fmt.Fprintf(out, "Stored result from %s (use --refresh to re-run)\n", result.CreatedAt)
+	}
+	return nil
+}
+
+// rankCandidates loads the named cells followed by the head cell of each
+// branch, skipping duplicates. Refs in external are already loaded.
+func rankCandidates(database *db.DB, external map[string]*core.CellView, cellIDs, branches []string) ([]db.Cell, error) {
+	seen := map[string]bool{}
+	cells := make([]db.Cell, 0, len(cellIDs)+len(branches))
+	add := func(id string) error {
+		if view, ok := external[id]; ok {
+			if !seen[view.Cell.ID] {
+				seen[view.Cell.ID] = true
+				cells = append(cells, view.Cell)
+			}
+			return nil
+		}
+		id, err := resolveCellID(database, id)
+		if err != nil {
+			return err
+		}
+		cell, err := database.GetCell(id)
+		if err != nil {
+			return err
+		}
+		if !seen[cell.ID] {
+			seen[cell.ID] = true
+			cells = append(cells, *cell)
+		}
+		return nil
+	}
+	for _, id := range cellIDs {
+		if err := add(strings.TrimSpace(id)); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range branches {
+		branch, err := database.GetBranch(name)
+		if err != nil {
+			if err == db.ErrNotFound {
+				return nil, notFoundErrorf("branch %q not found", name)
+			}
+			return nil, err
+		}
+		if branch.HeadCellID == nil || strings.TrimSpace(*branch.HeadCellID) == "" {
+			return nil, validationErrorf("branch %q has no cells", name)
+		}
+		if err := add(*branch.HeadCellID); err != nil {
+			return nil, err
+		}
+	}
+	if len(cells) < 2 {
+		return nil, validationErrorf("rank needs at least two distinct cells; pass cell IDs or --branches")
+	}
+	return cells, nil
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
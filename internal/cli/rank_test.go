@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestRunRankBranchesWithOllama(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Format struct {
+				Properties struct {
+					Ranking struct {
+						Items struct {
+							Properties struct {
+								CellID struct {
+									Enum []string `json:"enum"`
+								} `json:"cell_id"`
+							} `json:"properties"`
+						} `json:"items"`
+					} `json:"ranking"`
+				} `json:"properties"`
+			} `json:"format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		// Rank candidates in reverse of the order they were given.
+		ids := body.Format.Properties.Ranking.Items.Properties.CellID.Enum
+		ranking := make([]map[string]string, 0, len(ids))
+		for i := len(ids) - 1; i >= 0; i-- {
+			ranking = append(ranking, map[string]string{"cell_id": ids[i], "rationale": "attempt " + ids[i]})
+		}
+		content, _ := json.Marshal(map[string]any{"summary": "Two attempts.", "ranking": ranking})
+		_ = json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": string(content)}})
+	}))
+	defer server.Close()
+
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	configPath := filepath.Join(projectDir, config.StateDirName, config.ConfigFileName)
+	if err := os.WriteFile(configPath, []byte("[llm]\nprovider = \"ollama\"\nbase_url = \""+server.URL+"\"\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	writeAndSnap := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte(contents), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		if err := runSnap(projectDir, "attempt", "", "", false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+	writeAndSnap("base\n")
+	writeAndSnap("main attempt\n")
+	if err := runFork(projectDir, "alt", true); err != nil {
+		t.Fatalf("run fork: %v", err)
+	}
+	writeAndSnap("alt attempt\n")
+
+	var out bytes.Buffer
+	if err := runRank(projectDir, nil, []string{"main", "alt"}, "", 0, false, false, false, &out); err != nil {
+		t.Fatalf("run rank: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "Two attempts.") || !strings.Contains(text, "positions follow the AI order") {
+		t.Fatalf("unexpected rank output:\n%s", text)
+	}
+	rows := text[strings.Index(text, "RATIONALE"):]
+	if strings.Index(rows, "c_000003") > strings.Index(rows, "c_000002") {
+		t.Fatalf("expected c_000003 ranked first:\n%s", text)
+	}
+
+	out.Reset()
+	if err := runRank(projectDir, []string{"c_000002"}, []string{"main"}, "", 0, false, false, false, &out); err == nil || !strings.Contains(err.Error(), "at least two") {
+		t.Fatalf("expected duplicate candidates to be rejected, got %v", err)
+	}
+}
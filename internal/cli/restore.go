@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +16,7 @@ func newRestoreCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "restore <cell>",
 		Short: "Restore tracked files to a target cell state",
-		Long:  "Creates a safety snapshot first, then restores tracked files from the target cell while leaving untracked files untouched.",
+		Long:  "Creates a safety snapshot first, then restores tracked files from the target cell while leaving untracked files untouched. An archived cell (<archive>:<cell>) is first recreated on the active branch with a new ID.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cwd, err := os.Getwd()
@@ -35,17 +37,40 @@ func runRestore(projectDir, targetID string, outputJSON bool, out io.Writer) err
 	}
 	defer svc.DB.Close()
 
-	safety, err := svc.RestoreCell(context.Background(), targetID)
-	if err != nil {
-		return err
+	var safety *db.Cell
+	origin := ""
+	if archiveID, cellID := core.SplitCellRef(targetID); archiveID != "" {
+		var target *db.Cell
+		safety, target, err = svc.RestoreArchivedCell(context.Background(), targetID)
+		if err != nil {
+			return err
+		}
+		origin = *target.Origin
+		targetID = target.ID
+	} else {
+		if targetID, err = resolveCellID(svc.DB, cellID); err != nil {
+			return err
+		}
+		safety, err = svc.RestoreCell(context.Background(), targetID)
+		if err != nil {
+			return err
+		}
 	}
+	autoDescribe(svc, safety.ID)
 	if outputJSON {
-		return writeCommandSuccessJSON(out, "restore", map[string]any{
+		payload := map[string]any{
 			"target_cell_id": targetID,
 			"safety_cell_id": safety.ID,
-		})
+		}
+		if origin != "" {
+			payload["origin"] = origin
+		}
+		return writeCommandSuccessJSON(out, "restore", payload)
 	}
 	fmt.Fprintf(out, "Created safety cell: %s\n", safety.ID)
+	if origin != "" {
+		fmt.Fprintf(out, "Imported %s as %s\n", origin, targetID)
+	}
 	fmt.Fprintf(out, "Restored working tree to %s\n", targetID)
 	return nil
 }
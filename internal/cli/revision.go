@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+)
+
+// resolveCellID resolves a revision expression such as HEAD~2, a branch, a
+// tag, or best (see core.ResolveRevisionIn) in database, reporting unknown
+// revisions as NOT_FOUND.
+func resolveCellID(database *db.DB, rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	id, err := core.ResolveRevisionIn(database, rev)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return "", notFoundErrorf("cell %s not found", rev)
+		}
+		return "", validationErrorf("invalid revision %q: %v", rev, err)
+	}
+	return id, nil
+}
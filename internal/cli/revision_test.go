@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandsRejectWorktreeRevision(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if err := runSnap(projectDir, "first", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	commands := map[string]func() error{
+		"eval": func() error { return runEval(projectDir, "WORKTREE", false, &bytes.Buffer{}) },
+		"export": func() error {
+			return runExport(projectDir, "HEAD..WORKTREE", exportFormatPatch, "", false, &bytes.Buffer{})
+		},
+		"tag": func() error {
+			return runTagAdd(projectDir, "WORKTREE", []string{"wip"}, false, &bytes.Buffer{})
+		},
+		"note": func() error {
+			return runNote(projectDir, "WORKTREE", noteOptions{Message: "x"}, false, &bytes.Buffer{})
+		},
+		"bisect": func() error {
+			return runBisect(projectDir, bisectOptions{Good: "HEAD", Bad: "WORKTREE", Command: "true"}, true, false, &bytes.Buffer{})
+		},
+	}
+	for name, run := range commands {
+		err := run()
+		if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeValidation {
+			t.Fatalf("%s: expected VALIDATION for WORKTREE, got %v", name, err)
+		}
+		if !strings.Contains(err.Error(), "only diff and show accept it") {
+			t.Fatalf("%s: expected a WORKTREE hint, got %v", name, err)
+		}
+	}
+}
@@ -21,14 +21,28 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(newSnapCmd())
 	cmd.AddCommand(newEvalCmd())
 	cmd.AddCommand(newLogCmd())
+	cmd.AddCommand(newBestCmd())
 	cmd.AddCommand(newStatusCmd())
 	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newShowCmd())
+	cmd.AddCommand(newCatCmd())
+	cmd.AddCommand(newGrepCmd())
+	cmd.AddCommand(newBlameCmd())
+	cmd.AddCommand(newBisectCmd())
+	cmd.AddCommand(newPromoteCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newImportPatchCmd())
 	cmd.AddCommand(newRestoreCmd())
 	cmd.AddCommand(newWatchCmd())
 	cmd.AddCommand(newForkCmd())
 	cmd.AddCommand(newSwitchCmd())
 	cmd.AddCommand(newBranchesCmd())
 	cmd.AddCommand(newCompareCmd())
+	cmd.AddCommand(newRankCmd())
+	cmd.AddCommand(newDescribeCmd())
+	cmd.AddCommand(newTagCmd())
+	cmd.AddCommand(newNoteCmd())
+	cmd.AddCommand(newBenchCmd())
 	cmd.AddCommand(newHookCmd())
 	cmd.AddCommand(newGitHooksCmd())
 	cmd.AddCommand(newArchivesCmd())
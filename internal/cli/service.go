@@ -29,5 +29,9 @@ func openService(projectDir string) (*core.Service, error) {
 	objectStore := store.New(filepath.Join(stateDir, config.ObjectsDirName))
 	svc := core.NewService(projectDir, database, objectStore, eval.NewRunner())
 	svc.SetPolicy(policy)
+	if err := svc.RefreshScores(); err != nil {
+		_ = database.Close()
+		return nil, fmt.Errorf("rescore cells: %w", err)
+	}
 	return svc, nil
 }
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/store"
+	"github.com/spf13/cobra"
+)
+
+type showFileJSON struct {
+	Path   string `json:"path"`
+	Hash   string `json:"hash"`
+	Mode   int    `json:"mode"`
+	Size   int64  `json:"size"`
+	Status string `json:"status,omitempty"`
+}
+
+func newShowCmd() *cobra.Command {
+	var noColor bool
+	var noPatch bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "show <cell>",
+		Short: "Show a cell's details, files, patch, and stored comparisons",
+		Long:  "Shows a cell's metadata and eval results, its files with sizes, and its patch against its parent. The cell may be WORKTREE or an archived <archive>:<cell>.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runShow(cwd, args[0], noColor, noPatch, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors in output")
+	cmd.Flags().BoolVar(&noPatch, "no-patch", false, "Omit the patch against the parent cell")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runShow(projectDir, ref string, noColor bool, noPatch bool, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	ref = strings.TrimSpace(ref)
+	views, err := loadCellViews(svc, ref)
+	if err != nil {
+		return err
+	}
+	view := views[0]
+	cell := &view.Cell
+
+	var parent *core.CellView
+	if cell.ParentID != nil {
+		parentViews, err := loadCellViews(svc, *cell.ParentID)
+		if err != nil {
+			return err
+		}
+		parent = parentViews[0]
+	}
+	var parentManifest []db.ManifestEntry
+	blobs := store.Chain{view.Blobs}
+	if parent != nil {
+		parentManifest = parent.Manifest
+		blobs = append(blobs, parent.Blobs)
+	}
+	_, changes := diffManifests(parentManifest, view.Manifest, blobs)
+	status := make(map[string]string, len(changes))
+	for _, change := range changes {
+		status[change.Path] = change.Status
+	}
+	files := make([]showFileJSON, 0, len(view.Manifest))
+	for _, entry := range view.Manifest {
+		files = append(files, showFileJSON{
+			Path:   entry.Path,
+			Hash:   entry.Hash,
+			Mode:   entry.Mode,
+			Size:   entry.Size,
+			Status: status[entry.Path],
+		})
+	}
+
+	comparisons := []db.Comparison{}
+	rankings := []db.Ranking{}
+	notes := []db.Note{}
+	bisects := []db.BisectResult{}
+	if !view.External {
+		if notes, err = svc.DB.ListNotes(cell.ID); err != nil {
+			return err
+		}
+		if bisects, err = svc.DB.ListBisectResults(cell.ID); err != nil {
+			return err
+		}
+		if comparisons, err = svc.DB.ListComparisonsForCell(cell.ID); err != nil {
+			return err
+		}
+		if rankings, err = svc.DB.ListRankingsForCell(cell.ID); err != nil {
+			return err
+		}
+	}
+
+	if outputJSON {
+		payload := map[string]any{
+			"cell":        cell,
+			"files":       files,
+			"comparisons": comparisons,
+			"rankings":    rankings,
+			"notes":       notes,
+			"bisect":      bisects,
+		}
+		if !noPatch {
+			payload["changes"] = changes
+		}
+		return writeCommandSuccessJSON(out, "show", payload)
+	}
+
+	headCellID := ""
+	if v, err := svc.DB.GetMeta("head_cell"); err == nil && !view.External {
+		headCellID = strings.TrimSpace(v)
+	}
+	var parentCoveragePct *float64
+	if parent != nil {
+		parentCoveragePct = comparableCoverage(*cell, &parent.Cell)
+	}
+	palette := newLogPalette(noColor)
+	printCell(out, *cell, parentCoveragePct, cell.ID == headCellID, palette)
+	if cell.ParentID != nil {
+		fmt.Fprintf(out, "  %s : %s\n", palette.dim("parent"), *cell.ParentID)
+	}
+	if cell.Origin != nil {
+		fmt.Fprintf(out, "  %s : %s\n", palette.dim("origin"), *cell.Origin)
+	}
+
+	if len(notes) > 0 {
+		fmt.Fprintf(out, "\nNotes (%d):\n", len(notes))
+		printNotes(out, notes, "  ", palette)
+	}
+
+	fmt.Fprintf(out, "\nFiles (%d):\n", len(files))
+	for _, f := range files {
+		marker := " "
+		switch f.Status {
+		case "added":
+			marker = palette.green("+")
+		case "modified":
+			marker = palette.yellow("~")
+		}
+		fmt.Fprintf(out, "  %s %s  %s\n", marker, f.Path, palette.dim(formatBytes(f.Size)))
+	}
+	for _, change := range changes {
+		if change.Status == "removed" {
+			fmt.Fprintf(out, "  %s %s\n", palette.red("-"), change.Path)
+		}
+	}
+	if !noPatch {
+		fmt.Fprintln(out)
+		writePatches(out, changes, newDiffPalette(noColor))
+	}
+
+	if len(rankings) > 0 {
+		fmt.Fprintf(out, "\nRankings (%d):\n", len(rankings))
+		for _, r := range rankings {
+			for _, e := range r.Entries {
+				if e.CellID != cell.ID {
+					continue
+				}
+				fmt.Fprintf(out, "  #%d of %d  %s (%s)  %s\n", e.Position, len(r.Entries), r.Model, r.Provider, palette.dim(r.CreatedAt))
+				if rationale := strings.TrimSpace(e.Rationale); rationale != "" {
+					fmt.Fprintf(out, "    %s\n", rationale)
+				}
+			}
+		}
+	}
+
+	if len(bisects) > 0 {
+		fmt.Fprintf(out, "\nBisect (%d):\n", len(bisects))
+		for _, b := range bisects {
+			verdict := palette.red("bad")
+			if b.Good {
+				verdict = palette.green("good")
+			}
+			judge := "eval"
+			if b.Command != "" {
+				judge = b.Command
+			}
+			fmt.Fprintf(out, "  %s  %s  %s\n", verdict, judge, palette.dim(b.CreatedAt))
+		}
+	}
+
+	if len(comparisons) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "\nComparisons (%d):\n", len(comparisons))
+	for _, c := range comparisons {
+		fmt.Fprintf(out, "  %s -> %s  %s (%s)  %s\n", c.CellA, c.CellB, c.Model, c.Provider, palette.dim(c.CreatedAt))
+		if summary := strings.TrimSpace(c.Summary); summary != "" {
+			fmt.Fprintf(out, "    %s\n", summary)
+		}
+		if winner := strings.TrimSpace(c.Winner); winner != "" {
+			if c.WinnerReason != "" {
+				winner += " - " + c.WinnerReason
+			}
+			fmt.Fprintf(out, "    winner: %s\n", winner)
+		}
+		for _, risk := range c.Risks {
+			fmt.Fprintf(out, "    risk: %s\n", risk)
+		}
+	}
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunShowAndCat(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	notes := filepath.Join(projectDir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+	if err := runSnap(projectDir, "first", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+	if err := os.WriteFile(notes, []byte("hello\nworld\n"), 0o644); err != nil {
+		t.Fatalf("rewrite notes.txt: %v", err)
+	}
+	if err := runSnap(projectDir, "second", "", "", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run snap: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runShow(projectDir, "c_000002", true, false, false, &out); err != nil {
+		t.Fatalf("run show: %v", err)
+	}
+	for _, want := range []string{"parent : c_000001", "Files (", "~ notes.txt  12 B", "+world"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in show output:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runCat(projectDir, "HEAD~1", "notes.txt", &out); err != nil {
+		t.Fatalf("run cat: %v", err)
+	}
+	if out.String() != "hello\n" {
+		t.Fatalf("unexpected cat output %q", out.String())
+	}
+	if err := runCat(projectDir, "c_000001", "missing.txt", &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found for missing path, got %v", err)
+	}
+	err := runCat(projectDir, "HEAD~2", "notes.txt", &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeNotFound {
+		t.Fatalf("expected NOT_FOUND past the root, got %v", err)
+	}
+}
@@ -61,6 +61,7 @@ func runSnap(projectDir, message, tags, agent string, runEval bool, outputJSON b
 	if err != nil {
 		return fmt.Errorf("create cell: %w", err)
 	}
+	autoDescribe(svc, cell.ID)
 	skipped := svc.LastSnapshotSkipped()
 	if outputJSON {
 		payload := snapJSON{
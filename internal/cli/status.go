@@ -64,14 +64,21 @@ func runStatus(projectDir string, outputJSON bool, out io.Writer) error {
 		fmt.Fprintln(out, "No cells yet. Run 'converge snap -m \"message\"' to create one.")
 		return nil
 	}
+	parentCoveragePct := parentCoverage(svc.DB, *latest, nil)
 	if outputJSON {
+		var coverageDelta *float64
+		if latest.CoveragePct != nil && parentCoveragePct != nil {
+			d := *latest.CoveragePct - *parentCoveragePct
+			coverageDelta = &d
+		}
 		return writeCommandSuccessJSON(out, "status", map[string]any{
-			"active_branch": activeBranch,
-			"head_cell_id":  headCellID,
-			"has_cells":     true,
-			"latest_cell":   latest,
-			"delta":         delta,
-			"clean":         delta.Modified+delta.Added+delta.Removed == 0,
+			"active_branch":  activeBranch,
+			"head_cell_id":   headCellID,
+			"has_cells":      true,
+			"latest_cell":    latest,
+			"delta":          delta,
+			"clean":          delta.Modified+delta.Added+delta.Removed == 0,
+			"coverage_delta": coverageDelta,
 		})
 	}
 
@@ -83,6 +90,9 @@ func runStatus(projectDir string, outputJSON bool, out io.Writer) error {
 	fmt.Fprintf(out, "Last cell: [%s] %s %q\n", latest.ID, latest.Timestamp, latest.Message)
 	fmt.Fprintf(out, "  branch: %s\n", latest.Branch)
 	fmt.Fprintf(out, "  complexity(LOC): %d (delta %+d)\n", latest.TotalLOC, latest.LOCDelta)
+	if coverage := formatCoverage(latest.CoveragePct, parentCoveragePct, newLogPalette(true)); coverage != "" {
+		fmt.Fprintf(out, "  coverage: %s\n", coverage)
+	}
 	if delta.Modified+delta.Added+delta.Removed == 0 {
 		fmt.Fprintln(out, "  Working tree is clean (matches last cell)")
 	} else {
@@ -35,6 +35,9 @@ func runSwitch(projectDir, branchName string) error {
 	if err != nil {
 		return err
 	}
+	if safety != nil {
+		autoDescribe(svc, safety.ID)
+	}
 
 	fmt.Printf("Switched to branch %q\n", target.Branch)
 	fmt.Printf("Branch head: %s\n", target.ID)
@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/spf13/cobra"
+)
+
+func newTagCmd() *cobra.Command {
+	var name string
+	var force bool
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "tag --name <name> <cell>",
+		Short: "Manage cell tags and named bookmarks",
+		Long:  "With --name, points a bookmark at a cell; the bookmark then works anywhere a cell is accepted. Use the add, rm, and ls subcommands to manage tags.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runTagBookmark(cwd, name, args[0], force, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "Bookmark name to point at the cell")
+	cmd.Flags().BoolVar(&force, "force", false, "Move the bookmark if it already exists")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	_ = cmd.MarkFlagRequired("name")
+	cmd.AddCommand(newTagAddCmd())
+	cmd.AddCommand(newTagRmCmd())
+	cmd.AddCommand(newTagLsCmd())
+	return cmd
+}
+
+func newTagAddCmd() *cobra.Command {
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "add <cell> <tag>...",
+		Short: "Add tags to a cell",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			return runTagAdd(cwd, args[0], args[1:], outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func newTagRmCmd() *cobra.Command {
+	var name string
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "rm <cell> <tag>... | --name <name>",
+		Short: "Remove tags from a cell, or delete a bookmark",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				if len(args) > 0 {
+					return validationErrorf("--name takes no arguments")
+				}
+				return runTagRmBookmark(cwd, name, outputJSON, cmd.OutOrStdout())
+			}
+			if len(args) < 2 {
+				return validationErrorf("a cell and at least one tag are required")
+			}
+			return runTagRm(cwd, args[0], args[1:], outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "Bookmark to delete")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func newTagLsCmd() *cobra.Command {
+	var outputJSON bool
+	cmd := &cobra.Command{
+		Use:   "ls [cell]",
+		Short: "List a cell's tags and bookmarks, or every tag and bookmark",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			cell := ""
+			if len(args) == 1 {
+				cell = args[0]
+			}
+			return runTagLs(cwd, cell, outputJSON, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Print machine-readable JSON output")
+	return cmd
+}
+
+func runTagAdd(projectDir, ref string, tags []string, outputJSON bool, out io.Writer) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+	if err := svc.DB.AddCellTags(cellID, tags); err != nil {
+		return err
+	}
+	return writeCellTags(svc.DB, "tag add", cellID, outputJSON, out)
+}
+
+func runTagRm(projectDir, ref string, tags []string, outputJSON bool, out io.Writer) error {
+	tags, err := trimTags(tags)
+	if err != nil {
+		return err
+	}
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+	removed, err := svc.DB.RemoveCellTags(cellID, tags)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return notFoundErrorf("cell %s has none of the tags %s", cellID, strings.Join(tags, ", "))
+	}
+	return writeCellTags(svc.DB, "tag rm", cellID, outputJSON, out)
+}
+
+func runTagBookmark(projectDir, name, ref string, force bool, outputJSON bool, out io.Writer) error {
+	name = strings.TrimSpace(name)
+	if err := core.ValidateRevisionName(name); err != nil {
+		return validationErrorf("invalid bookmark name: %v", err)
+	}
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	if _, err := svc.DB.GetBranch(name); err == nil {
+		return conflictErrorf("a branch named %q already exists", name)
+	}
+	if existing, err := svc.DB.GetBookmark(name); err == nil && !force {
+		return conflictErrorf("bookmark %q already exists at %s (use --force to move it)", name, existing.CellID)
+	}
+	cellID, err := resolveCellID(svc.DB, ref)
+	if err != nil {
+		return err
+	}
+	if err := svc.DB.SetBookmark(name, cellID); err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "tag", map[string]any{
+			"name":    name,
+			"cell_id": cellID,
+		})
+	}
+	fmt.Fprintf(out, "Bookmark %s -> %s\n", name, cellID)
+	return nil
+}
+
+func runTagRmBookmark(projectDir, name string, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	name = strings.TrimSpace(name)
+	if err := svc.DB.DeleteBookmark(name); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return notFoundErrorf("bookmark %q not found", name)
+		}
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, "tag rm", map[string]any{"name": name})
+	}
+	fmt.Fprintf(out, "Deleted bookmark %s\n", name)
+	return nil
+}
+
+func runTagLs(projectDir, ref string, outputJSON bool, out io.Writer) error {
+	svc, err := openService(projectDir)
+	if err != nil {
+		return err
+	}
+	defer svc.DB.Close()
+
+	if strings.TrimSpace(ref) != "" {
+		cellID, err := resolveCellID(svc.DB, ref)
+		if err != nil {
+			return err
+		}
+		return writeCellTags(svc.DB, "tag ls", cellID, outputJSON, out)
+	}
+
+	counts, err := svc.DB.ListTagCounts()
+	if err != nil {
+		return err
+	}
+	bookmarks, err := svc.DB.ListBookmarks("")
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		tags := make([]map[string]any, 0, len(counts))
+		for _, tc := range counts {
+			tags = append(tags, map[string]any{"tag": tc.Tag, "cells": tc.Cells})
+		}
+		return writeCommandSuccessJSON(out, "tag ls", map[string]any{
+			"tags":      tags,
+			"bookmarks": bookmarksJSON(bookmarks),
+		})
+	}
+	if len(counts) == 0 && len(bookmarks) == 0 {
+		fmt.Fprintln(out, "No tags or bookmarks yet")
+		return nil
+	}
+	for _, tc := range counts {
+		fmt.Fprintf(out, "%s\t%d\n", tc.Tag, tc.Cells)
+	}
+	for _, b := range bookmarks {
+		fmt.Fprintf(out, "bookmark %s -> %s\n", b.Name, b.CellID)
+	}
+	return nil
+}
+
+func writeCellTags(database *db.DB, command, cellID string, outputJSON bool, out io.Writer) error {
+	tags, err := database.ListCellTags(cellID)
+	if err != nil {
+		return err
+	}
+	bookmarks, err := database.ListBookmarks(cellID)
+	if err != nil {
+		return err
+	}
+	if outputJSON {
+		return writeCommandSuccessJSON(out, command, map[string]any{
+			"cell_id":   cellID,
+			"tags":      tags,
+			"bookmarks": bookmarksJSON(bookmarks),
+		})
+	}
+	names := make([]string, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		names = append(names, b.Name)
+	}
+	fmt.Fprintf(out, "%s\ttags: %s", cellID, strings.Join(tags, ", "))
+	if len(names) > 0 {
+		fmt.Fprintf(out, "\tbookmarks: %s", strings.Join(names, ", "))
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+func bookmarksJSON(bookmarks []db.Bookmark) []map[string]string {
+	out := make([]map[string]string, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		out = append(out, map[string]string{"name": b.Name, "cell_id": b.CellID, "created_at": b.CreatedAt})
+	}
+	return out
+}
+
+// normalizeTags trims tags and rejects ones that could not be resolved back
+// as a revision (see core.ValidateRevisionName), which also keeps them out of
+// the comma-separated tags column's way.
+func normalizeTags(tags []string) ([]string, error) {
+	out, err := trimTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range out {
+		if err := core.ValidateRevisionName(tag); err != nil {
+			return nil, validationErrorf("invalid tag %q: %v", tag, err)
+		}
+	}
+	return out, nil
+}
+
+// trimTags trims tags and rejects ones the comma-separated tags column could
+// not hold. Removal uses it directly so tags recorded before names were
+// validated can still be dropped.
+func trimTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.ContainsAny(tag, ", \t") {
+			return nil, validationErrorf("invalid tag %q: tags cannot be empty or contain commas or spaces", tag)
+		}
+		out = append(out, tag)
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunTagAndBookmark(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := runInit(projectDir); err != nil {
+		t.Fatalf("run init: %v", err)
+	}
+	notes := filepath.Join(projectDir, "notes.txt")
+	for i, msg := range []string{"first", "second"} {
+		if err := os.WriteFile(notes, []byte(strings.Repeat("x\n", i+1)), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		if err := runSnap(projectDir, msg, "", "", false, false, &bytes.Buffer{}); err != nil {
+			t.Fatalf("run snap: %v", err)
+		}
+	}
+
+	if err := runTagAdd(projectDir, "HEAD~1", []string{"auth", "wip"}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("tag add: %v", err)
+	}
+	if err := runTagAdd(projectDir, "c_000001", []string{"bad,tag"}, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected comma in tag to be rejected")
+	}
+	for _, tag := range []string{"team:auth", "HEAD", "best", "c_000002", "42"} {
+		err := runTagAdd(projectDir, "c_000001", []string{tag}, false, &bytes.Buffer{})
+		if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeValidation {
+			t.Fatalf("expected VALIDATION for tag %q, got %v", tag, err)
+		}
+	}
+	if err := runTagRm(projectDir, "c_000001", []string{"wip"}, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("tag rm: %v", err)
+	}
+	err := runTagRm(projectDir, "c_000001", []string{"wip"}, false, &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeNotFound {
+		t.Fatalf("expected NOT_FOUND removing a missing tag, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runLog(projectDir, logOptions{Limit: 20, NoColor: true, SortBy: "time", Tag: "auth"}, &out); err != nil {
+		t.Fatalf("run log: %v", err)
+	}
+	if !strings.Contains(out.String(), "[c_000001]") || strings.Contains(out.String(), "[c_000002]") {
+		t.Fatalf("expected only c_000001 in tag-filtered log:\n%s", out.String())
+	}
+
+	if err := runTagBookmark(projectDir, "good-auth", "auth", false, false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("bookmark: %v", err)
+	}
+	err = runTagBookmark(projectDir, "good-auth", "HEAD", false, false, &bytes.Buffer{})
+	if cmdErr := classifyCommandError(err); cmdErr == nil || cmdErr.Code != ErrorCodeConflict {
+		t.Fatalf("expected CONFLICT moving a bookmark without --force, got %v", err)
+	}
+	if err := runTagBookmark(projectDir, "main", "HEAD", false, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected bookmark named after a branch to be rejected")
+	}
+	if err := runTagBookmark(projectDir, "HEAD~1", "HEAD", false, false, &bytes.Buffer{}); err == nil {
+		t.Fatalf("expected revision-like bookmark name to be rejected")
+	}
+
+	out.Reset()
+	if err := runCat(projectDir, "good-auth", "notes.txt", &out); err != nil {
+		t.Fatalf("cat via bookmark: %v", err)
+	}
+	if out.String() != "x\n" {
+		t.Fatalf("unexpected cat output through bookmark %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTagLs(projectDir, "", false, &out); err != nil {
+		t.Fatalf("tag ls: %v", err)
+	}
+	for _, want := range []string{"auth\t1\n", "bookmark good-auth -> c_000001"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in tag ls output:\n%s", want, out.String())
+		}
+	}
+	if err := runTagRmBookmark(projectDir, "good-auth", false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("delete bookmark: %v", err)
+	}
+}
@@ -55,6 +55,7 @@ func runWatch(projectDir string, debounce time.Duration) error {
 			return err
 		}
 		if created {
+			autoDescribe(svc, cell.ID)
 			fmt.Printf("[watch] %s branch=%s files=%d loc=%d delta=%+d\n", cell.ID, cell.Branch, cell.TotalFiles, cell.TotalLOC, cell.LOCDelta)
 		}
 		return nil
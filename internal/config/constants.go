@@ -7,6 +7,7 @@ const (
 	ObjectsDirName     = "objects"
 	ArchivesDirName    = "archives"
 	ArchiveMetaFile    = "meta.json"
+	SharedObjectsDir   = ".objects"
 	DBFileName         = "converge.db"
 	RestoreLock        = "restore.lock"
 	ArchiveLock        = "archive.lock"
@@ -64,6 +65,7 @@ target/
 *.zip
 *.tar
 *.tar.gz
+*.tar.zst
 *.tgz
 *.7z
 *.mp4
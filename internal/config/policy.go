@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	toml "github.com/pelletier/go-toml/v2"
+	"github.com/prit3010/converge/internal/score"
 )
 
 type BinaryPolicy string
@@ -23,26 +26,73 @@ type SnapshotPolicy struct {
 	BinaryPolicy     BinaryPolicy
 }
 
+type EvalScope string
+
+const (
+	EvalScopeFull    EvalScope = "full"
+	EvalScopeChanged EvalScope = "changed"
+)
+
 type EvalPolicy struct {
 	Tests []string
 	Lint  []string
 	Types []string
+	Scope EvalScope
+
+	// Coverage enables coverage collection alongside tests.
+	Coverage bool
+
+	// Bench commands run after checks; their output is parsed into metrics.
+	Bench []string
 }
 
 func (e EvalPolicy) HasOverrides() bool {
 	return len(e.Tests) > 0 || len(e.Lint) > 0 || len(e.Types) > 0
 }
 
+// ScorePolicy ranks cells. Expression takes the form described in the score
+// package; Weights is a weighted sum of metrics. At most one may be set.
+type ScorePolicy struct {
+	Expression string
+	Weights    map[string]float64
+}
+
+type LLMProvider string
+
+const (
+	LLMProviderOpenAI           LLMProvider = "openai"
+	LLMProviderOpenAICompatible LLMProvider = "openai-compatible"
+	LLMProviderAnthropic        LLMProvider = "anthropic"
+	LLMProviderOllama           LLMProvider = "ollama"
+)
+
+// LLMPolicy selects the model backend used by compare. An empty Model or
+// BaseURL means the provider default; APIKeyEnv overrides the environment
+// variable the key is read from. AutoMessage summarizes each new cell's diff
+// in the background.
+type LLMPolicy struct {
+	Provider    LLMProvider
+	Model       string
+	BaseURL     string
+	APIKeyEnv   string
+	AutoMessage bool
+}
+
 type Policy struct {
 	Snapshot SnapshotPolicy
 	Eval     EvalPolicy
+	Score    ScorePolicy
+	LLM      LLMPolicy
 
 	ignoreMatcher *IgnoreMatcher
+	scorer        *score.Scorer
 }
 
 type rawConfig struct {
 	Snapshot rawSnapshot `toml:"snapshot"`
 	Eval     rawEval     `toml:"eval"`
+	Score    rawScore    `toml:"score"`
+	LLM      rawLLM      `toml:"llm"`
 }
 
 type rawSnapshot struct {
@@ -52,9 +102,25 @@ type rawSnapshot struct {
 }
 
 type rawEval struct {
-	Tests []string `toml:"tests"`
-	Lint  []string `toml:"lint"`
-	Types []string `toml:"types"`
+	Tests    []string `toml:"tests"`
+	Lint     []string `toml:"lint"`
+	Types    []string `toml:"types"`
+	Scope    string   `toml:"scope"`
+	Coverage bool     `toml:"coverage"`
+	Bench    []string `toml:"bench"`
+}
+
+type rawLLM struct {
+	Provider    string `toml:"provider"`
+	Model       string `toml:"model"`
+	BaseURL     string `toml:"base_url"`
+	APIKeyEnv   string `toml:"api_key_env"`
+	AutoMessage bool   `toml:"auto_message"`
+}
+
+type rawScore struct {
+	Expression string             `toml:"expression"`
+	Weights    map[string]float64 `toml:"weights"`
 }
 
 func DefaultPolicy() Policy {
@@ -64,7 +130,8 @@ func DefaultPolicy() Policy {
 			MaxFileSizeBytes: 0,
 			BinaryPolicy:     BinaryPolicySkip,
 		},
-		Eval: EvalPolicy{},
+		Eval: EvalPolicy{Scope: EvalScopeFull},
+		LLM:  LLMPolicy{Provider: LLMProviderOpenAI},
 	}
 	matcher, _ := compileIgnoreMatcher(policy.Snapshot.IgnorePatterns)
 	policy.ignoreMatcher = matcher
@@ -108,6 +175,33 @@ func (p Policy) ShouldIgnore(relPath string, isDir bool) bool {
 	return p.ignoreMatcher != nil && p.ignoreMatcher.Matches(normalized, isDir)
 }
 
+// Scorer returns the compiled [score] policy, or nil when scoring is not
+// configured.
+func (p Policy) Scorer() *score.Scorer {
+	return p.scorer
+}
+
+// Fingerprint identifies the scoring rule so stored scores can be recomputed
+// when it changes. It is "" when scoring is not configured.
+func (p ScorePolicy) Fingerprint() string {
+	if p.Expression != "" {
+		return "expr:" + p.Expression
+	}
+	if len(p.Weights) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(p.Weights))
+	for name := range p.Weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+strconv.FormatFloat(p.Weights[name], 'g', -1, 64))
+	}
+	return "weights:" + strings.Join(parts, ",")
+}
+
 func readConfig(projectDir string) (*rawConfig, error) {
 	path := filepath.Join(projectDir, StateDirName, ConfigFileName)
 	data, err := os.ReadFile(path)
@@ -150,10 +244,53 @@ func applyRawConfig(policy *Policy, raw *rawConfig) error {
 		policy.Snapshot.IgnorePatterns = append(policy.Snapshot.IgnorePatterns, raw.Snapshot.Ignore...)
 	}
 
+	scope := EvalScopeFull
+	if raw.Eval.Scope != "" {
+		scope = EvalScope(strings.TrimSpace(strings.ToLower(raw.Eval.Scope)))
+		if scope != EvalScopeFull && scope != EvalScopeChanged {
+			return fmt.Errorf("invalid eval.scope %q (expected full|changed)", raw.Eval.Scope)
+		}
+	}
+
 	policy.Eval = EvalPolicy{
-		Tests: normalizeCommandList(raw.Eval.Tests),
-		Lint:  normalizeCommandList(raw.Eval.Lint),
-		Types: normalizeCommandList(raw.Eval.Types),
+		Tests:    normalizeCommandList(raw.Eval.Tests),
+		Lint:     normalizeCommandList(raw.Eval.Lint),
+		Types:    normalizeCommandList(raw.Eval.Types),
+		Scope:    scope,
+		Coverage: raw.Eval.Coverage,
+		Bench:    normalizeCommandList(raw.Eval.Bench),
+	}
+
+	expression := strings.TrimSpace(raw.Score.Expression)
+	if expression != "" && len(raw.Score.Weights) > 0 {
+		return fmt.Errorf("invalid score: set either score.expression or score.weights, not both")
+	}
+	scorer, err := score.New(expression, raw.Score.Weights)
+	if err != nil {
+		return fmt.Errorf("invalid score: %w", err)
+	}
+	policy.Score = ScorePolicy{Expression: expression, Weights: raw.Score.Weights}
+	policy.scorer = scorer
+
+	provider := LLMProviderOpenAI
+	if raw.LLM.Provider != "" {
+		provider = LLMProvider(strings.TrimSpace(strings.ToLower(raw.LLM.Provider)))
+		switch provider {
+		case LLMProviderOpenAI, LLMProviderOpenAICompatible, LLMProviderAnthropic, LLMProviderOllama:
+		default:
+			return fmt.Errorf("invalid llm.provider %q (expected openai|openai-compatible|anthropic|ollama)", raw.LLM.Provider)
+		}
+	}
+	baseURL := strings.TrimSpace(raw.LLM.BaseURL)
+	if provider == LLMProviderOpenAICompatible && baseURL == "" {
+		return fmt.Errorf("llm.base_url is required for provider %q", provider)
+	}
+	policy.LLM = LLMPolicy{
+		Provider:    provider,
+		Model:       strings.TrimSpace(raw.LLM.Model),
+		BaseURL:     baseURL,
+		APIKeyEnv:   strings.TrimSpace(raw.LLM.APIKeyEnv),
+		AutoMessage: raw.LLM.AutoMessage,
 	}
 	return nil
 }
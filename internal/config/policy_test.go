@@ -71,6 +71,7 @@ binary_policy = "fail"
 [eval]
 tests = ["go test ./..."]
 lint = ["golangci-lint run ./..."]
+bench = ["go test -run '^$' -bench . -count 5 ./...", "  "]
 `)
 	if err := os.WriteFile(filepath.Join(stateDir, ConfigFileName), configBody, 0o644); err != nil {
 		t.Fatalf("write config.toml: %v", err)
@@ -104,4 +105,128 @@ lint = ["golangci-lint run ./..."]
 	if !policy.Eval.HasOverrides() {
 		t.Fatalf("expected eval overrides from config")
 	}
+	if len(policy.Eval.Bench) != 1 {
+		t.Fatalf("expected 1 bench command, got %v", policy.Eval.Bench)
+	}
+}
+
+func TestLoadRepoPolicyEvalScope(t *testing.T) {
+	projectDir := t.TempDir()
+	stateDir := filepath.Join(projectDir, StateDirName)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatalf("mkdir state dir: %v", err)
+	}
+	configPath := filepath.Join(stateDir, ConfigFileName)
+
+	policy, err := LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load default policy: %v", err)
+	}
+	if policy.Eval.Scope != EvalScopeFull {
+		t.Fatalf("default eval scope = %q, want %q", policy.Eval.Scope, EvalScopeFull)
+	}
+
+	if err := os.WriteFile(configPath, []byte("[eval]\nscope = \"changed\"\n"), 0o644); err != nil {
+		t.Fatalf("write config.toml: %v", err)
+	}
+	policy, err = LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if policy.Eval.Scope != EvalScopeChanged {
+		t.Fatalf("eval scope = %q, want %q", policy.Eval.Scope, EvalScopeChanged)
+	}
+	if policy.Eval.HasOverrides() {
+		t.Fatalf("scope alone should not count as a command override")
+	}
+
+	if err := os.WriteFile(configPath, []byte("[eval]\nscope = \"some\"\n"), 0o644); err != nil {
+		t.Fatalf("write config.toml: %v", err)
+	}
+	if _, err := LoadRepoPolicy(projectDir); err == nil {
+		t.Fatalf("expected invalid eval.scope to fail")
+	}
+}
+
+func TestLoadRepoPolicyScore(t *testing.T) {
+	projectDir := t.TempDir()
+	stateDir := filepath.Join(projectDir, StateDirName)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatalf("mkdir state dir: %v", err)
+	}
+	configPath := filepath.Join(stateDir, ConfigFileName)
+
+	policy, err := LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load default policy: %v", err)
+	}
+	if policy.Scorer() != nil {
+		t.Fatalf("expected no scorer by default")
+	}
+
+	config := "[score.weights]\ntests_failed = -10\ncoverage = 0.5\n\"bench:BenchmarkParse\" = -0.001\n"
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatalf("write config.toml: %v", err)
+	}
+	policy, err = LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if policy.Scorer() == nil || len(policy.Score.Weights) != 3 || policy.Score.Weights["coverage"] != 0.5 {
+		t.Fatalf("unexpected score policy: %+v", policy.Score)
+	}
+
+	for _, bad := range []string{
+		"[score]\nexpression = \"tests_failed +\"\n",
+		"[score.weights]\nspeed = 1\n",
+		"[score]\nexpression = \"coverage\"\n[score.weights]\ncoverage = 1\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0o644); err != nil {
+			t.Fatalf("write config.toml: %v", err)
+		}
+		if _, err := LoadRepoPolicy(projectDir); err == nil {
+			t.Fatalf("expected invalid score config to fail: %q", bad)
+		}
+	}
+}
+
+func TestLoadRepoPolicyLLM(t *testing.T) {
+	projectDir := t.TempDir()
+	stateDir := filepath.Join(projectDir, StateDirName)
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		t.Fatalf("mkdir state dir: %v", err)
+	}
+	configPath := filepath.Join(stateDir, ConfigFileName)
+
+	policy, err := LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load default policy: %v", err)
+	}
+	if policy.LLM.Provider != LLMProviderOpenAI {
+		t.Fatalf("default provider = %q, want openai", policy.LLM.Provider)
+	}
+
+	config := "[llm]\nprovider = \"Ollama\"\nmodel = \"llama3.1\"\nbase_url = \"http://gpu-box:11434\"\nauto_message = true\n"
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatalf("write config.toml: %v", err)
+	}
+	policy, err = LoadRepoPolicy(projectDir)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if policy.LLM.Provider != LLMProviderOllama || policy.LLM.Model != "llama3.1" || policy.LLM.BaseURL != "http://gpu-box:11434" || !policy.LLM.AutoMessage {
+		t.Fatalf("unexpected llm policy: %+v", policy.LLM)
+	}
+
+	for _, bad := range []string{
+		"[llm]\nprovider = \"bard\"\n",
+		"[llm]\nprovider = \"openai-compatible\"\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0o644); err != nil {
+			t.Fatalf("write config.toml: %v", err)
+		}
+		if _, err := LoadRepoPolicy(projectDir); err == nil {
+			t.Fatalf("expected invalid llm config to fail: %q", bad)
+		}
+	}
 }
@@ -90,6 +90,9 @@ func (s *Service) RotateOnGitCommit(ctx context.Context, meta GitCommitMetadata)
 			return nil, rotateErr
 		}
 		archiveMeta = metaCopy
+		// Deduplicating into the shared store only saves space; a failure
+		// leaves the archive intact and `archives compact` retries it.
+		_ = s.compactArchive(archiveMeta.ArchiveID, &CompactResult{})
 	} else {
 		if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
 			return nil, fmt.Errorf("remove active db: %w", err)
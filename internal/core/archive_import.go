@@ -0,0 +1,282 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/store"
+)
+
+type ArchiveImportResult struct {
+	ArchiveID string
+	Branch    string
+	// Head is the active cell recreated from the requested archived cell.
+	Head *db.Cell
+	// Imported lists newly created cells, oldest first. Cells an earlier run
+	// imported onto the same parent are reused rather than duplicated.
+	Imported []db.Cell
+}
+
+// archiveSource is an open archive database and object store.
+type archiveSource struct {
+	id    string
+	db    *db.DB
+	store *store.Store
+}
+
+func (s *Service) openArchive(archiveID string) (*archiveSource, error) {
+	dbPath, objectsPath, err := s.ArchiveStatePaths(archiveID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, fmt.Errorf("archive %s not found: %w", archiveID, err)
+		}
+		return nil, err
+	}
+	archiveDB, err := db.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive db %s: %w", archiveID, err)
+	}
+	return &archiveSource{id: archiveID, db: archiveDB, store: s.ArchiveStore(objectsPath)}, nil
+}
+
+// ImportArchiveLineage recreates an archived cell and all of its ancestors in
+// the active history on a new branch. An empty cellID imports the head of
+// the archive's active branch; an empty branch is named after the archive.
+func (s *Service) ImportArchiveLineage(archiveID, cellID, branch string) (*ArchiveImportResult, error) {
+	src, err := s.openArchive(archiveID)
+	if err != nil {
+		return nil, err
+	}
+	defer src.db.Close()
+
+	if cellID == "" {
+		cellID, err = archiveHeadCell(src.db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	resolved, err := ResolveRevisionIn(src.db, cellID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, fmt.Errorf("cell %s not found", ArchiveCellRef(archiveID, cellID))
+		}
+		return nil, err
+	}
+	cellID = resolved
+	branch = strings.TrimSpace(branch)
+	if branch == "" {
+		branch = "archive-" + archiveID
+	}
+	if err := ValidateRevisionName(branch); err != nil {
+		return nil, fmt.Errorf("invalid branch name: %w", err)
+	}
+	if _, err := s.DB.GetBranch(branch); err == nil {
+		return nil, fmt.Errorf("branch %q already exists", branch)
+	} else if err != db.ErrNotFound {
+		return nil, err
+	}
+
+	lineage, err := archiveLineage(src.db, cellID)
+	if err != nil {
+		return nil, err
+	}
+	result := &ArchiveImportResult{ArchiveID: archiveID, Branch: branch}
+	var parentID *string
+	for _, cell := range lineage {
+		imported, created, err := s.importArchivedCell(src, cell, parentID, branch)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.Imported = append(result.Imported, *imported)
+		}
+		parentID = &imported.ID
+		result.Head = imported
+	}
+
+	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.DB.CreateBranch(branch, &result.Head.ID, createdAt); err != nil {
+		return nil, fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	return result, nil
+}
+
+// RestoreArchivedCell recreates one archived cell on top of the active
+// branch head and restores the working tree to it. It returns the safety
+// cell and the recreated cell.
+func (s *Service) RestoreArchivedCell(ctx context.Context, ref string) (*db.Cell, *db.Cell, error) {
+	archiveID, cellID := SplitCellRef(ref)
+	if archiveID == "" {
+		return nil, nil, fmt.Errorf("%s is not an archived cell ref", ref)
+	}
+	src, err := s.openArchive(archiveID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer src.db.Close()
+
+	cellID, err = ResolveRevisionIn(src.db, cellID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, nil, fmt.Errorf("cell %s not found", ref)
+		}
+		return nil, nil, err
+	}
+	cell, err := src.db.GetCell(cellID)
+	if err != nil {
+		return nil, nil, err
+	}
+	branch, err := s.ActiveBranch()
+	if err != nil {
+		return nil, nil, err
+	}
+	head, err := s.branchHeadCell(branch)
+	if err != nil {
+		return nil, nil, fmt.Errorf("branch head for %s: %w", branch, err)
+	}
+	var parentID *string
+	if head != nil {
+		parentID = &head.ID
+	}
+
+	target, _, err := s.importArchivedCell(src, *cell, parentID, branch)
+	if err != nil {
+		return nil, nil, err
+	}
+	safety, err := s.RestoreCell(ctx, target.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return safety, target, nil
+}
+
+// importArchivedCell copies an archived cell's blobs into the active store
+// and inserts it with a new ID, recording its origin, along with its notes,
+// eval, coverage, and benchmark details. A cell already imported from the
+// same origin onto the same parent is returned as is; the same origin under a
+// different parent (e.g. an earlier restore onto another head) gets a new
+// cell so lineage stays intact.
+func (s *Service) importArchivedCell(src *archiveSource, cell db.Cell, parentID *string, branch string) (*db.Cell, bool, error) {
+	origin := ArchiveCellRef(src.id, cell.ID)
+	if existing, err := s.DB.GetImportedCell(origin, parentID); err == nil {
+		return existing, false, nil
+	} else if err != db.ErrNotFound {
+		return nil, false, err
+	}
+
+	manifest, err := src.db.GetManifest(cell.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("get archived manifest %s: %w", origin, err)
+	}
+	for _, entry := range manifest {
+		data, err := src.store.Read(entry.Hash)
+		if err != nil {
+			return nil, false, fmt.Errorf("read archived object for %s: %w", entry.Path, err)
+		}
+		if _, err := s.Store.Write(data); err != nil {
+			return nil, false, fmt.Errorf("copy object for %s: %w", entry.Path, err)
+		}
+	}
+
+	seq, err := s.DB.AllocateSequence()
+	if err != nil {
+		return nil, false, fmt.Errorf("allocate sequence: %w", err)
+	}
+	imported := cell
+	imported.ID = CellID(seq)
+	imported.Sequence = seq
+	imported.ParentID = parentID
+	imported.Branch = branch
+	imported.Origin = &origin
+	entries := make([]db.ManifestEntry, 0, len(manifest))
+	for _, entry := range manifest {
+		entry.CellID = imported.ID
+		entries = append(entries, entry)
+	}
+	if err := s.DB.InsertImportedCell(imported, entries); err != nil {
+		return nil, false, fmt.Errorf("insert imported cell %s: %w", origin, err)
+	}
+	if err := copyCellDetails(src.db, s.DB, cell.ID, imported); err != nil {
+		return nil, false, fmt.Errorf("copy details of %s: %w", origin, err)
+	}
+	score, err := s.ScoreCell(imported.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("score imported cell %s: %w", origin, err)
+	}
+	imported.Score = score
+	return &imported, true, nil
+}
+
+// copyCellDetails copies the per-cell rows that live outside the cells table
+// from srcID in from to the imported cell in to.
+func copyCellDetails(from, to *db.DB, srcID string, imported db.Cell) error {
+	notes, err := from.ListNotes(srcID)
+	if err != nil {
+		return err
+	}
+	if err := to.ImportNotes(imported.ID, notes); err != nil {
+		return err
+	}
+	projects, err := from.ListEvalProjects(srcID)
+	if err != nil {
+		return err
+	}
+	if err := to.ReplaceEvalProjects(imported.ID, projects); err != nil {
+		return err
+	}
+	files, err := from.ListFileCoverage(srcID)
+	if err != nil {
+		return err
+	}
+	if err := to.ReplaceCellCoverage(imported.ID, imported.CoveragePct, files); err != nil {
+		return err
+	}
+	samples, err := from.ListBenchSamples(srcID)
+	if err != nil {
+		return err
+	}
+	return to.ReplaceBenchSamples(imported.ID, samples)
+}
+
+// archiveLineage returns cellID and its ancestors in an archive, root first.
+func archiveLineage(database *db.DB, cellID string) ([]db.Cell, error) {
+	var lineage []db.Cell
+	for id := cellID; id != ""; {
+		cell, err := database.GetCell(id)
+		if err != nil {
+			if err == db.ErrNotFound {
+				return nil, fmt.Errorf("archived cell %s not found", id)
+			}
+			return nil, err
+		}
+		lineage = append(lineage, *cell)
+		id = ""
+		if cell.ParentID != nil {
+			id = *cell.ParentID
+		}
+	}
+	for i, j := 0, len(lineage)-1; i < j; i, j = i+1, j-1 {
+		lineage[i], lineage[j] = lineage[j], lineage[i]
+	}
+	return lineage, nil
+}
+
+// archiveHeadCell returns the head of the archive's active branch.
+func archiveHeadCell(database *db.DB) (string, error) {
+	branch, err := database.GetMeta("active_branch")
+	if err != nil || strings.TrimSpace(branch) == "" {
+		branch = defaultBranchName
+	}
+	b, err := database.GetBranch(strings.TrimSpace(branch))
+	if err != nil {
+		return "", fmt.Errorf("archive branch %s: %w", branch, err)
+	}
+	if b.HeadCellID == nil || strings.TrimSpace(*b.HeadCellID) == "" {
+		return "", fmt.Errorf("archive branch %s has no cells", branch)
+	}
+	return *b.HeadCellID, nil
+}
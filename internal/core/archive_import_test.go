@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/eval"
+	"github.com/prit3010/converge/internal/store"
+)
+
+// newArchivedService returns a service whose state lives in an archive
+// directory of svc, so cells created through it become archived cells.
+func newArchivedService(t *testing.T, svc *Service, archiveID string) *Service {
+	t.Helper()
+	archiveRoot := filepath.Join(svc.ProjectDir, ".converge", "archives", archiveID)
+	if err := os.MkdirAll(filepath.Join(archiveRoot, "objects"), 0o755); err != nil {
+		t.Fatalf("mkdir archive objects: %v", err)
+	}
+	database, err := db.Open(filepath.Join(archiveRoot, "converge.db"))
+	if err != nil {
+		t.Fatalf("open archive db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+	return NewService(t.TempDir(), database, store.New(filepath.Join(archiveRoot, "objects")), eval.NewRunner())
+}
+
+func TestImportArchiveLineageCreatesBranchWithOrigin(t *testing.T) {
+	svc := newTestService(t)
+	archived := newArchivedService(t, svc, "a_test")
+	ctx := context.Background()
+
+	mainPath := filepath.Join(archived.ProjectDir, "main.go")
+	for i, body := range []string{"package main\n", "package main\nfunc main() {}\n"} {
+		if err := os.WriteFile(mainPath, []byte(body), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		if _, err := archived.CreateCell(ctx, SnapOptions{Message: "archived", RunEval: false}); err != nil {
+			t.Fatalf("create archived cell %d: %v", i, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package current\n"), 0o644); err != nil {
+		t.Fatalf("write current main.go: %v", err)
+	}
+	if _, err := svc.CreateCell(ctx, SnapOptions{Message: "current", RunEval: false}); err != nil {
+		t.Fatalf("create current cell: %v", err)
+	}
+
+	result, err := svc.ImportArchiveLineage("a_test", "", "revived")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Head.ID != "c_000003" {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+	if result.Head.Origin == nil || *result.Head.Origin != "a_test:c_000002" {
+		t.Fatalf("expected origin a_test:c_000002, got %v", result.Head.Origin)
+	}
+	if result.Head.ParentID == nil || *result.Head.ParentID != "c_000002" {
+		t.Fatalf("expected imported parent c_000002, got %v", result.Head.ParentID)
+	}
+	branch, err := svc.DB.GetBranch("revived")
+	if err != nil || branch.HeadCellID == nil || *branch.HeadCellID != result.Head.ID {
+		t.Fatalf("expected revived branch at %s, got %+v, %v", result.Head.ID, branch, err)
+	}
+	active, err := svc.ActiveBranch()
+	if err != nil || active != "main" {
+		t.Fatalf("expected active branch unchanged, got %q, %v", active, err)
+	}
+
+	if _, err := svc.ImportArchiveLineage("a_test", "", "revived"); err == nil {
+		t.Fatalf("expected duplicate branch to fail")
+	}
+	if _, err := svc.ImportArchiveLineage("a_test", "", "best"); err == nil || !strings.Contains(err.Error(), "invalid branch name") {
+		t.Fatalf("expected reserved branch name to fail, got %v", err)
+	}
+	again, err := svc.ImportArchiveLineage("a_test", "c_000001", "revived-root")
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if len(again.Imported) != 0 || again.Head.ID != "c_000002" {
+		t.Fatalf("expected re-import to reuse c_000002, got %+v", again)
+	}
+}
+
+func TestRestoreArchivedCellRestoresWorkingTree(t *testing.T) {
+	svc := newTestService(t)
+	archived := newArchivedService(t, svc, "a_test")
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(archived.ProjectDir, "main.go"), []byte("package archived\n"), 0o644); err != nil {
+		t.Fatalf("write archived main.go: %v", err)
+	}
+	if _, err := archived.CreateCell(ctx, SnapOptions{Message: "archived", RunEval: false}); err != nil {
+		t.Fatalf("create archived cell: %v", err)
+	}
+	mainPath := filepath.Join(svc.ProjectDir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package current\n"), 0o644); err != nil {
+		t.Fatalf("write current main.go: %v", err)
+	}
+	current, err := svc.CreateCell(ctx, SnapOptions{Message: "current", RunEval: false})
+	if err != nil {
+		t.Fatalf("create current cell: %v", err)
+	}
+
+	_, target, err := svc.RestoreArchivedCell(ctx, "a_test:c_000001")
+	if err != nil {
+		t.Fatalf("restore archived cell: %v", err)
+	}
+	if target.ParentID == nil || *target.ParentID != current.ID || target.Branch != "main" {
+		t.Fatalf("expected import on top of %s in main, got %+v", current.ID, target)
+	}
+	data, err := os.ReadFile(mainPath)
+	if err != nil {
+		t.Fatalf("read main.go: %v", err)
+	}
+	if string(data) != "package archived\n" {
+		t.Fatalf("expected archived contents, got %q", data)
+	}
+}
+
+func TestImportArchiveLineageAfterRestoreKeepsLineageAndDetails(t *testing.T) {
+	svc := newTestService(t)
+	archived := newArchivedService(t, svc, "a_test")
+	ctx := context.Background()
+	setScoreConfig(t, svc, "[score]\nexpression = \"coverage\"\n")
+
+	mainPath := filepath.Join(archived.ProjectDir, "main.go")
+	for i, body := range []string{"package main\n", "package main\nfunc main() {}\n"} {
+		if err := os.WriteFile(mainPath, []byte(body), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		if _, err := archived.CreateCell(ctx, SnapOptions{Message: "archived", RunEval: false}); err != nil {
+			t.Fatalf("create archived cell %d: %v", i, err)
+		}
+	}
+	pct := 75.0
+	if err := archived.DB.ReplaceCellCoverage("c_000002", &pct, []db.FileCoverage{{Path: "main.go", Covered: 3, Total: 4}}); err != nil {
+		t.Fatalf("set coverage: %v", err)
+	}
+	if err := archived.DB.ReplaceBenchSamples("c_000002", []db.BenchSample{{Name: "BenchmarkMain", Unit: "ns/op", Value: 12}}); err != nil {
+		t.Fatalf("set bench: %v", err)
+	}
+	if err := archived.DB.ReplaceEvalProjects("c_000002", []db.EvalProject{{Dir: ".", ProjectTypes: "go"}}); err != nil {
+		t.Fatalf("set eval projects: %v", err)
+	}
+	if _, err := archived.DB.AddNote(db.Note{CellID: "c_000002", Body: "keep this"}); err != nil {
+		t.Fatalf("add note: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package current\n"), 0o644); err != nil {
+		t.Fatalf("write current main.go: %v", err)
+	}
+	current, err := svc.CreateCell(ctx, SnapOptions{Message: "current", RunEval: false})
+	if err != nil {
+		t.Fatalf("create current cell: %v", err)
+	}
+	_, restored, err := svc.RestoreArchivedCell(ctx, "a_test:c_000002")
+	if err != nil {
+		t.Fatalf("restore archived cell: %v", err)
+	}
+	if restored.ParentID == nil || *restored.ParentID != current.ID {
+		t.Fatalf("expected restore on top of %s, got %+v", current.ID, restored)
+	}
+
+	result, err := svc.ImportArchiveLineage("a_test", "c_000002", "revived")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Head.ID == restored.ID {
+		t.Fatalf("expected a fresh lineage instead of reusing %s, got %+v", restored.ID, result)
+	}
+	root := result.Imported[0]
+	if root.ParentID != nil || result.Head.ParentID == nil || *result.Head.ParentID != root.ID {
+		t.Fatalf("expected %s -> %s lineage, got root parent %v, head parent %v", root.ID, result.Head.ID, root.ParentID, result.Head.ParentID)
+	}
+
+	head := result.Head.ID
+	if notes, err := svc.DB.ListNotes(head); err != nil || len(notes) != 1 || notes[0].Body != "keep this" {
+		t.Fatalf("expected note copied, got %+v (err %v)", notes, err)
+	}
+	if files, err := svc.DB.ListFileCoverage(head); err != nil || len(files) != 1 || files[0].Covered != 3 {
+		t.Fatalf("expected file coverage copied, got %+v (err %v)", files, err)
+	}
+	if samples, err := svc.DB.ListBenchSamples(head); err != nil || len(samples) != 1 || samples[0].Value != 12 {
+		t.Fatalf("expected bench samples copied, got %+v (err %v)", samples, err)
+	}
+	if projects, err := svc.DB.ListEvalProjects(head); err != nil || len(projects) != 1 {
+		t.Fatalf("expected eval projects copied, got %+v (err %v)", projects, err)
+	}
+	if got, err := svc.DB.GetCell(head); err != nil || got.Score == nil || *got.Score != 75 {
+		t.Fatalf("expected imported cell scored from its coverage, got %+v (err %v)", got, err)
+	}
+}
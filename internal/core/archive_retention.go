@@ -0,0 +1,464 @@
+package core
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/store"
+)
+
+// Archive bundle compressions written by ExportArchive. Imports detect the
+// compression from the bundle's magic bytes.
+const (
+	ArchiveCompressionZstd = "zstd"
+	ArchiveCompressionGzip = "gzip"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ArchiveCompressionFor picks the compression a bundle file name implies:
+// gzip for .gz and .tgz, zstd otherwise.
+func ArchiveCompressionFor(name string) string {
+	if strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		return ArchiveCompressionGzip
+	}
+	return ArchiveCompressionZstd
+}
+
+type PruneOptions struct {
+	// Keep is the number of newest archives that are never pruned.
+	Keep int
+	// OlderThan limits pruning to archives archived at least this long ago.
+	OlderThan time.Duration
+	DryRun    bool
+}
+
+type PruneResult struct {
+	Removed    []ArchiveMeta
+	BlobsFreed int
+	BytesFreed int64
+	DryRun     bool
+}
+
+type CompactResult struct {
+	Archives     int
+	BlobsMoved   int
+	Deduplicated int
+	BytesFreed   int64
+}
+
+func (s *Service) archivesDir() string {
+	return filepath.Join(s.ProjectDir, config.StateDirName, config.ArchivesDirName)
+}
+
+func (s *Service) sharedArchiveStore() *store.Store {
+	return store.New(filepath.Join(s.archivesDir(), config.SharedObjectsDir))
+}
+
+// ArchiveStore opens an archive's object store, falling back to the blobs
+// shared across archives by compaction.
+func (s *Service) ArchiveStore(objectsPath string) *store.Store {
+	return store.New(objectsPath).WithFallback(s.sharedArchiveStore())
+}
+
+// CompactArchives moves every archive's blobs into the shared archive store,
+// dropping copies the shared store already holds.
+func (s *Service) CompactArchives() (*CompactResult, error) {
+	unlock, err := s.writeArchiveLock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	archives, err := s.ListArchiveMetadata()
+	if err != nil {
+		return nil, err
+	}
+	result := &CompactResult{}
+	for _, archive := range archives {
+		if err := s.compactArchive(archive.ArchiveID, result); err != nil {
+			return nil, err
+		}
+		result.Archives++
+	}
+	return result, nil
+}
+
+func (s *Service) compactArchive(archiveID string, result *CompactResult) error {
+	_, objectsPath, err := s.ArchiveStatePaths(archiveID)
+	if err != nil {
+		return fmt.Errorf("resolve archive %s: %w", archiveID, err)
+	}
+	shared := s.sharedArchiveStore()
+	local := store.New(objectsPath)
+	hashes, err := local.Hashes()
+	if err != nil {
+		return fmt.Errorf("list objects of archive %s: %w", archiveID, err)
+	}
+	for _, hash := range hashes {
+		duplicate, freed, err := local.MoveTo(shared, hash)
+		if err != nil {
+			return fmt.Errorf("compact archive %s: %w", archiveID, err)
+		}
+		if duplicate {
+			result.Deduplicated++
+			result.BytesFreed += freed
+		} else {
+			result.BlobsMoved++
+		}
+	}
+	return nil
+}
+
+// PruneArchives deletes archives outside the newest opts.Keep that are also
+// older than opts.OlderThan, then drops shared blobs no remaining archive
+// references.
+func (s *Service) PruneArchives(opts PruneOptions) (*PruneResult, error) {
+	if opts.Keep <= 0 && opts.OlderThan <= 0 {
+		return nil, fmt.Errorf("prune requires a keep count or an age")
+	}
+	unlock, err := s.writeArchiveLock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	archives, err := s.ListArchiveMetadata()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-opts.OlderThan)
+	result := &PruneResult{DryRun: opts.DryRun}
+	for i, archive := range archives {
+		if opts.Keep > 0 && i < opts.Keep {
+			continue
+		}
+		if opts.OlderThan > 0 && parseRFC3339NanoOrZero(archive.ArchivedAt).After(cutoff) {
+			continue
+		}
+		result.Removed = append(result.Removed, archive)
+	}
+	if opts.DryRun || len(result.Removed) == 0 {
+		return result, nil
+	}
+
+	for _, archive := range result.Removed {
+		if _, _, err := s.ArchiveStatePaths(archive.ArchiveID); err != nil {
+			return nil, fmt.Errorf("resolve archive %s: %w", archive.ArchiveID, err)
+		}
+		archiveDir := filepath.Join(s.archivesDir(), archive.ArchiveID)
+		// Rename first so a failed removal never leaves a half-deleted
+		// archive that still lists.
+		trash := filepath.Join(s.archivesDir(), "."+archive.ArchiveID+".pruning")
+		if err := os.Rename(archiveDir, trash); err != nil {
+			return nil, fmt.Errorf("remove archive %s: %w", archive.ArchiveID, err)
+		}
+		if err := os.RemoveAll(trash); err != nil {
+			return nil, fmt.Errorf("remove archive %s: %w", archive.ArchiveID, err)
+		}
+	}
+
+	blobs, bytes, err := s.collectSharedObjects()
+	if err != nil {
+		return nil, err
+	}
+	result.BlobsFreed = blobs
+	result.BytesFreed = bytes
+	return result, nil
+}
+
+// collectSharedObjects removes shared blobs that no archive manifest uses.
+func (s *Service) collectSharedObjects() (int, int64, error) {
+	shared := s.sharedArchiveStore()
+	hashes, err := shared.Hashes()
+	if err != nil || len(hashes) == 0 {
+		return 0, 0, err
+	}
+
+	archives, err := s.ListArchiveMetadata()
+	if err != nil {
+		return 0, 0, err
+	}
+	referenced := make(map[string]bool)
+	for _, archive := range archives {
+		dbPath, _, err := s.ArchiveStatePaths(archive.ArchiveID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("resolve archive %s: %w", archive.ArchiveID, err)
+		}
+		archiveDB, err := db.Open(dbPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("open archive db %s: %w", archive.ArchiveID, err)
+		}
+		used, err := archiveDB.ListManifestHashes()
+		archiveDB.Close()
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, hash := range used {
+			referenced[hash] = true
+		}
+	}
+
+	blobs := 0
+	var bytes int64
+	for _, hash := range hashes {
+		if referenced[hash] {
+			continue
+		}
+		size, err := shared.Remove(hash)
+		if err != nil {
+			return 0, 0, err
+		}
+		blobs++
+		bytes += size
+	}
+	return blobs, bytes, nil
+}
+
+// ExportArchive writes an archive as a self-contained tar of its metadata,
+// database, and every blob its manifests use, compressed with compression.
+func (s *Service) ExportArchive(archiveID string, w io.Writer, compression string) error {
+	var cw io.WriteCloser
+	switch compression {
+	case ArchiveCompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return fmt.Errorf("start archive export: %w", err)
+		}
+		cw = zw
+	case ArchiveCompressionGzip:
+		cw = gzip.NewWriter(w)
+	default:
+		return fmt.Errorf("invalid archive compression %q (expected zstd or gzip)", compression)
+	}
+
+	dbPath, objectsPath, err := s.ArchiveStatePaths(archiveID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return fmt.Errorf("archive %s not found: %w", archiveID, err)
+		}
+		return err
+	}
+	archiveDir := filepath.Dir(dbPath)
+	meta, err := os.ReadFile(filepath.Join(archiveDir, config.ArchiveMetaFile))
+	if err != nil {
+		return fmt.Errorf("read archive metadata: %w", err)
+	}
+
+	archiveDB, err := db.Open(dbPath)
+	if err != nil {
+		return fmt.Errorf("open archive db %s: %w", archiveID, err)
+	}
+	defer archiveDB.Close()
+	tmpDir, err := os.MkdirTemp("", "converge-export-")
+	if err != nil {
+		return fmt.Errorf("create export temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	backupPath := filepath.Join(tmpDir, config.DBFileName)
+	if err := archiveDB.BackupTo(backupPath); err != nil {
+		return err
+	}
+	dbBytes, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("read database backup: %w", err)
+	}
+	hashes, err := archiveDB.ListManifestHashes()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(cw)
+	writeEntry := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    path.Join(archiveID, name),
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: time.Now().UTC(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		return nil
+	}
+	if err := writeEntry(config.ArchiveMetaFile, meta); err != nil {
+		return err
+	}
+	if err := writeEntry(config.DBFileName, dbBytes); err != nil {
+		return err
+	}
+	blobs := s.ArchiveStore(objectsPath)
+	for _, hash := range hashes {
+		data, err := blobs.Read(hash)
+		if err != nil {
+			return fmt.Errorf("export archive %s: %w", archiveID, err)
+		}
+		if err := writeEntry(path.Join(config.ObjectsDirName, hash[:2], hash), data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("finish archive export: %w", err)
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("finish archive export: %w", err)
+	}
+	return nil
+}
+
+// ImportArchiveBundle unpacks an archive written by ExportArchive into the
+// archives directory and compacts its blobs into the shared store.
+func (s *Service) ImportArchiveBundle(r io.Reader) (*ArchiveMeta, error) {
+	unlock, err := s.writeArchiveLock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := os.MkdirAll(s.archivesDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("create archives dir: %w", err)
+	}
+	stageDir, err := os.MkdirTemp(s.archivesDir(), ".import-")
+	if err != nil {
+		return nil, fmt.Errorf("create archive staging dir: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	if err := extractArchiveBundle(r, stageDir); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(stageDir, config.ArchiveMetaFile))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive bundle: missing %s", config.ArchiveMetaFile)
+	}
+	var meta ArchiveMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid archive bundle metadata: %w", err)
+	}
+	meta.ArchiveID = strings.TrimSpace(meta.ArchiveID)
+	if meta.ArchiveID == "" || meta.ArchiveID == "current" || strings.HasPrefix(meta.ArchiveID, ".") ||
+		strings.Contains(meta.ArchiveID, "..") || strings.ContainsAny(meta.ArchiveID, `/\`) {
+		return nil, fmt.Errorf("invalid archive id %q in bundle", meta.ArchiveID)
+	}
+	if _, err := os.Stat(filepath.Join(stageDir, config.DBFileName)); err != nil {
+		return nil, fmt.Errorf("invalid archive bundle: missing %s", config.DBFileName)
+	}
+	if err := os.MkdirAll(filepath.Join(stageDir, config.ObjectsDirName), 0o755); err != nil {
+		return nil, fmt.Errorf("create archive objects dir: %w", err)
+	}
+	if err := verifyObjects(store.New(filepath.Join(stageDir, config.ObjectsDirName))); err != nil {
+		return nil, err
+	}
+
+	archiveDir := filepath.Join(s.archivesDir(), meta.ArchiveID)
+	if _, err := os.Stat(archiveDir); err == nil {
+		return nil, fmt.Errorf("archive %s already exists", meta.ArchiveID)
+	}
+	if err := os.Rename(stageDir, archiveDir); err != nil {
+		return nil, fmt.Errorf("finalize archive directory: %w", err)
+	}
+	if err := s.compactArchive(meta.ArchiveID, &CompactResult{}); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// verifyObjects checks that every blob is stored under its own hash, so an
+// imported bundle cannot plant content the shared store would hand out for
+// another hash.
+func verifyObjects(objects *store.Store) error {
+	hashes, err := objects.Hashes()
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		data, err := objects.Read(hash)
+		if err != nil {
+			return err
+		}
+		if store.Hash(data) != hash {
+			return fmt.Errorf("invalid archive bundle: object %s does not match its hash", hash)
+		}
+	}
+	return nil
+}
+
+// extractArchiveBundle unpacks a bundle's single top-level directory into
+// dir, rejecting entries that would land outside it.
+func extractArchiveBundle(r io.Reader, dir string) error {
+	decompressed, err := decompressArchiveBundle(r)
+	if err != nil {
+		return fmt.Errorf("invalid archive bundle: %w", err)
+	}
+	defer decompressed.Close()
+	tr := tar.NewReader(decompressed)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		_, rel, found := strings.Cut(name, "/")
+		if !found || rel == "" || path.IsAbs(name) || strings.HasPrefix(rel, "../") || rel == ".." {
+			return fmt.Errorf("invalid archive bundle entry %q", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("extract %s: %w", rel, err)
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("extract %s: %w", rel, err)
+		}
+		_, copyErr := io.Copy(file, tr)
+		closeErr := file.Close()
+		if copyErr != nil {
+			return fmt.Errorf("extract %s: %w", rel, copyErr)
+		}
+		if closeErr != nil {
+			return fmt.Errorf("extract %s: %w", rel, closeErr)
+		}
+	}
+}
+
+// decompressArchiveBundle wraps r in the decompressor its magic bytes name.
+func decompressArchiveBundle(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	}
+	return nil, fmt.Errorf("not a zstd or gzip compressed tar")
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newMetaArchive creates an archive holding one cell of main.go with body,
+// archived age ago.
+func newMetaArchive(t *testing.T, svc *Service, archiveID, body string, age time.Duration) {
+	t.Helper()
+	archived := newArchivedService(t, svc, archiveID)
+	if err := os.WriteFile(filepath.Join(archived.ProjectDir, "main.go"), []byte(body), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if _, err := archived.CreateCell(context.Background(), SnapOptions{Message: archiveID, RunEval: false}); err != nil {
+		t.Fatalf("create archived cell: %v", err)
+	}
+	meta, err := json.Marshal(ArchiveMeta{
+		ArchiveID:  archiveID,
+		ArchivedAt: time.Now().UTC().Add(-age).Format(time.RFC3339Nano),
+		CellCount:  1,
+	})
+	if err != nil {
+		t.Fatalf("marshal meta: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(svc.archivesDir(), archiveID, "meta.json"), meta, 0o644); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+}
+
+func TestCompactAndPruneArchives(t *testing.T) {
+	svc := newTestService(t)
+	newMetaArchive(t, svc, "a_old", "package shared\n", 60*24*time.Hour)
+	newMetaArchive(t, svc, "a_mid", "package shared\n", 40*24*time.Hour)
+	newMetaArchive(t, svc, "a_new", "package fresh\n", time.Hour)
+
+	compacted, err := svc.CompactArchives()
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if compacted.Archives != 3 || compacted.BlobsMoved != 2 || compacted.Deduplicated != 1 {
+		t.Fatalf("unexpected compaction: %+v", compacted)
+	}
+	view, err := svc.LoadCellView("a_old:c_000001")
+	if err != nil {
+		t.Fatalf("load compacted cell: %v", err)
+	}
+	if data, err := view.Blobs.Read(view.Manifest[0].Hash); err != nil || string(data) != "package shared\n" {
+		t.Fatalf("read compacted blob: %q, %v", data, err)
+	}
+
+	pruned, err := svc.PruneArchives(PruneOptions{Keep: 1, OlderThan: 50 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if len(pruned.Removed) != 1 || pruned.Removed[0].ArchiveID != "a_old" || pruned.BlobsFreed != 0 {
+		t.Fatalf("expected only a_old pruned with its blob still shared, got %+v", pruned)
+	}
+
+	pruned, err = svc.PruneArchives(PruneOptions{Keep: 1})
+	if err != nil {
+		t.Fatalf("prune keep 1: %v", err)
+	}
+	if len(pruned.Removed) != 1 || pruned.Removed[0].ArchiveID != "a_mid" || pruned.BlobsFreed != 1 {
+		t.Fatalf("expected a_mid pruned and its blob freed, got %+v", pruned)
+	}
+	archives, err := svc.ListArchiveMetadata()
+	if err != nil || len(archives) != 1 || archives[0].ArchiveID != "a_new" {
+		t.Fatalf("expected only a_new left, got %+v, %v", archives, err)
+	}
+}
+
+func TestExportAndImportArchiveBundle(t *testing.T) {
+	src := newTestService(t)
+	newMetaArchive(t, src, "a_moved", "package moved\n", time.Hour)
+	if _, err := src.CompactArchives(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	for _, compression := range []string{ArchiveCompressionZstd, ArchiveCompressionGzip} {
+		var bundle bytes.Buffer
+		if err := src.ExportArchive("a_moved", &bundle, compression); err != nil {
+			t.Fatalf("export %s: %v", compression, err)
+		}
+		magic := zstdMagic
+		if compression == ArchiveCompressionGzip {
+			magic = gzipMagic
+		}
+		if !bytes.HasPrefix(bundle.Bytes(), magic) {
+			t.Fatalf("expected a %s bundle, got % x", compression, bundle.Bytes()[:4])
+		}
+
+		dst := newTestService(t)
+		meta, err := dst.ImportArchiveBundle(bytes.NewReader(bundle.Bytes()))
+		if err != nil {
+			t.Fatalf("import %s: %v", compression, err)
+		}
+		if meta.ArchiveID != "a_moved" {
+			t.Fatalf("expected a_moved, got %s", meta.ArchiveID)
+		}
+		view, err := dst.LoadCellView("a_moved:c_000001")
+		if err != nil {
+			t.Fatalf("load imported cell: %v", err)
+		}
+		if data, err := view.Blobs.Read(view.Manifest[0].Hash); err != nil || string(data) != "package moved\n" {
+			t.Fatalf("read imported blob: %q, %v", data, err)
+		}
+		if _, err := dst.ImportArchiveBundle(bytes.NewReader(bundle.Bytes())); err == nil {
+			t.Fatalf("expected importing the same archive twice to fail")
+		}
+	}
+	if err := src.ExportArchive("a_moved", io.Discard, "lz4"); err == nil {
+		t.Fatalf("expected unknown compression to fail")
+	}
+	if got := ArchiveCompressionFor("a.tar.gz"); got != ArchiveCompressionGzip {
+		t.Fatalf("expected gzip for .tar.gz, got %s", got)
+	}
+	if got := ArchiveCompressionFor("a.tar.zst"); got != ArchiveCompressionZstd {
+		t.Fatalf("expected zstd for .tar.zst, got %s", got)
+	}
+}
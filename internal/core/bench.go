@@ -0,0 +1,45 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/prit3010/converge/internal/bench"
+	"github.com/prit3010/converge/internal/db"
+)
+
+// BenchSummaries returns the benchmark metrics recorded for a cell.
+func (s *Service) BenchSummaries(cellID string) ([]bench.Summary, error) {
+	if _, err := s.DB.GetCell(cellID); err != nil {
+		if err == db.ErrNotFound {
+			return nil, fmt.Errorf("cell %s not found", cellID)
+		}
+		return nil, err
+	}
+	rows, err := s.DB.ListBenchSamples(cellID)
+	if err != nil {
+		return nil, err
+	}
+	return bench.Summarize(BenchSamplesFromRows(rows)), nil
+}
+
+// CompareBench runs a significance comparison of the metrics recorded on two
+// cells.
+func (s *Service) CompareBench(cellA, cellB string) ([]bench.Comparison, error) {
+	a, err := s.BenchSummaries(cellA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.BenchSummaries(cellB)
+	if err != nil {
+		return nil, err
+	}
+	return bench.CompareSummaries(a, b), nil
+}
+
+func BenchSamplesFromRows(rows []db.BenchSample) []bench.Sample {
+	out := make([]bench.Sample, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, bench.Sample{Name: row.Name, Unit: row.Unit, Value: row.Value})
+	}
+	return out
+}
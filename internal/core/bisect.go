@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/eval"
+)
+
+// bisectOutputLimit caps the command output kept with each bisect result.
+const bisectOutputLimit = 4096
+
+// BisectOptions names the known-good and known-bad ends of a parent chain.
+type BisectOptions struct {
+	Good string
+	Bad  string
+	// Command judges each candidate: exit status 0 is good, anything else
+	// bad. Empty runs the configured eval, which is bad on failing tests or
+	// an eval error.
+	Command string
+}
+
+// BisectReport is the outcome of a bisect. Steps lists the recorded verdicts
+// in the order they were run.
+type BisectReport struct {
+	FirstBad   string            `json:"first_bad"`
+	LastGood   string            `json:"last_good"`
+	Candidates int               `json:"candidates"`
+	Steps      []db.BisectResult `json:"steps"`
+}
+
+// Bisect binary-searches the ParentID chain between opts.Good and opts.Bad
+// for the first bad cell. Each candidate is materialized into its own
+// temporary directory, so the working tree is never touched, and its verdict
+// is recorded on the cell.
+func (s *Service) Bisect(ctx context.Context, opts BisectOptions) (*BisectReport, error) {
+	if opts.Command == "" && s.Evaluator == nil {
+		return nil, fmt.Errorf("evaluator is not configured")
+	}
+	chain, err := s.cellChain(opts.Good, opts.Bad)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BisectReport{Candidates: len(chain) - 2, Steps: []db.BisectResult{}}
+	good, bad := 0, len(chain)-1
+	for bad-good > 1 {
+		mid := (good + bad) / 2
+		result, err := s.bisectStep(ctx, &chain[mid], opts.Command)
+		if err != nil {
+			return nil, err
+		}
+		report.Steps = append(report.Steps, *result)
+		if result.Good {
+			good = mid
+		} else {
+			bad = mid
+		}
+	}
+	report.LastGood, report.FirstBad = chain[good].ID, chain[bad].ID
+	return report, nil
+}
+
+// cellChain returns the cells from fromID to toID inclusive, oldest first,
+// following toID's parents.
+func (s *Service) cellChain(fromID, toID string) ([]db.Cell, error) {
+	if fromID == toID {
+		return nil, fmt.Errorf("invalid cell range: both ends are %s", fromID)
+	}
+	var chain []db.Cell
+	for id := &toID; ; {
+		cell, err := s.DB.GetCell(*id)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", *id, err)
+		}
+		chain = append(chain, *cell)
+		if cell.ID == fromID {
+			break
+		}
+		if cell.ParentID == nil {
+			return nil, fmt.Errorf("invalid cell range: %s is not an ancestor of %s", fromID, toID)
+		}
+		id = cell.ParentID
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+func (s *Service) bisectStep(ctx context.Context, cell *db.Cell, command string) (*db.BisectResult, error) {
+	dir, err := os.MkdirTemp("", "converge-bisect-")
+	if err != nil {
+		return nil, fmt.Errorf("create bisect dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := s.materializeCell(cell.ID, dir); err != nil {
+		return nil, err
+	}
+
+	result := db.BisectResult{CellID: cell.ID, Command: command}
+	if command == "" {
+		// Always run the full eval: a changed-scope run only checks what the
+		// candidate touched and would miss failures it inherited.
+		evalResult, evalErr := s.Evaluator.Run(ctx, dir)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// The verdict lives in bisect_results. Only fill in the cell's eval
+		// when it has none, so a bisect never replaces the eval, score, or
+		// best pick the cell already had.
+		if !cell.EvalRan {
+			if err := s.recordEval(cell.ID, evalResult, evalErr); err != nil {
+				return nil, err
+			}
+		}
+		result.Good = evalErr == nil && evalResult.TestsFailed == 0
+		result.Output = bisectEvalSummary(evalResult, evalErr)
+	} else {
+		cmd := exec.CommandContext(ctx, "bash", "-c", command)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "CONVERGE_CELL="+cell.ID)
+		output, runErr := cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var exitErr *exec.ExitError
+		if runErr != nil && !errors.As(runErr, &exitErr) {
+			return nil, fmt.Errorf("run bisect command on %s: %w", cell.ID, runErr)
+		}
+		code := cmd.ProcessState.ExitCode()
+		result.Good = code == 0
+		result.ExitCode = &code
+		if len(output) > bisectOutputLimit {
+			output = output[len(output)-bisectOutputLimit:]
+		}
+		result.Output = string(output)
+	}
+	return s.DB.AddBisectResult(result)
+}
+
+// materializeCell writes a cell's tracked files into dir.
+func (s *Service) materializeCell(cellID, dir string) error {
+	manifest, err := s.DB.GetManifest(cellID)
+	if err != nil {
+		return fmt.Errorf("manifest for %s: %w", cellID, err)
+	}
+	for _, entry := range manifest {
+		data, err := s.Store.Read(entry.Hash)
+		if err != nil {
+			return fmt.Errorf("read object for %s: %w", entry.Path, err)
+		}
+		fullPath := filepath.Join(dir, filepath.FromSlash(entry.Path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return fmt.Errorf("mkdir for %s: %w", entry.Path, err)
+		}
+		if err := os.WriteFile(fullPath, data, os.FileMode(entry.Mode)); err != nil {
+			return fmt.Errorf("write file %s: %w", entry.Path, err)
+		}
+	}
+	return nil
+}
+
+func bisectEvalSummary(result eval.Result, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if !result.HasTests {
+		return "no tests ran"
+	}
+	return fmt.Sprintf("tests %d passed, %d failed", result.TestsPassed, result.TestsFailed)
+}
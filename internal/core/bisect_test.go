@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestBisectFindsFirstBadCellWithCommand(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	status := filepath.Join(svc.ProjectDir, "status.txt")
+	var ids []string
+	for _, content := range []string{"ok\n", "ok\n1\n", "ok\n2\n", "fail\n", "fail\n4\n", "fail\n5\n"} {
+		if err := os.WriteFile(status, []byte(content), 0o644); err != nil {
+			t.Fatalf("write status.txt: %v", err)
+		}
+		cell, err := svc.CreateCell(ctx, SnapOptions{Message: strings.TrimSpace(content), Source: "watch"})
+		if err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+		ids = append(ids, cell.ID)
+	}
+
+	report, err := svc.Bisect(ctx, BisectOptions{Good: ids[0], Bad: ids[5], Command: "grep -q ok status.txt"})
+	if err != nil {
+		t.Fatalf("bisect: %v", err)
+	}
+	if report.FirstBad != ids[3] || report.LastGood != ids[2] || report.Candidates != 4 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.Steps) != 2 {
+		t.Fatalf("expected 2 steps for 4 candidates, got %+v", report.Steps)
+	}
+	for _, step := range report.Steps {
+		recorded, err := svc.DB.ListBisectResults(step.CellID)
+		if err != nil {
+			t.Fatalf("list bisect results: %v", err)
+		}
+		if len(recorded) != 1 || recorded[0].Good != step.Good || recorded[0].ExitCode == nil {
+			t.Fatalf("expected recorded verdict for %s, got %+v", step.CellID, recorded)
+		}
+	}
+	// Candidates are materialized elsewhere; the working tree keeps the last snap.
+	data, err := os.ReadFile(status)
+	if err != nil || string(data) != "fail\n5\n" {
+		t.Fatalf("working tree changed: %q, %v", data, err)
+	}
+
+	if _, err := svc.Bisect(ctx, BisectOptions{Good: ids[5], Bad: ids[0], Command: "true"}); err == nil || !strings.Contains(err.Error(), "not an ancestor") {
+		t.Fatalf("expected not-an-ancestor error, got %v", err)
+	}
+}
+
+func TestBisectEvalIgnoresChangedScope(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	policy := config.DefaultPolicy()
+	policy.Eval.Scope = config.EvalScopeChanged
+	svc.SetPolicy(policy)
+
+	var ids []string
+	for _, content := range []string{"one\n", "two\n", "three\n"} {
+		if err := os.WriteFile(filepath.Join(svc.ProjectDir, "notes.txt"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		cell, err := svc.CreateCell(ctx, SnapOptions{Message: content, Source: "manual"})
+		if err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+		ids = append(ids, cell.ID)
+	}
+
+	if _, err := svc.Bisect(ctx, BisectOptions{Good: ids[0], Bad: ids[2]}); err != nil {
+		t.Fatalf("bisect: %v", err)
+	}
+	got, err := svc.DB.GetCell(ids[1])
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if got.EvalScope == nil || *got.EvalScope != string(config.EvalScopeFull) {
+		t.Fatalf("expected bisect to run a full eval, got scope %v", got.EvalScope)
+	}
+}
+
+func TestBisectEvalKeepsExistingCellEval(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, content := range []string{"one\n", "two\n", "three\n"} {
+		if err := os.WriteFile(filepath.Join(svc.ProjectDir, "notes.txt"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write notes.txt: %v", err)
+		}
+		cell, err := svc.CreateCell(ctx, SnapOptions{Message: content, Source: "manual"})
+		if err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+		ids = append(ids, cell.ID)
+	}
+	passed, failed := 7, 0
+	if err := svc.DB.UpdateCellEval(ids[1], &passed, &failed, nil, nil, nil, nil); err != nil {
+		t.Fatalf("seed eval: %v", err)
+	}
+
+	if _, err := svc.Bisect(ctx, BisectOptions{Good: ids[0], Bad: ids[2]}); err != nil {
+		t.Fatalf("bisect: %v", err)
+	}
+	got, err := svc.DB.GetCell(ids[1])
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if got.TestsPassed == nil || *got.TestsPassed != passed || got.EvalScope != nil {
+		t.Fatalf("expected bisect to keep the stored eval, got passed %v scope %v", got.TestsPassed, got.EvalScope)
+	}
+	results, err := svc.DB.ListBisectResults(ids[1])
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected the bisect verdict recorded separately, got %+v (err %v)", results, err)
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/diff"
+	"github.com/prit3010/converge/internal/snapshot"
+	"github.com/prit3010/converge/internal/store"
+)
+
+// FileChange is a cell in which a file's content changed.
+type FileChange struct {
+	Cell   db.Cell `json:"cell"`
+	Status string  `json:"status"`
+	Hash   string  `json:"hash,omitempty"`
+}
+
+// BlameLine attributes one line of a file to the cell that introduced it.
+type BlameLine struct {
+	Line      int     `json:"line"`
+	Text      string  `json:"text"`
+	CellID    string  `json:"cell_id"`
+	Agent     *string `json:"agent,omitempty"`
+	Message   string  `json:"message"`
+	Timestamp string  `json:"timestamp"`
+}
+
+func (s *Service) FileHistory(path string) ([]FileChange, error) {
+	return FileHistoryIn(s.DB, path)
+}
+
+// FileHistoryIn lists the cells, newest first, whose copy of path differs
+// from their parent's: the file was added, modified, or removed.
+func FileHistoryIn(database *db.DB, path string) ([]FileChange, error) {
+	hashes, err := database.ListPathHashes(path)
+	if err != nil {
+		return nil, err
+	}
+	cells, err := database.ListAllCells()
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]FileChange, 0)
+	for i := len(cells) - 1; i >= 0; i-- {
+		cell := cells[i]
+		hash, has := hashes[cell.ID]
+		parentHash, parentHas := "", false
+		if cell.ParentID != nil {
+			parentHash, parentHas = hashes[*cell.ParentID]
+		}
+		switch {
+		case has && !parentHas:
+			changes = append(changes, FileChange{Cell: cell, Status: "added", Hash: hash})
+		case has && hash != parentHash:
+			changes = append(changes, FileChange{Cell: cell, Status: "modified", Hash: hash})
+		case !has && parentHas:
+			changes = append(changes, FileChange{Cell: cell, Status: "removed"})
+		}
+	}
+	return changes, nil
+}
+
+func (s *Service) Blame(cellID, path string) ([]BlameLine, error) {
+	return BlameIn(s.DB, s.Store, cellID, path)
+}
+
+// BlameIn attributes each line of path at cellID to the cell that introduced
+// it, walking parents and mapping lines across each content change with the
+// diff engine. Lines still unclaimed when the file first appears belong to
+// that cell.
+func BlameIn(database *db.DB, blobs store.Reader, cellID, path string) ([]BlameLine, error) {
+	hashes, err := database.ListPathHashes(path)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := hashes[cellID]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in %s: %w", path, cellID, db.ErrNotFound)
+	}
+	lines, err := readLines(blobs, hash, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// pos[i] tracks where line i of the blamed file sits in the version
+	// currently being examined; owner[i] is set once a cell claims it.
+	pos := make([]int, len(lines))
+	owner := make([]*db.Cell, len(lines))
+	for i := range pos {
+		pos[i] = i
+	}
+	unclaimed := len(lines)
+	current, currentLines := cellID, lines
+	for unclaimed > 0 {
+		cell, err := database.GetCell(current)
+		if err != nil {
+			return nil, err
+		}
+		parentHash, parentHas := "", false
+		if cell.ParentID != nil {
+			parentHash, parentHas = hashes[*cell.ParentID]
+		}
+		if !parentHas {
+			for i := range owner {
+				if owner[i] == nil {
+					owner[i] = cell
+				}
+			}
+			break
+		}
+		if parentHash != hashes[current] {
+			parentLines, err := readLines(blobs, parentHash, path)
+			if err != nil {
+				return nil, err
+			}
+			mapping := diff.MatchLines(parentLines, currentLines)
+			for i := range pos {
+				if owner[i] != nil {
+					continue
+				}
+				if mapping[pos[i]] < 0 {
+					owner[i] = cell
+					unclaimed--
+					continue
+				}
+				pos[i] = mapping[pos[i]]
+			}
+			currentLines = parentLines
+		}
+		current = *cell.ParentID
+	}
+
+	out := make([]BlameLine, len(lines))
+	for i, text := range lines {
+		out[i] = BlameLine{
+			Line:      i + 1,
+			Text:      text,
+			CellID:    owner[i].ID,
+			Agent:     owner[i].Agent,
+			Message:   owner[i].Message,
+			Timestamp: owner[i].Timestamp,
+		}
+	}
+	return out, nil
+}
+
+func readLines(blobs store.Reader, hash, path string) ([]string, error) {
+	data, err := blobs.Read(hash)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if !snapshot.IsText(data) {
+		return nil, fmt.Errorf("%s is a binary file", path)
+	}
+	content := strings.TrimSuffix(string(data), "\n")
+	if content == "" {
+		return []string{}, nil
+	}
+	return strings.Split(content, "\n"), nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlameAndFileHistory(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	mainPath := filepath.Join(svc.ProjectDir, "main.go")
+	readme := filepath.Join(svc.ProjectDir, "README.md")
+	steps := []struct {
+		content string
+		agent   string
+		readme  bool
+	}{
+		{"package main\n\nfunc main() {}\n", "codex", false},
+		{"package main\n\nfunc main() {}\n", "codex", true},
+		{"package main\n\nimport \"fmt\"\n\nfunc main() {}\n", "claude", false},
+		{"package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n", "codex", false},
+	}
+	for i, step := range steps {
+		if err := os.WriteFile(mainPath, []byte(step.content), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		if step.readme {
+			if err := os.WriteFile(readme, []byte("# demo\n"), 0o644); err != nil {
+				t.Fatalf("write README.md: %v", err)
+			}
+		}
+		if _, err := svc.CreateCell(ctx, SnapOptions{Message: "step", Source: "agent", Agent: step.agent}); err != nil {
+			t.Fatalf("create cell %d: %v", i, err)
+		}
+	}
+
+	lines, err := svc.Blame("c_000004", "main.go")
+	if err != nil {
+		t.Fatalf("blame: %v", err)
+	}
+	want := []string{"c_000001", "c_000001", "c_000003", "c_000003", "c_000004", "c_000004", "c_000004"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	for i, line := range lines {
+		if line.CellID != want[i] {
+			t.Fatalf("line %d (%q) blamed on %s, want %s", line.Line, line.Text, line.CellID, want[i])
+		}
+	}
+	if lines[2].Agent == nil || *lines[2].Agent != "claude" {
+		t.Fatalf("expected the import line to carry agent claude, got %+v", lines[2])
+	}
+
+	history, err := svc.FileHistory("main.go")
+	if err != nil {
+		t.Fatalf("file history: %v", err)
+	}
+	got := []string{}
+	for _, change := range history {
+		got = append(got, change.Cell.ID+":"+change.Status)
+	}
+	if len(got) != 3 || got[0] != "c_000004:modified" || got[2] != "c_000001:added" {
+		t.Fatalf("unexpected file history %v", got)
+	}
+}
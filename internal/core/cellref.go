@@ -0,0 +1,110 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/store"
+)
+
+// archiveRefSeparator splits "<archive>:<cell>" refs, e.g.
+// a_20260101T120000Z_abcd1234:c_000004.
+const archiveRefSeparator = ":"
+
+// SplitCellRef splits an "<archive>:<cell>" ref. A ref without a prefix, or
+// with the "current" prefix, addresses the active history and returns an
+// empty archive ID.
+func SplitCellRef(ref string) (archiveID, cellID string) {
+	ref = strings.TrimSpace(ref)
+	archiveID, cellID, found := strings.Cut(ref, archiveRefSeparator)
+	if !found {
+		return "", ref
+	}
+	archiveID, cellID = strings.TrimSpace(archiveID), strings.TrimSpace(cellID)
+	if archiveID == "current" {
+		archiveID = ""
+	}
+	return archiveID, cellID
+}
+
+// ArchiveCellRef joins an archive ID and a cell ID into a ref.
+func ArchiveCellRef(archiveID, cellID string) string {
+	if archiveID == "" || archiveID == "current" {
+		return cellID
+	}
+	return archiveID + archiveRefSeparator + cellID
+}
+
+// CellView is a cell loaded with its manifest and the blobs to read it from,
+// wherever it lives.
+type CellView struct {
+	Cell     db.Cell
+	Manifest []db.ManifestEntry
+	Blobs    store.Reader
+	// External marks cells outside the active database: the working tree or
+	// an archived cell. Their Cell.ID (and ParentID) carry the full ref so
+	// they cannot be mistaken for active cells.
+	External bool
+}
+
+// LoadCellView resolves a revision, WORKTREE, or an "<archive>:<rev>" ref.
+// Archived cells are read from the archive's database and object store.
+func (s *Service) LoadCellView(ref string) (*CellView, error) {
+	if strings.TrimSpace(ref) == WorktreeRef {
+		wt, err := s.CaptureWorktree()
+		if err != nil {
+			return nil, err
+		}
+		return &CellView{Cell: wt.Cell, Manifest: wt.Manifest, Blobs: wt.Blobs, External: true}, nil
+	}
+
+	archiveID, cellID := SplitCellRef(ref)
+	if cellID == "" {
+		return nil, fmt.Errorf("cell ref %q has no cell ID", ref)
+	}
+	if archiveID == "" {
+		return loadCellView(s.DB, s.Store, cellID)
+	}
+
+	dbPath, objectsPath, err := s.ArchiveStatePaths(archiveID)
+	if err != nil {
+		return nil, err
+	}
+	archiveDB, err := db.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive db %s: %w", archiveID, err)
+	}
+	defer archiveDB.Close()
+
+	view, err := loadCellView(archiveDB, s.ArchiveStore(objectsPath), cellID)
+	if err != nil {
+		return nil, err
+	}
+	view.External = true
+	view.Cell.ID = ArchiveCellRef(archiveID, view.Cell.ID)
+	if view.Cell.ParentID != nil {
+		parent := ArchiveCellRef(archiveID, *view.Cell.ParentID)
+		view.Cell.ParentID = &parent
+	}
+	for i := range view.Manifest {
+		view.Manifest[i].CellID = view.Cell.ID
+	}
+	return view, nil
+}
+
+func loadCellView(database *db.DB, blobs *store.Store, rev string) (*CellView, error) {
+	cellID, err := ResolveRevisionIn(database, rev)
+	if err != nil {
+		return nil, err
+	}
+	cell, err := database.GetCell(cellID)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := database.GetManifest(cellID)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest %s: %w", cellID, err)
+	}
+	return &CellView{Cell: *cell, Manifest: manifest, Blobs: blobs}, nil
+}
@@ -0,0 +1,21 @@
+package core
+
+import "testing"
+
+func TestSplitCellRef(t *testing.T) {
+	cases := map[string][2]string{
+		"c_000004":                             {"", "c_000004"},
+		"current:c_000004":                     {"", "c_000004"},
+		"a_20260101T120000Z_abcd1234:c_000004": {"a_20260101T120000Z_abcd1234", "c_000004"},
+		" a_x : c_000001 ":                     {"a_x", "c_000001"},
+	}
+	for ref, want := range cases {
+		archiveID, cellID := SplitCellRef(ref)
+		if archiveID != want[0] || cellID != want[1] {
+			t.Fatalf("SplitCellRef(%q) = %q, %q; want %q, %q", ref, archiveID, cellID, want[0], want[1])
+		}
+	}
+	if got := ArchiveCellRef("current", "c_000001"); got != "c_000001" {
+		t.Fatalf("expected current refs unprefixed, got %q", got)
+	}
+}
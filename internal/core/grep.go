@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/snapshot"
+)
+
+// GrepOptions scopes a search across stored cells.
+type GrepOptions struct {
+	Pattern *regexp.Regexp
+	// Branch limits the search to one branch; empty searches every cell.
+	Branch string
+	// PathGlob limits the search to matching paths or directories.
+	PathGlob string
+}
+
+// GrepMatch is one matching line in one cell's copy of a file.
+type GrepMatch struct {
+	CellID string `json:"cell_id"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+}
+
+// GrepResult lists matches oldest cell first. Cells lists every searched
+// cell containing a match, in the same order. Warnings name files that were
+// skipped because their stored content could not be read.
+type GrepResult struct {
+	Matches       []GrepMatch `json:"matches"`
+	Cells         []string    `json:"cells"`
+	SearchedCells int         `json:"searched_cells"`
+	SearchedBlobs int         `json:"searched_blobs"`
+	Warnings      []string    `json:"warnings"`
+	searched      []db.Cell
+}
+
+type grepLine struct {
+	line int
+	text string
+}
+
+// Grep searches the files of every cell in scope. Each distinct blob is read
+// and searched once, however many cells reference it; binary blobs are
+// skipped, and blobs missing from the store are skipped with a warning.
+func (s *Service) Grep(opts GrepOptions) (*GrepResult, error) {
+	if opts.Pattern == nil {
+		return nil, fmt.Errorf("grep pattern is required")
+	}
+	glob := strings.TrimSpace(opts.PathGlob)
+	if glob != "" && !doublestar.ValidatePattern(glob) {
+		return nil, fmt.Errorf("invalid path glob %q", opts.PathGlob)
+	}
+	cells, err := s.DB.ListAllCells()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GrepResult{Matches: []GrepMatch{}, Cells: []string{}, Warnings: []string{}}
+	blobs := map[string][]grepLine{}
+	for _, cell := range cells {
+		if opts.Branch != "" && cell.Branch != opts.Branch {
+			continue
+		}
+		result.SearchedCells++
+		result.searched = append(result.searched, cell)
+		manifest, err := s.DB.GetManifest(cell.ID)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, entry := range manifest {
+			if glob != "" && !MatchPathGlob(glob, entry.Path) {
+				continue
+			}
+			lines, ok := blobs[entry.Hash]
+			if !ok {
+				data, err := s.Store.Read(entry.Hash)
+				blobs[entry.Hash] = nil
+				if err != nil {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("skipped %s in %s: %v", entry.Path, cell.ID, err))
+					continue
+				}
+				lines = grepBlob(opts.Pattern, data)
+				blobs[entry.Hash] = lines
+				result.SearchedBlobs++
+			}
+			for _, l := range lines {
+				result.Matches = append(result.Matches, GrepMatch{CellID: cell.ID, Path: entry.Path, Line: l.line, Text: l.text})
+			}
+			matched = matched || len(lines) > 0
+		}
+		if matched {
+			result.Cells = append(result.Cells, cell.ID)
+		}
+	}
+	return result, nil
+}
+
+// MatchesIn returns the matches found in one cell.
+func (r *GrepResult) MatchesIn(cellID string) []GrepMatch {
+	out := make([]GrepMatch, 0)
+	for _, m := range r.Matches {
+		if m.CellID == cellID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// RemovedIn returns the first searched child of cellID without a match: the
+// cell where the text disappeared. It returns "" if no such child exists.
+func (r *GrepResult) RemovedIn(cellID string) string {
+	hasMatch := make(map[string]bool, len(r.Cells))
+	for _, id := range r.Cells {
+		hasMatch[id] = true
+	}
+	for _, cell := range r.searched {
+		if cell.ParentID != nil && *cell.ParentID == cellID && !hasMatch[cell.ID] {
+			return cell.ID
+		}
+	}
+	return ""
+}
+
+func grepBlob(pattern *regexp.Regexp, data []byte) []grepLine {
+	if !snapshot.IsText(data) {
+		return nil
+	}
+	var lines []grepLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		if line := scanner.Text(); pattern.MatchString(line) {
+			lines = append(lines, grepLine{line: n, text: line})
+		}
+	}
+	return lines
+}
+
+// MatchPathGlob matches path against glob, treating a glob that names a
+// directory as matching everything beneath it.
+func MatchPathGlob(glob, path string) bool {
+	glob = strings.TrimSuffix(glob, "/")
+	if ok, _ := doublestar.Match(glob, path); ok {
+		return true
+	}
+	ok, _ := doublestar.Match(glob+"/**", path)
+	return ok
+}
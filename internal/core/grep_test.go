@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGrepSearchesEachBlobOnce(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(svc.ProjectDir, name)), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(svc.ProjectDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write("auth/login.go", "package auth\n\nfunc Login() {}\n")
+	write("main.go", "package main\n")
+	for _, step := range []struct {
+		message string
+		edit    func()
+	}{
+		{"one", func() {}},
+		{"two", func() { write("main.go", "package main\n\n// calls Login\n") }},
+		{"three", func() { write("auth/login.go", "package auth\n") }},
+	} {
+		step.edit()
+		if _, err := svc.CreateCell(ctx, SnapOptions{Message: step.message, Source: "manual"}); err != nil {
+			t.Fatalf("create cell %s: %v", step.message, err)
+		}
+	}
+
+	result, err := svc.Grep(GrepOptions{Pattern: regexp.MustCompile(`func Login`)})
+	if err != nil {
+		t.Fatalf("grep: %v", err)
+	}
+	if len(result.Matches) != 2 || result.Matches[0].Line != 3 || result.Matches[0].Path != "auth/login.go" {
+		t.Fatalf("unexpected matches %+v", result.Matches)
+	}
+	// Four distinct blobs back the six manifest entries.
+	if result.SearchedCells != 3 || result.SearchedBlobs != 4 {
+		t.Fatalf("expected 3 cells and 4 blobs searched, got %d and %d", result.SearchedCells, result.SearchedBlobs)
+	}
+	if got := result.Cells; len(got) != 2 || got[1] != "c_000002" {
+		t.Fatalf("unexpected matching cells %v", got)
+	}
+	if removed := result.RemovedIn("c_000002"); removed != "c_000003" {
+		t.Fatalf("expected removal in c_000003, got %q", removed)
+	}
+
+	scoped, err := svc.Grep(GrepOptions{Pattern: regexp.MustCompile(`Login`), PathGlob: "auth"})
+	if err != nil {
+		t.Fatalf("grep with path: %v", err)
+	}
+	for _, m := range scoped.Matches {
+		if m.Path != "auth/login.go" {
+			t.Fatalf("expected only auth/ matches, got %+v", m)
+		}
+	}
+}
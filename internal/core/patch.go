@@ -0,0 +1,374 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/diff"
+	"github.com/prit3010/converge/internal/snapshot"
+)
+
+const (
+	// mboxSeparator opens each message, matching git format-patch.
+	mboxSeparator    = "From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n"
+	patchAuthorEmail = "converge@localhost"
+)
+
+var (
+	mboxSeparatorPattern = regexp.MustCompile(`(?m)^From [0-9a-f]{40} `)
+	patchSubjectPrefix   = regexp.MustCompile(`^\[[^\]]*PATCH[^\]]*\]\s*`)
+)
+
+// CellPatch is one cell's change against its parent as a mail message that
+// git am accepts.
+type CellPatch struct {
+	Cell    db.Cell
+	Subject string
+	Text    string
+}
+
+// PatchMessage is one change read from a patch file: a mail message from an
+// mbox, or a bare diff.
+type PatchMessage struct {
+	Subject string
+	Agent   string
+	Files   []diff.FilePatch
+}
+
+// ImportPatchOptions places imported cells. Branch must not exist yet; it is
+// created at the last imported cell.
+type ImportPatchOptions struct {
+	Base    string
+	Branch  string
+	Message string
+	Agent   string
+}
+
+// DiffCells returns a git-applicable diff from one cell's files to another's.
+func (s *Service) DiffCells(fromID, toID string) (string, error) {
+	from, err := s.DB.GetManifest(fromID)
+	if err != nil {
+		return "", fmt.Errorf("manifest for %s: %w", fromID, err)
+	}
+	to, err := s.DB.GetManifest(toID)
+	if err != nil {
+		return "", fmt.Errorf("manifest for %s: %w", toID, err)
+	}
+	return s.gitDiff(from, to)
+}
+
+// FormatPatches returns one mail-formatted patch per cell after fromID up to
+// toID along toID's parents, like git format-patch. Cells that change
+// nothing are skipped, as git am rejects empty patches.
+func (s *Service) FormatPatches(fromID, toID string) ([]CellPatch, error) {
+	chain, err := s.cellChain(fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	type pending struct {
+		cell db.Cell
+		diff string
+	}
+	var changed []pending
+	for i := 1; i < len(chain); i++ {
+		text, err := s.DiffCells(chain[i-1].ID, chain[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if text != "" {
+			changed = append(changed, pending{cell: chain[i], diff: text})
+		}
+	}
+
+	patches := make([]CellPatch, 0, len(changed))
+	for n, p := range changed {
+		message := strings.TrimSpace(p.cell.Message)
+		if message == "" {
+			message = "Converge cell " + p.cell.ID
+		}
+		subject, body, _ := strings.Cut(cellCommitMessage(message, &p.cell), "\n")
+		prefix := "[PATCH]"
+		if len(changed) > 1 {
+			prefix = fmt.Sprintf("[PATCH %d/%d]", n+1, len(changed))
+		}
+		author := p.cell.Source
+		if p.cell.Agent != nil && *p.cell.Agent != "" {
+			author = *p.cell.Agent
+		}
+		date := time.Now()
+		if ts, err := time.Parse(time.RFC3339Nano, p.cell.Timestamp); err == nil {
+			date = ts
+		}
+
+		var text strings.Builder
+		text.WriteString(mboxSeparator)
+		fmt.Fprintf(&text, "From: %s <%s>\n", mime.QEncoding.Encode("utf-8", author), patchAuthorEmail)
+		fmt.Fprintf(&text, "Date: %s\n", date.Format(time.RFC1123Z))
+		fmt.Fprintf(&text, "Subject: %s %s\n", prefix, mime.QEncoding.Encode("utf-8", subject))
+		if !isASCII(body) {
+			text.WriteString("MIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n")
+		}
+		fmt.Fprintf(&text, "%s\n---\n\n%s-- \nconverge\n\n", body, p.diff)
+		patches = append(patches, CellPatch{Cell: p.cell, Subject: subject, Text: text.String()})
+	}
+	return patches, nil
+}
+
+func (s *Service) gitDiff(from, to []db.ManifestEntry) (string, error) {
+	fromEntries := make(map[string]db.ManifestEntry, len(from))
+	toEntries := make(map[string]db.ManifestEntry, len(to))
+	paths := map[string]bool{}
+	for _, e := range from {
+		fromEntries[e.Path] = e
+		paths[e.Path] = true
+	}
+	for _, e := range to {
+		toEntries[e.Path] = e
+		paths[e.Path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var out strings.Builder
+	for _, path := range sorted {
+		a, inFrom := fromEntries[path]
+		b, inTo := toEntries[path]
+		if inFrom && inTo && a.Hash == b.Hash && diff.GitMode(a.Mode) == diff.GitMode(b.Mode) {
+			continue
+		}
+		var sides [2]*diff.GitFile
+		binary := false
+		for i, side := range []struct {
+			entry db.ManifestEntry
+			ok    bool
+		}{{a, inFrom}, {b, inTo}} {
+			if !side.ok {
+				continue
+			}
+			data, err := s.Store.Read(side.entry.Hash)
+			if err != nil {
+				return "", fmt.Errorf("read %s: %w", path, err)
+			}
+			binary = binary || !snapshot.IsText(data)
+			sides[i] = &diff.GitFile{Mode: side.entry.Mode, Data: data}
+		}
+		out.WriteString(diff.GitPatch(path, sides[0], sides[1], binary))
+	}
+	return out.String(), nil
+}
+
+// ParsePatchMessages splits a patch file into its changes: one per mail
+// message in an mbox or git-am series, or a single bare diff.
+func ParsePatchMessages(text string) ([]PatchMessage, error) {
+	starts := mboxSeparatorPattern.FindAllStringIndex(text, -1)
+	if len(starts) == 0 {
+		files, err := diff.ParsePatch(text)
+		if err != nil {
+			return nil, err
+		}
+		return []PatchMessage{{Files: files}}, nil
+	}
+
+	messages := make([]PatchMessage, 0, len(starts))
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		msg, err := parsePatchMail(text[start[0]:end])
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i+1, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func parsePatchMail(text string) (PatchMessage, error) {
+	var msg PatchMessage
+	header, body, _ := strings.Cut(text, "\n\n")
+	var subject []string
+	inSubject := false
+	for _, line := range strings.Split(header, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Subject:"):
+			subject, inSubject = []string{strings.TrimSpace(strings.TrimPrefix(line, "Subject:"))}, true
+		case inSubject && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			subject = append(subject, strings.TrimSpace(line))
+		default:
+			inSubject = false
+		}
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(strings.Join(subject, " "))
+	if err != nil {
+		decoded = strings.Join(subject, " ")
+	}
+	msg.Subject = strings.TrimSpace(patchSubjectPrefix.ReplaceAllString(decoded, ""))
+	for _, line := range strings.Split(body, "\n") {
+		if value, ok := strings.CutPrefix(line, "Converge-Agent: "); ok {
+			msg.Agent = strings.TrimSpace(value)
+		}
+		if line == "---" || strings.HasPrefix(line, "diff --git ") {
+			break
+		}
+	}
+	msg.Files, err = diff.ParsePatch(body)
+	return msg, err
+}
+
+// ImportPatches applies each message on top of the previous one, starting
+// from opts.Base, and records every result as a cell on the new branch
+// opts.Branch. The working tree, the active branch, and HEAD are untouched.
+// Nothing is recorded unless every message applies, and the cells and branch
+// are written in one transaction.
+func (s *Service) ImportPatches(ctx context.Context, messages []PatchMessage, opts ImportPatchOptions) ([]*db.Cell, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("patch contains no changes")
+	}
+	if err := ValidateRevisionName(opts.Branch); err != nil {
+		return nil, fmt.Errorf("invalid branch name: %w", err)
+	}
+	if _, err := s.DB.GetBranch(opts.Branch); err == nil {
+		return nil, fmt.Errorf("branch %q already exists", opts.Branch)
+	} else if err != db.ErrNotFound {
+		return nil, err
+	}
+	base, err := s.DB.GetCell(opts.Base)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, fmt.Errorf("cell %s not found", opts.Base)
+		}
+		return nil, err
+	}
+	baseEntries, err := s.DB.GetManifest(base.ID)
+	if err != nil {
+		return nil, fmt.Errorf("manifest for %s: %w", base.ID, err)
+	}
+	manifest := make(snapshot.Manifest, len(baseEntries))
+	for _, e := range baseEntries {
+		manifest[e.Path] = snapshot.FileEntry{Hash: e.Hash, Mode: fs.FileMode(e.Mode), Size: e.Size}
+	}
+
+	// Apply everything first so a bad patch leaves no partial branch.
+	results := make([]snapshot.Manifest, 0, len(messages))
+	for i, msg := range messages {
+		next := make(snapshot.Manifest, len(manifest))
+		for path, entry := range manifest {
+			next[path] = entry
+		}
+		for _, file := range msg.Files {
+			if err := s.applyFilePatch(next, file); err != nil {
+				return nil, fmt.Errorf("patch %d: %w", i+1, err)
+			}
+		}
+		results = append(results, next)
+		manifest = next
+	}
+
+	parent, parentEntries := base, baseEntries
+	records := make([]db.Cell, 0, len(messages))
+	manifests := make([][]db.ManifestEntry, 0, len(messages))
+	for i, msg := range messages {
+		message := strings.TrimSpace(opts.Message)
+		if message == "" {
+			message = msg.Subject
+		}
+		if message == "" {
+			message = "Imported patch"
+		}
+		agent := strings.TrimSpace(opts.Agent)
+		if agent == "" {
+			agent = msg.Agent
+		}
+		seq, err := s.DB.AllocateSequence()
+		if err != nil {
+			return nil, fmt.Errorf("allocate sequence: %w", err)
+		}
+		cell, entries := s.newCellRecord(seq, opts.Branch, results[i], SnapOptions{Message: message, Source: "import", Agent: agent}, parent, parentEntries)
+		records = append(records, cell)
+		manifests = append(manifests, entries)
+		parent, parentEntries = &records[len(records)-1], entries
+	}
+	if err := s.DB.InsertImportedBranch(opts.Branch, records, manifests); err != nil {
+		return nil, fmt.Errorf("record imported cells: %w", err)
+	}
+
+	cells := make([]*db.Cell, 0, len(records))
+	for _, record := range records {
+		if _, err := s.ScoreCell(record.ID); err != nil {
+			return nil, fmt.Errorf("score cell: %w", err)
+		}
+		created, err := s.DB.GetCell(record.ID)
+		if err != nil {
+			return nil, fmt.Errorf("load created cell: %w", err)
+		}
+		cells = append(cells, created)
+	}
+	return cells, nil
+}
+
+// applyFilePatch applies one file's change to manifest, storing the new
+// content in the object store.
+func (s *Service) applyFilePatch(manifest snapshot.Manifest, file diff.FilePatch) error {
+	var old []byte
+	mode := fs.FileMode(0o644)
+	if file.OldPath != "" {
+		entry, ok := manifest[file.OldPath]
+		if !ok {
+			return fmt.Errorf("%s does not exist in the base cell", file.OldPath)
+		}
+		data, err := s.Store.Read(entry.Hash)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file.OldPath, err)
+		}
+		old, mode = data, entry.Mode
+	} else if _, exists := manifest[file.NewPath]; exists {
+		return fmt.Errorf("%s already exists in the base cell", file.NewPath)
+	}
+
+	data, err := file.Apply(old)
+	if err != nil {
+		path := file.NewPath
+		if path == "" {
+			path = file.OldPath
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if file.OldPath != "" {
+		delete(manifest, file.OldPath)
+	}
+	if file.NewPath == "" {
+		return nil
+	}
+	switch file.NewMode {
+	case "100755":
+		mode = 0o755
+	case "100644":
+		mode = 0o644
+	}
+	hash, err := s.Store.Write(data)
+	if err != nil {
+		return fmt.Errorf("store %s: %w", file.NewPath, err)
+	}
+	manifest[file.NewPath] = snapshot.FileEntry{Hash: hash, Mode: mode, Size: int64(len(data))}
+	return nil
+}
+
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
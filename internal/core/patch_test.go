@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestFormatPatchesRoundTripThroughImport(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	policy := config.DefaultPolicy()
+	policy.Snapshot.BinaryPolicy = config.BinaryPolicyInclude
+	svc.SetPolicy(policy)
+	write := func(name, content string, mode os.FileMode) {
+		t.Helper()
+		path := filepath.Join(svc.ProjectDir, name)
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("chmod %s: %v", name, err)
+		}
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n", 0o644)
+	write("old.txt", "remove me\n", 0o644)
+	base, err := svc.CreateCell(ctx, SnapOptions{Message: "base", Source: "manual"})
+	if err != nil {
+		t.Fatalf("create base: %v", err)
+	}
+	write("main.go", "package main\n\nfunc main() {\n\trun()\n}\n", 0o644)
+	write("run.sh", "#!/bin/sh\necho hi", 0o755)
+	write("logo.bin", "\x00\x01\x02binary\xff", 0o644)
+	if _, err := svc.CreateCell(ctx, SnapOptions{Message: "add runner", Source: "agent", Agent: "codex"}); err != nil {
+		t.Fatalf("create first: %v", err)
+	}
+	if err := os.Remove(filepath.Join(svc.ProjectDir, "old.txt")); err != nil {
+		t.Fatalf("remove old.txt: %v", err)
+	}
+	last, err := svc.CreateCell(ctx, SnapOptions{Message: "drop old file", Source: "agent", Agent: "codex"})
+	if err != nil {
+		t.Fatalf("create last: %v", err)
+	}
+
+	patches, err := svc.FormatPatches(base.ID, last.ID)
+	if err != nil {
+		t.Fatalf("format patches: %v", err)
+	}
+	if len(patches) != 2 || !strings.Contains(patches[0].Text, "Subject: [PATCH 1/2] add runner") {
+		t.Fatalf("unexpected patches: %+v", patches)
+	}
+	var mbox strings.Builder
+	for _, p := range patches {
+		mbox.WriteString(p.Text)
+	}
+	for _, want := range []string{"new file mode 100755", "GIT binary patch", "deleted file mode 100644", "Converge-Agent: codex"} {
+		if !strings.Contains(mbox.String(), want) {
+			t.Fatalf("expected %q in mbox:\n%s", want, mbox.String())
+		}
+	}
+
+	messages, err := ParsePatchMessages(mbox.String())
+	if err != nil {
+		t.Fatalf("parse patches: %v", err)
+	}
+	cells, err := svc.ImportPatches(ctx, messages, ImportPatchOptions{Base: base.ID, Branch: "review"})
+	if err != nil {
+		t.Fatalf("import patches: %v", err)
+	}
+	if len(cells) != 2 || cells[0].Message != "add runner" || cells[1].Agent == nil || *cells[1].Agent != "codex" {
+		t.Fatalf("unexpected imported cells: %+v", cells)
+	}
+	if cells[0].ParentID == nil || *cells[0].ParentID != base.ID || cells[1].Branch != "review" {
+		t.Fatalf("unexpected imported lineage: %+v", cells)
+	}
+	if text, err := svc.DiffCells(last.ID, cells[1].ID); err != nil || text != "" {
+		t.Fatalf("expected imported files to match %s, got diff %q (err %v)", last.ID, text, err)
+	}
+
+	branch, err := svc.DB.GetBranch("review")
+	if err != nil || branch.HeadCellID == nil || *branch.HeadCellID != cells[1].ID {
+		t.Fatalf("expected review branch at %s, got %+v (err %v)", cells[1].ID, branch, err)
+	}
+	if head, _ := svc.DB.GetMeta("head_cell"); head != last.ID {
+		t.Fatalf("expected head_cell to stay %s, got %s", last.ID, head)
+	}
+	if active, _ := svc.DB.GetMeta("active_branch"); active == "review" {
+		t.Fatalf("expected active branch to stay put")
+	}
+	if _, err := os.Stat(filepath.Join(svc.ProjectDir, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected working tree untouched, old.txt stat err %v", err)
+	}
+
+	if _, err := svc.ImportPatches(ctx, messages, ImportPatchOptions{Base: base.ID, Branch: "review"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing branch error, got %v", err)
+	}
+	if _, err := svc.ImportPatches(ctx, messages, ImportPatchOptions{Base: last.ID, Branch: "again"}); err == nil || !strings.Contains(err.Error(), "already exists in the base cell") {
+		t.Fatalf("expected apply error, got %v", err)
+	}
+	if _, err := svc.DB.GetBranch("again"); err == nil {
+		t.Fatalf("expected failed import to leave no branch")
+	}
+}
+
+func TestFormatPatchesApplyWithGitAm(t *testing.T) {
+	requireGit(t)
+	svc := newTestService(t)
+	ctx := context.Background()
+	policy := config.DefaultPolicy()
+	policy.Snapshot.BinaryPolicy = config.BinaryPolicyInclude
+	svc.SetPolicy(policy)
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	write(svc.ProjectDir, "notes.md", "one\ntwo\nthree\n")
+	base, err := svc.CreateCell(ctx, SnapOptions{Message: "base", Source: "manual"})
+	if err != nil {
+		t.Fatalf("create base: %v", err)
+	}
+	write(svc.ProjectDir, "notes.md", "one\n2\nthree\n")
+	write(svc.ProjectDir, "data.bin", "\x00\xfe\xff")
+	last, err := svc.CreateCell(ctx, SnapOptions{Message: "rewrite notes", Source: "agent", Agent: "claude"})
+	if err != nil {
+		t.Fatalf("create last: %v", err)
+	}
+	patches, err := svc.FormatPatches(base.ID, last.ID)
+	if err != nil {
+		t.Fatalf("format patches: %v", err)
+	}
+
+	repo := t.TempDir()
+	initGitRepo(t, repo)
+	write(repo, "notes.md", "one\ntwo\nthree\n")
+	gitCommitAll(t, repo, "initial")
+	write(repo, "series.mbox", patches[0].Text)
+	runGit(t, repo, "am", "series.mbox")
+
+	if subject := gitOutput(t, repo, "log", "-1", "--format=%s"); subject != "rewrite notes" {
+		t.Fatalf("unexpected commit subject %q", subject)
+	}
+	if body := gitOutput(t, repo, "log", "-1", "--format=%b"); !strings.Contains(body, "Converge-Cell: "+last.ID) {
+		t.Fatalf("expected Converge-Cell trailer, got:\n%s", body)
+	}
+	got, err := os.ReadFile(filepath.Join(repo, "data.bin"))
+	if err != nil || string(got) != "\x00\xfe\xff" {
+		t.Fatalf("unexpected binary content %q (err %v)", got, err)
+	}
+}
@@ -0,0 +1,341 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+// PromoteOptions describes a git commit built from a cell.
+type PromoteOptions struct {
+	CellID string
+	// Branch is the git branch to commit onto; empty means the checked-out
+	// branch. A missing branch is created from HEAD.
+	Branch string
+	// Message defaults to the cell's message.
+	Message string
+	// NoCheckout leaves the working tree alone when Branch is checked out;
+	// only the ref and the index move, like `git reset --mixed`.
+	NoCheckout bool
+}
+
+// PromoteResult describes the commit written by Promote.
+type PromoteResult struct {
+	Commit  string `json:"commit"`
+	Tree    string `json:"tree"`
+	Parent  string `json:"parent,omitempty"`
+	Branch  string `json:"branch"`
+	Subject string `json:"subject"`
+	// CheckedOut is true when Branch is the checked-out branch, so the
+	// commit landed on HEAD.
+	CheckedOut bool `json:"checked_out"`
+	// UpdatedWorktree is true when the working tree was moved to the commit.
+	UpdatedWorktree bool `json:"updated_worktree"`
+}
+
+// Promote commits a cell's files to a git branch with plumbing commands. The
+// tree starts from the branch tip so git-tracked paths the ignore policy keeps
+// out of cells survive; every other path mirrors the cell's manifest. The
+// real index and working tree are only touched when the branch is checked
+// out.
+func (s *Service) Promote(ctx context.Context, opts PromoteOptions) (*PromoteResult, error) {
+	cell, err := s.DB.GetCell(opts.CellID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, fmt.Errorf("cell %s not found", opts.CellID)
+		}
+		return nil, err
+	}
+	manifest, err := s.DB.GetManifest(cell.ID)
+	if err != nil {
+		return nil, fmt.Errorf("manifest for %s: %w", cell.ID, err)
+	}
+
+	current, _ := s.git(ctx, nil, nil, "symbolic-ref", "--short", "-q", "HEAD")
+	branch := strings.TrimSpace(opts.Branch)
+	if branch == "" {
+		if current == "" {
+			return nil, fmt.Errorf("HEAD is detached; a git branch is required")
+		}
+		branch = current
+	}
+	if _, err := s.git(ctx, nil, nil, "check-ref-format", "--branch", branch); err != nil {
+		return nil, fmt.Errorf("invalid git branch %q", branch)
+	}
+	prefix, err := s.git(ctx, nil, nil, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	// The ref only moves if it still holds oldTip; a new branch starts at HEAD.
+	oldTip, _ := s.git(ctx, nil, nil, "rev-parse", "-q", "--verify", "refs/heads/"+branch+"^{commit}")
+	parent := oldTip
+	if parent == "" {
+		parent, _ = s.git(ctx, nil, nil, "rev-parse", "-q", "--verify", "HEAD^{commit}")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "converge-promote-")
+	if err != nil {
+		return nil, fmt.Errorf("create promote dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tree, err := s.promoteTree(ctx, tmpDir, prefix, parent, cell.ID, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if parent != "" {
+		parentTree, err := s.git(ctx, nil, nil, "rev-parse", parent+"^{tree}")
+		if err != nil {
+			return nil, err
+		}
+		if parentTree == tree {
+			return nil, fmt.Errorf("cell %s has no changes to promote onto %s", cell.ID, branch)
+		}
+	}
+
+	subject := strings.TrimSpace(opts.Message)
+	if subject == "" {
+		subject = strings.TrimSpace(cell.Message)
+	}
+	if subject == "" {
+		subject = "Promote " + cell.ID
+	}
+	args := []string{"commit-tree", tree}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := s.git(ctx, nil, strings.NewReader(cellCommitMessage(subject, cell)), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PromoteResult{
+		Commit:     commit,
+		Tree:       tree,
+		Parent:     parent,
+		Branch:     branch,
+		Subject:    strings.SplitN(subject, "\n", 2)[0],
+		CheckedOut: branch == current,
+	}
+	if result.CheckedOut && !opts.NoCheckout {
+		// A two-way read-tree carries local edits forward and refuses to
+		// overwrite ones that conflict, before the ref moves.
+		if err := s.stageMatchingWorktree(ctx, parent, commit); err != nil {
+			return nil, err
+		}
+		readArgs := []string{"read-tree", "-m", "-u", commit}
+		if parent != "" {
+			readArgs = []string{"read-tree", "-m", "-u", parent, commit}
+		}
+		if _, err := s.git(ctx, nil, nil, readArgs...); err != nil {
+			return nil, err
+		}
+		result.UpdatedWorktree = true
+	}
+	if _, err := s.git(ctx, nil, nil, "update-ref", "-m", "converge promote "+cell.ID, "refs/heads/"+branch, commit, oldTip); err != nil {
+		return nil, err
+	}
+	if result.CheckedOut && opts.NoCheckout {
+		if _, err := s.git(ctx, nil, nil, "read-tree", commit); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// promoteTree writes the tree for a cell into the object database using a
+// scratch index in tmpDir, leaving the real index alone.
+func (s *Service) promoteTree(ctx context.Context, tmpDir, prefix, parent, cellID string, manifest []db.ManifestEntry) (string, error) {
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}
+	filesDir := filepath.Join(tmpDir, "files")
+	if err := s.materializeCell(cellID, filesDir); err != nil {
+		return "", err
+	}
+
+	inCell := make(map[string]bool, len(manifest))
+	for _, entry := range manifest {
+		inCell[entry.Path] = true
+	}
+	if parent != "" {
+		if _, err := s.git(ctx, env, nil, "read-tree", parent); err != nil {
+			return "", err
+		}
+		// Run from the project directory, ls-files lists only the files
+		// under it, relative to it, which is also how update-index reads
+		// the paths back.
+		listed, err := s.git(ctx, env, nil, "ls-files", "-z")
+		if err != nil {
+			return "", err
+		}
+		var removed bytes.Buffer
+		for _, path := range strings.Split(listed, "\x00") {
+			if path == "" || inCell[path] || s.Policy.ShouldIgnore(path, false) {
+				continue
+			}
+			removed.WriteString(path + "\x00")
+		}
+		if removed.Len() > 0 {
+			if _, err := s.git(ctx, env, &removed, "update-index", "--force-remove", "-z", "--stdin"); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if len(manifest) > 0 {
+		var paths strings.Builder
+		for _, entry := range manifest {
+			paths.WriteString(filepath.Join(filesDir, filepath.FromSlash(entry.Path)) + "\n")
+		}
+		hashed, err := s.git(ctx, nil, strings.NewReader(paths.String()), "hash-object", "-w", "--no-filters", "--stdin-paths")
+		if err != nil {
+			return "", err
+		}
+		hashes := strings.Fields(hashed)
+		if len(hashes) != len(manifest) {
+			return "", fmt.Errorf("git hash-object returned %d hashes for %d files", len(hashes), len(manifest))
+		}
+		var info strings.Builder
+		for i, entry := range manifest {
+			mode := "100644"
+			if entry.Mode&0o111 != 0 {
+				mode = "100755"
+			}
+			fmt.Fprintf(&info, "%s %s\t%s\n", mode, hashes[i], prefix+entry.Path)
+		}
+		if _, err := s.git(ctx, env, strings.NewReader(info.String()), "update-index", "--add", "--index-info"); err != nil {
+			return "", err
+		}
+	}
+	return s.git(ctx, env, nil, "write-tree")
+}
+
+// stageMatchingWorktree stages paths that differ between parent and commit
+// but whose working copy already matches commit. Promoting the cell that
+// captured the working tree is the common case, and read-tree would
+// otherwise reject those files as local changes.
+func (s *Service) stageMatchingWorktree(ctx context.Context, parent, commit string) error {
+	// Only paths under the project directory change, so every listing here
+	// is relative to it, as git commands run there expect.
+	diffArgs := []string{"ls-tree", "-r", "-z", "--name-only", commit}
+	if parent != "" {
+		diffArgs = []string{"diff", "--name-only", "-z", "--no-renames", "--relative", parent, commit}
+	}
+	listed, err := s.git(ctx, nil, nil, diffArgs...)
+	if err != nil {
+		return err
+	}
+	targetListing, err := s.git(ctx, nil, nil, "ls-tree", "-r", "-z", commit)
+	if err != nil {
+		return err
+	}
+	target := map[string]string{}
+	for _, line := range strings.Split(targetListing, "\x00") {
+		// Each line is "<mode> blob <hash>\t<path>".
+		meta, path, ok := strings.Cut(line, "\t")
+		if fields := strings.Fields(meta); ok && len(fields) == 3 {
+			target[path] = fields[2]
+		}
+	}
+
+	var present []string
+	var stage bytes.Buffer
+	for _, path := range strings.Split(listed, "\x00") {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(s.ProjectDir, filepath.FromSlash(path))); err != nil {
+			if _, wanted := target[path]; !wanted && os.IsNotExist(err) {
+				stage.WriteString(path + "\x00")
+			}
+			continue
+		}
+		present = append(present, path)
+	}
+	if len(present) > 0 {
+		hashed, err := s.git(ctx, nil, strings.NewReader(strings.Join(present, "\n")+"\n"), "hash-object", "--no-filters", "--stdin-paths")
+		if err != nil {
+			return err
+		}
+		for i, hash := range strings.Fields(hashed) {
+			if target[present[i]] == hash {
+				stage.WriteString(present[i] + "\x00")
+			}
+		}
+	}
+	if stage.Len() == 0 {
+		return nil
+	}
+	_, err = s.git(ctx, nil, &stage, "update-index", "--add", "--remove", "-z", "--stdin")
+	return err
+}
+
+func cellCommitMessage(subject string, cell *db.Cell) string {
+	var b strings.Builder
+	b.WriteString(subject)
+	b.WriteString("\n\nConverge-Cell: " + cell.ID + "\n")
+	if cell.Agent != nil && *cell.Agent != "" {
+		b.WriteString("Converge-Agent: " + *cell.Agent + "\n")
+	}
+	if summary := cellEvalSummary(cell); summary != "" {
+		b.WriteString("Converge-Eval: " + summary + "\n")
+	}
+	return b.String()
+}
+
+// cellEvalSummary condenses a cell's stored eval into one line, or "" if no
+// eval ran.
+func cellEvalSummary(cell *db.Cell) string {
+	if !cell.EvalRan {
+		return ""
+	}
+	if cell.EvalError != nil {
+		return "error"
+	}
+	var parts []string
+	if cell.TestsPassed != nil && cell.TestsFailed != nil {
+		parts = append(parts, fmt.Sprintf("tests %d/%d", *cell.TestsPassed, *cell.TestsPassed+*cell.TestsFailed))
+	}
+	if cell.LintErrors != nil {
+		parts = append(parts, fmt.Sprintf("lint %d", *cell.LintErrors))
+	}
+	if cell.TypeErrors != nil {
+		parts = append(parts, fmt.Sprintf("types %d", *cell.TypeErrors))
+	}
+	if cell.Score != nil {
+		parts = append(parts, fmt.Sprintf("score %.2f", *cell.Score))
+	}
+	if len(parts) == 0 {
+		return "no checks ran"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// git runs a git command in the project directory with extra environment and
+// optional stdin, returning trimmed stdout.
+func (s *Service) git(ctx context.Context, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.ProjectDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/eval"
+	"github.com/prit3010/converge/internal/store"
+)
+
+func TestPromoteCommitsCellManifestToGit(t *testing.T) {
+	requireGit(t)
+	svc := newTestService(t)
+	ctx := context.Background()
+	initGitRepo(t, svc.ProjectDir)
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(svc.ProjectDir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write(".gitignore", ".converge/\n")
+	write("main.go", "package main\n")
+	write("old.go", "package main\n\nfunc old() {}\n")
+	base := gitCommitAll(t, svc.ProjectDir, "initial")
+	branch := gitOutput(t, svc.ProjectDir, "branch", "--show-current")
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("new.go", "package main\n\nfunc added() {}\n")
+	if err := os.Remove(filepath.Join(svc.ProjectDir, "old.go")); err != nil {
+		t.Fatalf("remove old.go: %v", err)
+	}
+	cell, err := svc.CreateCell(ctx, SnapOptions{Message: "add main", Source: "agent", Agent: "codex"})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+
+	result, err := svc.Promote(ctx, PromoteOptions{CellID: cell.ID, Branch: "winner"})
+	if err != nil {
+		t.Fatalf("promote to winner: %v", err)
+	}
+	if result.Parent != base || result.CheckedOut || result.UpdatedWorktree {
+		t.Fatalf("unexpected promote result %+v", result)
+	}
+	if files := gitOutput(t, svc.ProjectDir, "ls-tree", "--name-only", "winner"); files != ".gitignore\nmain.go\nnew.go" {
+		t.Fatalf("unexpected promoted tree:\n%s", files)
+	}
+	message := gitOutput(t, svc.ProjectDir, "log", "-1", "--format=%B", "winner")
+	for _, want := range []string{"add main", "Converge-Cell: " + cell.ID, "Converge-Agent: codex"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("expected %q in commit message:\n%s", want, message)
+		}
+	}
+	if head := gitOutput(t, svc.ProjectDir, "rev-parse", "HEAD"); head != base {
+		t.Fatalf("expected HEAD to stay at %s, got %s", base, head)
+	}
+
+	result, err = svc.Promote(ctx, PromoteOptions{CellID: cell.ID, Message: "ship it"})
+	if err != nil {
+		t.Fatalf("promote to %s: %v", branch, err)
+	}
+	if !result.CheckedOut || !result.UpdatedWorktree || result.Branch != branch {
+		t.Fatalf("unexpected promote result %+v", result)
+	}
+	if head := gitOutput(t, svc.ProjectDir, "rev-parse", "HEAD"); head != result.Commit {
+		t.Fatalf("expected HEAD at promoted commit %s, got %s", result.Commit, head)
+	}
+	if status := gitOutput(t, svc.ProjectDir, "status", "--porcelain"); status != "" {
+		t.Fatalf("expected clean status after promote, got:\n%s", status)
+	}
+
+	if _, err := svc.Promote(ctx, PromoteOptions{CellID: cell.ID}); err == nil || !strings.Contains(err.Error(), "no changes") {
+		t.Fatalf("expected no-changes error, got %v", err)
+	}
+}
+
+func TestPromoteFromGitSubdirectoryRemovesDeletedFiles(t *testing.T) {
+	requireGit(t)
+	repo := t.TempDir()
+	initGitRepo(t, repo)
+	projectDir := filepath.Join(repo, "sub")
+	if err := os.MkdirAll(filepath.Join(projectDir, ".converge", "objects"), 0o755); err != nil {
+		t.Fatalf("mkdir state dirs: %v", err)
+	}
+	database, err := db.Open(filepath.Join(projectDir, ".converge", "converge.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = database.Close()
+	})
+	svc := NewService(projectDir, database, store.New(filepath.Join(projectDir, ".converge", "objects")), eval.NewRunner())
+	ctx := context.Background()
+
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	write(".gitignore", ".converge/\n")
+	write("top.txt", "outside the project\n")
+	write("sub/a.txt", "a\n")
+	write("sub/b.txt", "b\n")
+	gitCommitAll(t, repo, "initial")
+
+	if err := os.Remove(filepath.Join(projectDir, "b.txt")); err != nil {
+		t.Fatalf("remove b.txt: %v", err)
+	}
+	cell, err := svc.CreateCell(ctx, SnapOptions{Message: "drop b", Source: "manual"})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	if _, err := svc.Promote(ctx, PromoteOptions{CellID: cell.ID, NoCheckout: true}); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	if files := gitOutput(t, repo, "ls-tree", "-r", "--name-only", "HEAD"); files != ".gitignore\nsub/a.txt\ntop.txt" {
+		t.Fatalf("unexpected promoted tree:\n%s", files)
+	}
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+// HeadRevision names the head of the active branch.
+const HeadRevision = "HEAD"
+
+// BestRevision names the top cell by score, or by the eval heuristic.
+const BestRevision = "best"
+
+// ErrWorktreeRevision is returned when WORKTREE is used where a recorded
+// cell is required.
+var ErrWorktreeRevision = errors.New("WORKTREE is the uncommitted working tree, not a cell; only diff and show accept it")
+
+var (
+	cellIDPattern    = regexp.MustCompile(`^c_\d+$`)
+	ancestorPattern  = regexp.MustCompile(`^(.*?)((?:~\d*|\^)+)$`)
+	ancestorStep     = regexp.MustCompile(`~\d*|\^`)
+	sourceRevPattern = regexp.MustCompile(`^@\{([^}]*?)(?:-(\d+))?\}$`)
+)
+
+// ResolveRevision resolves a revision expression against the active history.
+// See ResolveRevisionIn for the syntax.
+func (s *Service) ResolveRevision(rev string) (string, error) {
+	return ResolveRevisionIn(s.DB, rev)
+}
+
+// ResolveRevisionIn resolves a revision expression to a cell ID in database:
+//
+//	c_000123      a cell ID
+//	42            a sequence number
+//	HEAD          head of the active branch
+//	<branch>      head of a branch
+//	<bookmark>    the cell a bookmark names
+//	<tag>         newest cell carrying the tag
+//	best          top cell by score (or the eval heuristic)
+//	@{watch-5}    the 5th cell before the newest one from source "watch"
+//	<rev>~N, ^    the Nth (or first) ancestor of any of the above
+//
+// Unknown names and ancestors past the root return db.ErrNotFound. WORKTREE
+// is not a cell and returns ErrWorktreeRevision; commands that can compare
+// against the working tree load it through Service.LoadCellView instead.
+func ResolveRevisionIn(database *db.DB, rev string) (string, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" {
+		return "", fmt.Errorf("revision cannot be empty")
+	}
+	if rev == WorktreeRef || strings.HasPrefix(rev, WorktreeRef+"~") || strings.HasPrefix(rev, WorktreeRef+"^") {
+		return "", ErrWorktreeRevision
+	}
+	if m := ancestorPattern.FindStringSubmatch(rev); m != nil && m[1] != "" && !sourceRevPattern.MatchString(rev) {
+		id, err := ResolveRevisionIn(database, m[1])
+		if err != nil {
+			return "", err
+		}
+		return walkAncestors(database, id, ancestorCount(m[2]), rev)
+	}
+
+	if cellIDPattern.MatchString(rev) {
+		if _, err := database.GetCell(rev); err != nil {
+			return "", err
+		}
+		return rev, nil
+	}
+	if seq, err := strconv.Atoi(rev); err == nil && seq > 0 {
+		id := CellID(seq)
+		if _, err := database.GetCell(id); err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+	if m := sourceRevPattern.FindStringSubmatch(rev); m != nil {
+		back := 0
+		if m[2] != "" {
+			back, _ = strconv.Atoi(m[2])
+		}
+		return nthCellFromSource(database, strings.TrimSpace(m[1]), back)
+	}
+	if rev == HeadRevision {
+		branch, err := database.GetMeta("active_branch")
+		if err != nil || strings.TrimSpace(branch) == "" {
+			branch = defaultBranchName
+		}
+		return branchHead(database, strings.TrimSpace(branch))
+	}
+	if rev == BestRevision {
+		cells, err := database.ListAllCells()
+		if err != nil {
+			return "", err
+		}
+		winner, _ := PickWinner(cells)
+		if winner == nil {
+			return "", db.ErrNotFound
+		}
+		return winner.ID, nil
+	}
+
+	if id, err := branchHead(database, rev); err != db.ErrNotFound {
+		return id, err
+	}
+	if bookmark, err := database.GetBookmark(rev); err != db.ErrNotFound {
+		if err != nil {
+			return "", err
+		}
+		return bookmark.CellID, nil
+	}
+	tagged, err := database.ListCellIDsWithTag(rev)
+	if err != nil {
+		return "", err
+	}
+	if len(tagged) == 0 {
+		return "", db.ErrNotFound
+	}
+	return tagged[0], nil
+}
+
+// ValidateRevisionName rejects names that could not be resolved back as
+// themselves, such as a bookmark called HEAD~1 or 42.
+func ValidateRevisionName(name string) error {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return fmt.Errorf("name cannot be empty")
+	case name == HeadRevision, name == BestRevision, name == WorktreeRef:
+		return fmt.Errorf("%s is a reserved revision", name)
+	case cellIDPattern.MatchString(name):
+		return fmt.Errorf("%s looks like a cell ID", name)
+	case strings.ContainsAny(name, ":~^@{}, \t"):
+		return fmt.Errorf("name %q cannot contain ':', '~', '^', '@', braces, commas, or spaces", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("%s looks like a sequence number", name)
+	}
+	return nil
+}
+
+// ancestorCount sums a suffix such as "~2^~" into a number of generations.
+func ancestorCount(suffix string) int {
+	total := 0
+	for _, part := range ancestorStep.FindAllString(suffix, -1) {
+		if part == "^" || part == "~" {
+			total++
+			continue
+		}
+		n, _ := strconv.Atoi(part[1:])
+		total += n
+	}
+	return total
+}
+
+func walkAncestors(database *db.DB, id string, generations int, rev string) (string, error) {
+	for i := 0; i < generations; i++ {
+		cell, err := database.GetCell(id)
+		if err != nil {
+			return "", err
+		}
+		if cell.ParentID == nil {
+			return "", fmt.Errorf("revision %s goes past the root cell %s: %w", rev, cell.ID, db.ErrNotFound)
+		}
+		id = *cell.ParentID
+	}
+	return id, nil
+}
+
+func branchHead(database *db.DB, name string) (string, error) {
+	branch, err := database.GetBranch(name)
+	if err != nil {
+		return "", err
+	}
+	if branch.HeadCellID == nil || strings.TrimSpace(*branch.HeadCellID) == "" {
+		return "", fmt.Errorf("branch %s has no cells: %w", name, db.ErrNotFound)
+	}
+	return *branch.HeadCellID, nil
+}
+
+// nthCellFromSource returns the cell back places before the newest cell
+// created by source.
+func nthCellFromSource(database *db.DB, source string, back int) (string, error) {
+	cells, err := database.ListAllCells()
+	if err != nil {
+		return "", err
+	}
+	seen := 0
+	for i := len(cells) - 1; i >= 0; i-- {
+		if cells[i].Source != source {
+			continue
+		}
+		if seen == back {
+			return cells[i].ID, nil
+		}
+		seen++
+	}
+	return "", db.ErrNotFound
+}
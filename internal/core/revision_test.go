@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+func TestResolveRevision(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	mainPath := filepath.Join(svc.ProjectDir, "main.go")
+	for i, opts := range []SnapOptions{
+		{Message: "one", Source: "manual", Tags: "baseline, v1"},
+		{Message: "two", Source: "watch"},
+		{Message: "three", Source: "watch"},
+		{Message: "four", Source: "manual", Tags: "v1"},
+	} {
+		if err := os.WriteFile(mainPath, []byte("package main\n// "+opts.Message+"\n"), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		if _, err := svc.CreateCell(ctx, opts); err != nil {
+			t.Fatalf("create cell %d: %v", i, err)
+		}
+	}
+
+	cases := map[string]string{
+		"c_000002":   "c_000002",
+		"3":          "c_000003",
+		"HEAD":       "c_000004",
+		"HEAD~3":     "c_000001",
+		"HEAD^^":     "c_000002",
+		"main~1":     "c_000003",
+		"main":       "c_000004",
+		"@{watch}":   "c_000003",
+		"@{watch-1}": "c_000002",
+		"baseline":   "c_000001",
+		"v1":         "c_000004",
+		"v1~2":       "c_000002",
+		"best":       "c_000004",
+	}
+	for rev, want := range cases {
+		got, err := svc.ResolveRevision(rev)
+		if err != nil || got != want {
+			t.Fatalf("ResolveRevision(%q) = %q, %v; want %q", rev, got, err, want)
+		}
+	}
+
+	for _, rev := range []string{"c_000009", "HEAD~4", "@{watch-2}", "nope", "99"} {
+		if _, err := svc.ResolveRevision(rev); !errors.Is(err, db.ErrNotFound) {
+			t.Fatalf("ResolveRevision(%q): expected not found, got %v", rev, err)
+		}
+	}
+	for _, rev := range []string{"WORKTREE", "WORKTREE~1"} {
+		if _, err := svc.ResolveRevision(rev); !errors.Is(err, ErrWorktreeRevision) {
+			t.Fatalf("ResolveRevision(%q): expected ErrWorktreeRevision, got %v", rev, err)
+		}
+	}
+}
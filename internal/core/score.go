@@ -0,0 +1,267 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/prit3010/converge/internal/bench"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/score"
+)
+
+// Winner selection bases reported alongside a picked cell.
+const (
+	WinnerBasisScore     = "score"
+	WinnerBasisHeuristic = "heuristic"
+)
+
+// ScoreCell recomputes a cell's score from the [score] policy and stores it.
+// The stored score is cleared when scoring is not configured or a metric the
+// policy needs is unavailable for the cell.
+func (s *Service) ScoreCell(cellID string) (*float64, error) {
+	cell, err := s.DB.GetCell(cellID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, fmt.Errorf("cell %s not found", cellID)
+		}
+		return nil, err
+	}
+	value, err := s.computeScore(*cell)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.SetCellScore(cellID, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// scorePolicyMetaKey records a hash of the [score] policy stored scores were
+// computed with.
+const scorePolicyMetaKey = "score_policy_hash"
+
+// RefreshScores rescores every cell when the [score] policy hash differs from
+// the one stored in meta, so score ordering never uses stale values. When the
+// policy is unchanged it only reads meta.
+func (s *Service) RefreshScores() error {
+	stored, err := s.DB.GetMeta(scorePolicyMetaKey)
+	if err != nil && err != db.ErrNotFound {
+		return err
+	}
+	if stored == s.scorePolicyHash() {
+		return nil
+	}
+	_, err = s.RescoreCells()
+	return err
+}
+
+// scorePolicyHash is "" when scoring is not configured.
+func (s *Service) scorePolicyHash() string {
+	fingerprint := s.Policy.Score.Fingerprint()
+	if fingerprint == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fingerprint))
+	return hex.EncodeToString(sum[:])
+}
+
+// RescoreCells recomputes the score of every cell, for use after the [score]
+// policy changes. It returns the number of cells that received a score.
+func (s *Service) RescoreCells() (int, error) {
+	cells, err := s.DB.ListAllCells()
+	if err != nil {
+		return 0, err
+	}
+	scored := 0
+	for _, cell := range cells {
+		value, err := s.computeScore(cell)
+		if err != nil {
+			return scored, err
+		}
+		if err := s.DB.SetCellScore(cell.ID, value); err != nil {
+			return scored, err
+		}
+		if value != nil {
+			scored++
+		}
+	}
+	if err := s.DB.SetMeta(scorePolicyMetaKey, s.scorePolicyHash()); err != nil {
+		return scored, err
+	}
+	return scored, nil
+}
+
+func (s *Service) computeScore(cell db.Cell) (*float64, error) {
+	scorer := s.Policy.Scorer()
+	if scorer == nil {
+		return nil, nil
+	}
+	rows, err := s.DB.ListBenchSamples(cell.ID)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := scorer.Score(CellMetrics(cell, bench.Summarize(BenchSamplesFromRows(rows))))
+	if !ok {
+		return nil, nil
+	}
+	return &value, nil
+}
+
+// CellMetrics collects the scoring inputs for a cell. Eval counts are only
+// available once eval has run, and count as zero when a check did not report.
+func CellMetrics(cell db.Cell, benches []bench.Summary) score.Metrics {
+	values := map[string]float64{
+		score.TotalLOC:      float64(cell.TotalLOC),
+		score.LOCDelta:      float64(cell.LOCDelta),
+		score.TotalFiles:    float64(cell.TotalFiles),
+		score.FilesAdded:    float64(cell.FilesAdded),
+		score.FilesModified: float64(cell.FilesModified),
+		score.FilesRemoved:  float64(cell.FilesRemoved),
+		score.LinesAdded:    float64(cell.LinesAdded),
+		score.LinesRemoved:  float64(cell.LinesRemoved),
+	}
+	if cell.EvalRan && cell.EvalError == nil {
+		passed := ptrInt(cell.TestsPassed)
+		failed := ptrInt(cell.TestsFailed)
+		values[score.TestsPassed] = float64(passed)
+		values[score.TestsFailed] = float64(failed)
+		values[score.TestsTotal] = float64(passed + failed)
+		if passed+failed > 0 {
+			values[score.PassRate] = float64(passed) * 100 / float64(passed+failed)
+		}
+		values[score.LintErrors] = float64(ptrInt(cell.LintErrors))
+		values[score.TypeErrors] = float64(ptrInt(cell.TypeErrors))
+	}
+	if cell.CoveragePct != nil {
+		values[score.Coverage] = *cell.CoveragePct
+	}
+	return score.Metrics{Values: values, Bench: benches}
+}
+
+// PickWinner chooses the best cell. When any candidate has a score the
+// highest score wins; otherwise cells are ranked by the built-in heuristic
+// (fewest failing tests, then fewest lint/type errors, then most passing
+// tests), preferring evaluated cells and falling back to the latest attempt.
+func PickWinner(cells []db.Cell) (*db.Cell, string) {
+	if len(cells) == 0 {
+		return nil, ""
+	}
+
+	scored := make([]db.Cell, 0, len(cells))
+	for _, cell := range cells {
+		if cell.Score != nil {
+			scored = append(scored, cell)
+		}
+	}
+	if len(scored) > 0 {
+		best := scored[0]
+		for _, cell := range scored[1:] {
+			if ScorePreferred(cell, best) {
+				best = cell
+			}
+		}
+		return &best, WinnerBasisScore
+	}
+
+	evaluated := make([]db.Cell, 0, len(cells))
+	for _, cell := range cells {
+		if cellHasEval(cell) {
+			evaluated = append(evaluated, cell)
+		}
+	}
+
+	// If there is no evaluation data at all, default to the latest attempt.
+	pool := evaluated
+	if len(pool) == 0 {
+		pool = cells
+	}
+
+	best := pool[0]
+	for i := 1; i < len(pool); i += 1 {
+		if HeuristicPreferred(pool[i], best) {
+			best = pool[i]
+		}
+	}
+	return &best, WinnerBasisHeuristic
+}
+
+// MetricOrder ranks cells best-first by score, or by the eval heuristic when
+// no cell is scored, and reports which basis was used. It returns no order
+// when none of the cells has a score or eval data to rank by.
+func MetricOrder(cells []db.Cell) ([]string, string) {
+	basis := ""
+	for _, cell := range cells {
+		if cell.Score != nil {
+			basis = WinnerBasisScore
+			break
+		}
+		if cellHasEval(cell) {
+			basis = WinnerBasisHeuristic
+		}
+	}
+	if basis == "" {
+		return nil, ""
+	}
+	preferred := HeuristicPreferred
+	if basis == WinnerBasisScore {
+		preferred = ScorePreferred
+	}
+	sorted := append([]db.Cell(nil), cells...)
+	sort.SliceStable(sorted, func(i, j int) bool { return preferred(sorted[i], sorted[j]) })
+	ids := make([]string, 0, len(sorted))
+	for _, cell := range sorted {
+		ids = append(ids, cell.ID)
+	}
+	return ids, basis
+}
+
+// ScorePreferred orders scored cells: higher score first, unscored last, and
+// the heuristic breaks ties.
+func ScorePreferred(candidate, current db.Cell) bool {
+	switch {
+	case candidate.Score == nil && current.Score == nil:
+		return HeuristicPreferred(candidate, current)
+	case candidate.Score == nil:
+		return false
+	case current.Score == nil:
+		return true
+	case *candidate.Score != *current.Score:
+		return *candidate.Score > *current.Score
+	}
+	return HeuristicPreferred(candidate, current)
+}
+
+func HeuristicPreferred(candidate, current db.Cell) bool {
+	candidateFailed := ptrInt(candidate.TestsFailed)
+	currentFailed := ptrInt(current.TestsFailed)
+	if candidateFailed != currentFailed {
+		return candidateFailed < currentFailed
+	}
+
+	candidateLintType := ptrInt(candidate.LintErrors) + ptrInt(candidate.TypeErrors)
+	currentLintType := ptrInt(current.LintErrors) + ptrInt(current.TypeErrors)
+	if candidateLintType != currentLintType {
+		return candidateLintType < currentLintType
+	}
+
+	candidatePassed := ptrInt(candidate.TestsPassed)
+	currentPassed := ptrInt(current.TestsPassed)
+	if candidatePassed != currentPassed {
+		return candidatePassed > currentPassed
+	}
+
+	return candidate.Sequence > current.Sequence
+}
+
+func cellHasEval(cell db.Cell) bool {
+	return cell.TestsPassed != nil || cell.TestsFailed != nil || cell.LintErrors != nil || cell.TypeErrors != nil
+}
+
+func ptrInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
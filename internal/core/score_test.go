@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/db"
+)
+
+func TestCreateCellStoresScore(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	setScoreConfig(t, svc, "[score]\nexpression = \"100 - total_loc\"\n")
+
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "a.txt"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	cell, err := svc.CreateCell(ctx, SnapOptions{Message: "base", RunEval: false})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	if cell.Score == nil || *cell.Score != 97 {
+		t.Fatalf("expected score 97, got %v", cell.Score)
+	}
+
+	setScoreConfig(t, svc, "[score]\nexpression = \"100 - tests_failed\"\n")
+	scored, err := svc.RescoreCells()
+	if err != nil {
+		t.Fatalf("rescore cells: %v", err)
+	}
+	if scored != 0 {
+		t.Fatalf("expected unevaluated cell to be unscored, got %d scored", scored)
+	}
+	updated, err := svc.DB.GetCell(cell.ID)
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if updated.Score != nil {
+		t.Fatalf("expected score cleared, got %v", *updated.Score)
+	}
+}
+
+func TestRefreshScoresOnlyRescoresWhenPolicyHashChanges(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	setScoreConfig(t, svc, "[score]\nexpression = \"100 - total_loc\"\n")
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	cell, err := svc.CreateCell(ctx, SnapOptions{Message: "base"})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	if err := svc.RefreshScores(); err != nil {
+		t.Fatalf("refresh scores: %v", err)
+	}
+	hash, err := svc.DB.GetMeta(scorePolicyMetaKey)
+	if err != nil || len(hash) != 64 {
+		t.Fatalf("expected a stored policy hash, got %q (err %v)", hash, err)
+	}
+
+	// A stale score must survive a refresh under the same policy.
+	stale := 1.0
+	if err := svc.DB.SetCellScore(cell.ID, &stale); err != nil {
+		t.Fatalf("set score: %v", err)
+	}
+	if err := svc.RefreshScores(); err != nil {
+		t.Fatalf("refresh scores: %v", err)
+	}
+	if got, _ := svc.DB.GetCell(cell.ID); got.Score == nil || *got.Score != stale {
+		t.Fatalf("expected unchanged policy to skip rescoring, got %v", got.Score)
+	}
+
+	setScoreConfig(t, svc, "[score]\nexpression = \"50 - total_loc\"\n")
+	if err := svc.RefreshScores(); err != nil {
+		t.Fatalf("refresh scores: %v", err)
+	}
+	if got, _ := svc.DB.GetCell(cell.ID); got.Score == nil || *got.Score != 49 {
+		t.Fatalf("expected rescore after policy change, got %v", got.Score)
+	}
+	if next, _ := svc.DB.GetMeta(scorePolicyMetaKey); next == hash {
+		t.Fatalf("expected policy hash to be updated")
+	}
+}
+
+func TestPickWinnerFallsBackToHeuristic(t *testing.T) {
+	cells := []db.Cell{
+		{ID: "c_000001", Sequence: 1, TestsPassed: intPtr(5), TestsFailed: intPtr(1)},
+		{ID: "c_000002", Sequence: 2, TestsPassed: intPtr(6), TestsFailed: intPtr(0)},
+		{ID: "c_000003", Sequence: 3},
+	}
+	winner, basis := PickWinner(cells)
+	if winner == nil || winner.ID != "c_000002" || basis != WinnerBasisHeuristic {
+		t.Fatalf("expected heuristic winner c_000002, got %+v (%s)", winner, basis)
+	}
+}
+
+func TestMetricOrderPrefersScoreThenHeuristic(t *testing.T) {
+	low, high := 1.0, 9.0
+	cells := []db.Cell{
+		{ID: "c_000001", Sequence: 1, Score: &low},
+		{ID: "c_000002", Sequence: 2},
+		{ID: "c_000003", Sequence: 3, Score: &high},
+	}
+	order, basis := MetricOrder(cells)
+	if basis != WinnerBasisScore || strings.Join(order, ",") != "c_000003,c_000001,c_000002" {
+		t.Fatalf("unexpected score order %v (%s)", order, basis)
+	}
+
+	order, basis = MetricOrder([]db.Cell{
+		{ID: "c_000001", Sequence: 1, TestsFailed: intPtr(2)},
+		{ID: "c_000002", Sequence: 2, TestsFailed: intPtr(0)},
+	})
+	if basis != WinnerBasisHeuristic || strings.Join(order, ",") != "c_000002,c_000001" {
+		t.Fatalf("unexpected heuristic order %v (%s)", order, basis)
+	}
+
+	if order, basis := MetricOrder([]db.Cell{{ID: "c_000001"}, {ID: "c_000002"}}); order != nil || basis != "" {
+		t.Fatalf("expected no order without metrics, got %v (%s)", order, basis)
+	}
+}
+
+func setScoreConfig(t *testing.T, svc *Service, contents string) {
+	t.Helper()
+	path := filepath.Join(svc.ProjectDir, config.StateDirName, config.ConfigFileName)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	policy, err := config.LoadRepoPolicy(svc.ProjectDir)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	svc.SetPolicy(policy)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
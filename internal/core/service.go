@@ -9,6 +9,7 @@ import (
 
 	"github.com/prit3010/converge/internal/config"
 	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/diff"
 	"github.com/prit3010/converge/internal/eval"
 	"github.com/prit3010/converge/internal/snapshot"
 	"github.com/prit3010/converge/internal/store"
@@ -33,6 +34,7 @@ func NewService(projectDir string, database *db.DB, objectStore *store.Store, ev
 	}
 	policy := config.DefaultPolicy()
 	evaluator.SetPolicy(policy.Eval)
+	evaluator.SetIgnore(policy.ShouldIgnore)
 	return &Service{
 		DB:         database,
 		Store:      objectStore,
@@ -50,6 +52,7 @@ func (s *Service) SetPolicy(policy config.Policy) {
 	}
 	if s.Evaluator != nil {
 		s.Evaluator.SetPolicy(policy.Eval)
+		s.Evaluator.SetIgnore(policy.ShouldIgnore)
 	}
 }
 
@@ -86,6 +89,9 @@ func (s *Service) ForkBranch(name string, switchNow bool) (*db.Branch, error) {
 	if name == "" {
 		return nil, fmt.Errorf("branch name cannot be empty")
 	}
+	if err := ValidateRevisionName(name); err != nil {
+		return nil, fmt.Errorf("invalid branch name: %w", err)
+	}
 	if strings.EqualFold(name, defaultBranchName) {
 		if _, err := s.DB.GetBranch(defaultBranchName); err == nil {
 			return nil, fmt.Errorf("branch %q already exists", defaultBranchName)
@@ -269,6 +275,108 @@ func (s *Service) createCellFromManifest(
 		}
 	}
 
+	cell, entries := s.newCellRecord(seq, branch, manifest, opts, parent, parentEntries)
+	if err := s.DB.InsertCellWithManifestAndAdvanceBranch(cell, entries); err != nil {
+		return nil, fmt.Errorf("insert cell: %w", err)
+	}
+
+	scored := false
+	if opts.RunEval {
+		if _, err := s.EvaluateCell(ctx, cellID); err != nil {
+			// Evaluation is best-effort; persist failure text and still keep snapshot.
+			errText := err.Error()
+			if updateErr := s.DB.UpdateCellEval(cellID, nil, nil, nil, nil, nil, &errText); updateErr != nil {
+				return nil, fmt.Errorf("persist eval failure: %w", updateErr)
+			}
+		} else {
+			scored = true
+		}
+	}
+	if !scored {
+		if _, err := s.ScoreCell(cellID); err != nil {
+			return nil, fmt.Errorf("score cell: %w", err)
+		}
+	}
+
+	created, err := s.DB.GetCell(cellID)
+	if err != nil {
+		return nil, fmt.Errorf("load created cell: %w", err)
+	}
+	return created, nil
+}
+
+func (s *Service) EvaluateCell(ctx context.Context, cellID string) (eval.Result, error) {
+	cell, err := s.DB.GetCell(cellID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return eval.Result{}, fmt.Errorf("cell %s not found", cellID)
+		}
+		return eval.Result{}, err
+	}
+	if s.Evaluator == nil {
+		return eval.Result{}, fmt.Errorf("evaluator is not configured")
+	}
+
+	result, err := s.runEvaluator(ctx, cell, s.ProjectDir)
+	if recordErr := s.recordEval(cellID, result, err); recordErr != nil {
+		return eval.Result{}, recordErr
+	}
+	return result, err
+}
+
+// recordEval stores an eval result, or the error that cut it short, on a cell
+// and rescores it.
+func (s *Service) recordEval(cellID string, result eval.Result, err error) error {
+	var errText *string
+	if err != nil {
+		e := err.Error()
+		errText = &e
+	}
+	if updateErr := s.DB.UpdateCellEval(
+		cellID,
+		result.TestsPassedPtr(),
+		result.TestsFailedPtr(),
+		result.LintErrorsPtr(),
+		result.TypeErrorsPtr(),
+		result.SkippedPtr(),
+		errText,
+	); updateErr != nil {
+		return updateErr
+	}
+	var scope *string
+	if result.Scope != "" {
+		value := string(result.Scope)
+		scope = &value
+	}
+	if updateErr := s.DB.SetCellEvalScope(cellID, scope); updateErr != nil {
+		return updateErr
+	}
+	if updateErr := s.DB.ReplaceEvalProjects(cellID, evalProjectsFromResult(cellID, result)); updateErr != nil {
+		return updateErr
+	}
+	if updateErr := s.DB.ReplaceCellCoverage(cellID, result.CoveragePctPtr(), fileCoverageFromResult(cellID, result)); updateErr != nil {
+		return updateErr
+	}
+	if updateErr := s.DB.ReplaceBenchSamples(cellID, benchSamplesFromResult(cellID, result)); updateErr != nil {
+		return updateErr
+	}
+	if _, updateErr := s.ScoreCell(cellID); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+// newCellRecord builds the row and manifest for a new cell on branch, with
+// diff stats against parent.
+func (s *Service) newCellRecord(
+	seq int,
+	branch string,
+	manifest snapshot.Manifest,
+	opts SnapOptions,
+	parent *db.Cell,
+	parentEntries []db.ManifestEntry,
+) (db.Cell, []db.ManifestEntry) {
+	cellID := CellID(seq)
 	added, modified, removed, linesAdded, linesRemoved := computeDiffStats(manifest, parentEntries, s.Store)
 	totalLOC, totalFiles := computeLOC(manifest, s.Store)
 	locDelta := totalLOC
@@ -330,56 +438,73 @@ func (s *Service) createCellFromManifest(
 		})
 	}
 
-	if err := s.DB.InsertCellWithManifestAndAdvanceBranch(cell, entries); err != nil {
-		return nil, fmt.Errorf("insert cell: %w", err)
-	}
+	return cell, entries
+}
 
-	if opts.RunEval {
-		if _, err := s.EvaluateCell(ctx, cellID); err != nil {
-			// Evaluation is best-effort; persist failure text and still keep snapshot.
-			errText := err.Error()
-			if updateErr := s.DB.UpdateCellEval(cellID, nil, nil, nil, nil, nil, &errText); updateErr != nil {
-				return nil, fmt.Errorf("persist eval failure: %w", updateErr)
-			}
-		}
+func benchSamplesFromResult(cellID string, result eval.Result) []db.BenchSample {
+	out := make([]db.BenchSample, 0, len(result.Bench))
+	for _, sample := range result.Bench {
+		out = append(out, db.BenchSample{CellID: cellID, Name: sample.Name, Unit: sample.Unit, Value: sample.Value})
 	}
+	return out
+}
 
-	created, err := s.DB.GetCell(cellID)
-	if err != nil {
-		return nil, fmt.Errorf("load created cell: %w", err)
+func fileCoverageFromResult(cellID string, result eval.Result) []db.FileCoverage {
+	if result.Coverage == nil {
+		return nil
 	}
-	return created, nil
+	out := make([]db.FileCoverage, 0, len(result.Coverage.Files))
+	for _, f := range result.Coverage.Files {
+		out = append(out, db.FileCoverage{CellID: cellID, Path: f.Path, Covered: f.Covered, Total: f.Total})
+	}
+	return out
 }
 
-func (s *Service) EvaluateCell(ctx context.Context, cellID string) (eval.Result, error) {
-	if _, err := s.DB.GetCell(cellID); err != nil {
-		if err == db.ErrNotFound {
-			return eval.Result{}, fmt.Errorf("cell %s not found", cellID)
-		}
-		return eval.Result{}, err
+// runEvaluator evaluates cell's files in dir: a full eval unless the policy
+// asks for changed scope and the cell has a parent to diff against.
+func (s *Service) runEvaluator(ctx context.Context, cell *db.Cell, dir string) (eval.Result, error) {
+	if s.Policy.Eval.Scope != config.EvalScopeChanged || cell.ParentID == nil {
+		return s.Evaluator.Run(ctx, dir)
 	}
-	if s.Evaluator == nil {
-		return eval.Result{}, fmt.Errorf("evaluator is not configured")
+	changed, err := s.changedPathsFromParent(cell)
+	if err != nil {
+		return eval.Result{}, err
 	}
+	return s.Evaluator.RunChanged(ctx, dir, changed)
+}
 
-	result, err := s.Evaluator.Run(ctx, s.ProjectDir)
-	var errText *string
+func (s *Service) changedPathsFromParent(cell *db.Cell) ([]string, error) {
+	parentEntries, err := s.DB.GetManifest(*cell.ParentID)
 	if err != nil {
-		e := err.Error()
-		errText = &e
+		return nil, err
 	}
-	if updateErr := s.DB.UpdateCellEval(
-		cellID,
-		result.TestsPassedPtr(),
-		result.TestsFailedPtr(),
-		result.LintErrorsPtr(),
-		result.TypeErrorsPtr(),
-		result.SkippedPtr(),
-		errText,
-	); updateErr != nil {
-		return eval.Result{}, updateErr
+	entries, err := s.DB.GetManifest(cell.ID)
+	if err != nil {
+		return nil, err
 	}
-	return result, err
+	delta := diff.CompareManifests(manifestHashesFromEntries(parentEntries), manifestHashesFromEntries(entries))
+	changed := make([]string, 0, len(delta.Added)+len(delta.Modified)+len(delta.Removed))
+	changed = append(changed, delta.Added...)
+	changed = append(changed, delta.Modified...)
+	changed = append(changed, delta.Removed...)
+	return changed, nil
+}
+
+func evalProjectsFromResult(cellID string, result eval.Result) []db.EvalProject {
+	out := make([]db.EvalProject, 0, len(result.Projects))
+	for _, project := range result.Projects {
+		out = append(out, db.EvalProject{
+			CellID:       cellID,
+			Dir:          project.Dir,
+			ProjectTypes: eval.ProjectTypesString(project.Types),
+			TestsPassed:  project.TestsPassedPtr(),
+			TestsFailed:  project.TestsFailedPtr(),
+			LintErrors:   project.LintErrorsPtr(),
+			TypeErrors:   project.TypeErrorsPtr(),
+			EvalSkipped:  project.SkippedPtr(),
+		})
+	}
+	return out
 }
 
 func (s *Service) WorkingTreeDelta(ctx context.Context) (*db.Cell, WorkingTreeDelta, error) {
@@ -523,7 +648,7 @@ func computeDiffStats(current snapshot.Manifest, parentEntries []db.ManifestEntr
 	return
 }
 
-func computeLOC(manifest snapshot.Manifest, objectStore *store.Store) (int, int) {
+func computeLOC(manifest snapshot.Manifest, objectStore store.Reader) (int, int) {
 	totalLOC := 0
 	paths := make([]string, 0, len(manifest))
 	for path := range manifest {
@@ -4,8 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/prit3010/converge/internal/config"
 	"github.com/prit3010/converge/internal/db"
 	"github.com/prit3010/converge/internal/eval"
 	"github.com/prit3010/converge/internal/store"
@@ -103,6 +105,50 @@ func TestEvaluateCellPersistsResult(t *testing.T) {
 	}
 }
 
+func TestEvaluateCellRecordsChangedScope(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	policy := config.DefaultPolicy()
+	policy.Eval.Scope = config.EvalScopeChanged
+	svc.SetPolicy(policy)
+
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "notes.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	first, err := svc.CreateCell(ctx, SnapOptions{Message: "base", RunEval: false})
+	if err != nil {
+		t.Fatalf("create first cell: %v", err)
+	}
+	if _, err := svc.EvaluateCell(ctx, first.ID); err != nil {
+		t.Fatalf("evaluate first cell: %v", err)
+	}
+	got, err := svc.DB.GetCell(first.ID)
+	if err != nil {
+		t.Fatalf("get first cell: %v", err)
+	}
+	if got.EvalScope == nil || *got.EvalScope != string(config.EvalScopeFull) {
+		t.Fatalf("expected root cell to fall back to full scope, got %v", got.EvalScope)
+	}
+
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "notes.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	second, err := svc.CreateCell(ctx, SnapOptions{Message: "edit", RunEval: false})
+	if err != nil {
+		t.Fatalf("create second cell: %v", err)
+	}
+	if _, err := svc.EvaluateCell(ctx, second.ID); err != nil {
+		t.Fatalf("evaluate second cell: %v", err)
+	}
+	got, err = svc.DB.GetCell(second.ID)
+	if err != nil {
+		t.Fatalf("get second cell: %v", err)
+	}
+	if got.EvalScope == nil || *got.EvalScope != string(config.EvalScopeChanged) {
+		t.Fatalf("expected changed scope, got %v", got.EvalScope)
+	}
+}
+
 func TestBranchForkAndSwitchUsesBranchHeadParent(t *testing.T) {
 	svc := newTestService(t)
 	ctx := context.Background()
@@ -120,6 +166,11 @@ func TestBranchForkAndSwitchUsesBranchHeadParent(t *testing.T) {
 		t.Fatalf("expected main branch, got %s", mainBase.Branch)
 	}
 
+	for _, name := range []string{"HEAD", "c_000007", "team:auth", "two words"} {
+		if _, err := svc.ForkBranch(name, false); err == nil || !strings.Contains(err.Error(), "invalid branch name") {
+			t.Fatalf("expected fork %q to be rejected, got %v", name, err)
+		}
+	}
 	if _, err := svc.ForkBranch("feature-a", true); err != nil {
 		t.Fatalf("fork feature-a: %v", err)
 	}
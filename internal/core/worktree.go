@@ -0,0 +1,65 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/snapshot"
+	"github.com/prit3010/converge/internal/store"
+)
+
+// WorktreeRef names the uncommitted working tree wherever a cell ID is
+// accepted.
+const WorktreeRef = "WORKTREE"
+
+// Worktree is the working tree captured in memory and shaped like a cell so
+// diff and compare can treat it as one. Nothing is written to the database
+// or the object store.
+type Worktree struct {
+	Cell     db.Cell
+	Manifest []db.ManifestEntry
+	// Blobs serves the working tree's contents ahead of the object store.
+	Blobs *store.Overlay
+}
+
+// CaptureWorktree snapshots the working tree into memory. The pseudo-cell's
+// parent is the active branch head, matching what a snap would record.
+func (s *Service) CaptureWorktree() (*Worktree, error) {
+	overlay := store.NewOverlay(s.Store)
+	manifest, err := s.Snapshot.CaptureOverlay(s.ProjectDir, overlay)
+	if err != nil {
+		return nil, fmt.Errorf("capture working tree: %w", err)
+	}
+	branch, err := s.ActiveBranch()
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.branchHeadCell(branch)
+	if err != nil {
+		return nil, fmt.Errorf("branch head for %s: %w", branch, err)
+	}
+
+	totalLOC, totalFiles := computeLOC(manifest, overlay)
+	cell := db.Cell{
+		ID:         WorktreeRef,
+		Timestamp:  time.Now().UTC().Format(time.RFC3339Nano),
+		Message:    "uncommitted working tree",
+		Source:     "worktree",
+		Branch:     branch,
+		TotalLOC:   totalLOC,
+		TotalFiles: totalFiles,
+	}
+	if head != nil {
+		cell.ParentID = &head.ID
+		cell.Sequence = head.Sequence
+		cell.LOCDelta = totalLOC - head.TotalLOC
+	}
+
+	entries := make([]db.ManifestEntry, 0, len(manifest))
+	for _, path := range snapshot.SortedPaths(manifest) {
+		f := manifest[path]
+		entries = append(entries, db.ManifestEntry{CellID: WorktreeRef, Path: path, Hash: f.Hash, Mode: int(f.Mode), Size: f.Size})
+	}
+	return &Worktree{Cell: cell, Manifest: entries, Blobs: overlay}, nil
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prit3010/converge/internal/store"
+)
+
+func TestCaptureWorktreeDoesNotPersist(t *testing.T) {
+	svc := newTestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	head, err := svc.CreateCell(context.Background(), SnapOptions{Message: "first", Source: "manual"})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	edited := []byte("package main\n\nfunc main() {}\n")
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), edited, 0o644); err != nil {
+		t.Fatalf("rewrite main.go: %v", err)
+	}
+
+	wt, err := svc.CaptureWorktree()
+	if err != nil {
+		t.Fatalf("capture worktree: %v", err)
+	}
+	if wt.Cell.ID != WorktreeRef || wt.Cell.ParentID == nil || *wt.Cell.ParentID != head.ID {
+		t.Fatalf("expected worktree cell parented on %s, got %+v", head.ID, wt.Cell)
+	}
+	if len(wt.Manifest) != 1 || wt.Manifest[0].Hash != store.Hash(edited) {
+		t.Fatalf("unexpected worktree manifest %+v", wt.Manifest)
+	}
+	if svc.Store.Has(store.Hash(edited)) {
+		t.Fatalf("worktree capture wrote to the object store")
+	}
+	if data, err := wt.Blobs.Read(wt.Manifest[0].Hash); err != nil || string(data) != string(edited) {
+		t.Fatalf("worktree blob read: %q, %v", data, err)
+	}
+	if _, err := svc.DB.GetCell(WorktreeRef); err == nil {
+		t.Fatalf("worktree capture persisted a cell")
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+)
+
+// ReplaceBenchSamples swaps the benchmark samples stored for a cell. Sample
+// order is preserved per (name, unit).
+func (d *DB) ReplaceBenchSamples(cellID string, samples []BenchSample) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin bench samples tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM bench_samples WHERE cell_id = ?`, cellID); err != nil {
+		return fmt.Errorf("clear bench samples %s: %w", cellID, err)
+	}
+	next := map[[2]string]int{}
+	for _, s := range samples {
+		key := [2]string{s.Name, s.Unit}
+		idx := next[key]
+		next[key] = idx + 1
+		_, err := tx.Exec(`
+INSERT INTO bench_samples (cell_id, name, unit, idx, value)
+VALUES (?, ?, ?, ?, ?)
+`, cellID, s.Name, s.Unit, idx, s.Value)
+		if err != nil {
+			return fmt.Errorf("insert bench sample %s %s: %w", cellID, s.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit bench samples tx: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) ListBenchSamples(cellID string) ([]BenchSample, error) {
+	return d.queryBenchSamples(`
+SELECT cell_id, name, unit, value
+FROM bench_samples
+WHERE cell_id = ?
+ORDER BY name ASC, unit ASC, idx ASC
+`, cellID)
+}
+
+// ListAllBenchSamples returns every stored sample ordered by cell sequence,
+// for plotting metrics across history.
+func (d *DB) ListAllBenchSamples() ([]BenchSample, error) {
+	return d.queryBenchSamples(`
+SELECT b.cell_id, b.name, b.unit, b.value
+FROM bench_samples b
+JOIN cells c ON c.id = b.cell_id
+ORDER BY c.sequence ASC, b.name ASC, b.unit ASC, b.idx ASC
+`)
+}
+
+func (d *DB) queryBenchSamples(query string, args ...any) ([]BenchSample, error) {
+	rows, err := d.sql.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list bench samples: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]BenchSample, 0)
+	for rows.Next() {
+		var s BenchSample
+		if err := rows.Scan(&s.CellID, &s.Name, &s.Unit, &s.Value); err != nil {
+			return nil, fmt.Errorf("scan bench sample: %w", err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bench samples: %w", err)
+	}
+	return out, nil
+}
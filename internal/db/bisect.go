@@ -0,0 +1,63 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BisectResult records one bisect verdict on a cell. An empty Command means
+// the configured eval was used.
+type BisectResult struct {
+	ID        int64  `json:"id"`
+	CellID    string `json:"cell_id"`
+	Command   string `json:"command"`
+	Good      bool   `json:"good"`
+	ExitCode  *int   `json:"exit_code,omitempty"`
+	Output    string `json:"output"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AddBisectResult stores a verdict and returns it with its ID and timestamp set.
+func (d *DB) AddBisectResult(r BisectResult) (*BisectResult, error) {
+	r.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.Exec(`
+INSERT INTO bisect_results (cell_id, command, good, exit_code, output, created_at) VALUES (?, ?, ?, ?, ?, ?)
+`, r.CellID, r.Command, r.Good, r.ExitCode, r.Output, r.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("add bisect result on %s: %w", r.CellID, err)
+	}
+	r.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read bisect result id: %w", err)
+	}
+	return &r, nil
+}
+
+// ListBisectResults returns a cell's bisect verdicts oldest first.
+func (d *DB) ListBisectResults(cellID string) ([]BisectResult, error) {
+	rows, err := d.sql.Query(`
+SELECT id, cell_id, command, good, exit_code, output, created_at FROM bisect_results WHERE cell_id = ? ORDER BY id ASC
+`, cellID)
+	if err != nil {
+		return nil, fmt.Errorf("list bisect results %s: %w", cellID, err)
+	}
+	defer rows.Close()
+	out := make([]BisectResult, 0)
+	for rows.Next() {
+		var r BisectResult
+		var exitCode sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.CellID, &r.Command, &r.Good, &exitCode, &r.Output, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan bisect result: %w", err)
+		}
+		if exitCode.Valid {
+			v := int(exitCode.Int64)
+			r.ExitCode = &v
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bisect results: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const comparisonSelect = `
+SELECT cell_a, cell_b, model, prompt_hash, provider, summary, winner, winner_reason,
+	highlights, risks, file_notes, prompt_tokens, completion_tokens, created_at
+FROM comparisons`
+
+// UpsertComparison stores a compare result, replacing any earlier result for
+// the same (cell_a, cell_b, model, prompt_hash).
+func (d *DB) UpsertComparison(c Comparison) error {
+	if c.CreatedAt == "" {
+		c.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	highlights, err := encodeJSONList(c.Highlights)
+	if err != nil {
+		return fmt.Errorf("encode comparison highlights: %w", err)
+	}
+	risks, err := encodeJSONList(c.Risks)
+	if err != nil {
+		return fmt.Errorf("encode comparison risks: %w", err)
+	}
+	fileNotes, err := encodeJSONList(c.FileNotes)
+	if err != nil {
+		return fmt.Errorf("encode comparison file notes: %w", err)
+	}
+	_, err = d.sql.Exec(`
+INSERT INTO comparisons (
+	cell_a, cell_b, model, prompt_hash, provider, summary, winner, winner_reason,
+	highlights, risks, file_notes, prompt_tokens, completion_tokens, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(cell_a, cell_b, model, prompt_hash) DO UPDATE SET
+	provider = excluded.provider,
+	summary = excluded.summary,
+	winner = excluded.winner,
+	winner_reason = excluded.winner_reason,
+	highlights = excluded.highlights,
+	risks = excluded.risks,
+	file_notes = excluded.file_notes,
+	prompt_tokens = excluded.prompt_tokens,
+	completion_tokens = excluded.completion_tokens,
+	created_at = excluded.created_at
+`, c.CellA, c.CellB, c.Model, c.PromptHash, c.Provider, c.Summary, c.Winner, c.WinnerReason,
+		highlights, risks, fileNotes, c.PromptTokens, c.CompletionTokens, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert comparison %s %s: %w", c.CellA, c.CellB, err)
+	}
+	return nil
+}
+
+func (d *DB) GetComparison(cellA, cellB, model, promptHash string) (*Comparison, error) {
+	row := d.sql.QueryRow(comparisonSelect+`
+WHERE cell_a = ? AND cell_b = ? AND model = ? AND prompt_hash = ?
+`, cellA, cellB, model, promptHash)
+	c, err := scanComparison(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get comparison %s %s: %w", cellA, cellB, err)
+	}
+	return c, nil
+}
+
+// ListComparisonsForCell returns stored comparisons in which the cell appears
+// on either side, newest first.
+func (d *DB) ListComparisonsForCell(cellID string) ([]Comparison, error) {
+	rows, err := d.sql.Query(comparisonSelect+`
+WHERE cell_a = ? OR cell_b = ?
+ORDER BY created_at DESC
+`, cellID, cellID)
+	if err != nil {
+		return nil, fmt.Errorf("list comparisons %s: %w", cellID, err)
+	}
+	defer rows.Close()
+
+	out := make([]Comparison, 0)
+	for rows.Next() {
+		c, err := scanComparison(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan comparison: %w", err)
+		}
+		out = append(out, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate comparisons: %w", err)
+	}
+	return out, nil
+}
+
+func scanComparison(row cellScanner) (*Comparison, error) {
+	var c Comparison
+	var highlights, risks, fileNotes string
+	if err := row.Scan(
+		&c.CellA,
+		&c.CellB,
+		&c.Model,
+		&c.PromptHash,
+		&c.Provider,
+		&c.Summary,
+		&c.Winner,
+		&c.WinnerReason,
+		&highlights,
+		&risks,
+		&fileNotes,
+		&c.PromptTokens,
+		&c.CompletionTokens,
+		&c.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(highlights), &c.Highlights); err != nil {
+		return nil, fmt.Errorf("decode comparison highlights: %w", err)
+	}
+	if err := json.Unmarshal([]byte(risks), &c.Risks); err != nil {
+		return nil, fmt.Errorf("decode comparison risks: %w", err)
+	}
+	if err := json.Unmarshal([]byte(fileNotes), &c.FileNotes); err != nil {
+		return nil, fmt.Errorf("decode comparison file notes: %w", err)
+	}
+	return &c, nil
+}
+
+// encodeJSONList stores nil slices as [] so the NOT NULL columns always hold
+// valid JSON.
+func encodeJSONList[T any](items []T) (string, error) {
+	if items == nil {
+		items = []T{}
+	}
+	encoded, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
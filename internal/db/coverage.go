@@ -0,0 +1,62 @@
+package db
+
+import (
+	"fmt"
+)
+
+// ReplaceCellCoverage stores a cell's total coverage percentage and swaps its
+// per-file coverage rows. A nil pct clears coverage for the cell.
+func (d *DB) ReplaceCellCoverage(cellID string, pct *float64, files []FileCoverage) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin coverage tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE cells SET coverage_pct = ? WHERE id = ?`, pct, cellID); err != nil {
+		return fmt.Errorf("update cell coverage %s: %w", cellID, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM file_coverage WHERE cell_id = ?`, cellID); err != nil {
+		return fmt.Errorf("clear file coverage %s: %w", cellID, err)
+	}
+	for _, f := range files {
+		_, err := tx.Exec(`
+INSERT INTO file_coverage (cell_id, path, covered, total)
+VALUES (?, ?, ?, ?)
+`, cellID, f.Path, f.Covered, f.Total)
+		if err != nil {
+			return fmt.Errorf("insert file coverage %s %s: %w", cellID, f.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit coverage tx: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) ListFileCoverage(cellID string) ([]FileCoverage, error) {
+	rows, err := d.sql.Query(`
+SELECT cell_id, path, covered, total
+FROM file_coverage
+WHERE cell_id = ?
+ORDER BY path ASC
+`, cellID)
+	if err != nil {
+		return nil, fmt.Errorf("list file coverage %s: %w", cellID, err)
+	}
+	defer rows.Close()
+
+	out := make([]FileCoverage, 0)
+	for rows.Next() {
+		var f FileCoverage
+		if err := rows.Scan(&f.CellID, &f.Path, &f.Covered, &f.Total); err != nil {
+			return nil, fmt.Errorf("scan file coverage: %w", err)
+		}
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate file coverage: %w", err)
+	}
+	return out, nil
+}
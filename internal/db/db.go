@@ -39,6 +39,15 @@ func (d *DB) Ping() error {
 	return d.sql.Ping()
 }
 
+// BackupTo writes a consistent copy of the database to path, which must not
+// exist yet.
+func (d *DB) BackupTo(path string) error {
+	if _, err := d.sql.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
+
 func migrate(sqlDB *sql.DB) error {
 	tx, err := sqlDB.Begin()
 	if err != nil {
@@ -99,6 +108,7 @@ CREATE TABLE IF NOT EXISTS meta (
 
 CREATE INDEX IF NOT EXISTS idx_cells_sequence ON cells(sequence DESC);
 CREATE INDEX IF NOT EXISTS idx_manifest_cell ON manifest_entries(cell_id);
+CREATE INDEX IF NOT EXISTS idx_manifest_path ON manifest_entries(path);
 
 CREATE TABLE IF NOT EXISTS cell_sequences (
 	name TEXT PRIMARY KEY,
@@ -120,6 +130,123 @@ CREATE TABLE IF NOT EXISTS agent_runs (
 );
 
 CREATE INDEX IF NOT EXISTS idx_agent_runs_updated_at ON agent_runs(updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS eval_projects (
+	cell_id TEXT NOT NULL,
+	dir TEXT NOT NULL,
+	project_types TEXT NOT NULL DEFAULT '',
+	tests_passed INTEGER,
+	tests_failed INTEGER,
+	lint_errors INTEGER,
+	type_errors INTEGER,
+	eval_skipped TEXT,
+	PRIMARY KEY (cell_id, dir),
+	FOREIGN KEY(cell_id) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS bench_samples (
+	cell_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	unit TEXT NOT NULL DEFAULT '',
+	idx INTEGER NOT NULL,
+	value REAL NOT NULL,
+	PRIMARY KEY (cell_id, name, unit, idx),
+	FOREIGN KEY(cell_id) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS file_coverage (
+	cell_id TEXT NOT NULL,
+	path TEXT NOT NULL,
+	covered INTEGER NOT NULL,
+	total INTEGER NOT NULL,
+	PRIMARY KEY (cell_id, path),
+	FOREIGN KEY(cell_id) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comparisons (
+	cell_a TEXT NOT NULL,
+	cell_b TEXT NOT NULL,
+	model TEXT NOT NULL,
+	prompt_hash TEXT NOT NULL,
+	provider TEXT NOT NULL DEFAULT '',
+	summary TEXT NOT NULL DEFAULT '',
+	winner TEXT NOT NULL DEFAULT '',
+	highlights TEXT NOT NULL DEFAULT '[]',
+	prompt_tokens INTEGER,
+	completion_tokens INTEGER,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (cell_a, cell_b, model, prompt_hash),
+	FOREIGN KEY(cell_a) REFERENCES cells(id) ON DELETE CASCADE,
+	FOREIGN KEY(cell_b) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_comparisons_cell_b ON comparisons(cell_b);
+
+CREATE TABLE IF NOT EXISTS rankings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	model TEXT NOT NULL,
+	prompt_hash TEXT NOT NULL,
+	provider TEXT NOT NULL DEFAULT '',
+	summary TEXT NOT NULL DEFAULT '',
+	metric_basis TEXT NOT NULL DEFAULT '',
+	prompt_tokens INTEGER,
+	completion_tokens INTEGER,
+	created_at TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rankings_prompt ON rankings(model, prompt_hash);
+
+CREATE TABLE IF NOT EXISTS ranking_entries (
+	ranking_id INTEGER NOT NULL,
+	cell_id TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	llm_position INTEGER NOT NULL,
+	metric_position INTEGER,
+	rationale TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (ranking_id, cell_id),
+	FOREIGN KEY(ranking_id) REFERENCES rankings(id) ON DELETE CASCADE,
+	FOREIGN KEY(cell_id) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ranking_entries_cell ON ranking_entries(cell_id);
+
+CREATE TABLE IF NOT EXISTS cell_tags (
+	cell_id TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (cell_id, tag),
+	FOREIGN KEY(cell_id) REFERENCES cells(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cell_tags_tag ON cell_tags(tag);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+	name TEXT PRIMARY KEY,
+	cell_id TEXT NOT NULL REFERENCES cells(id) ON DELETE CASCADE,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS cell_notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	cell_id TEXT NOT NULL REFERENCES cells(id) ON DELETE CASCADE,
+	body TEXT NOT NULL,
+	path TEXT,
+	line INTEGER,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cell_notes_cell ON cell_notes(cell_id, id);
+
+CREATE TABLE IF NOT EXISTS bisect_results (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	cell_id TEXT NOT NULL REFERENCES cells(id) ON DELETE CASCADE,
+	command TEXT NOT NULL,
+	good INTEGER NOT NULL,
+	exit_code INTEGER,
+	output TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_bisect_results_cell ON bisect_results(cell_id, id);
 `
 	if _, err := tx.Exec(schema); err != nil {
 		return fmt.Errorf("create base schema: %w", err)
@@ -128,6 +255,30 @@ CREATE INDEX IF NOT EXISTS idx_agent_runs_updated_at ON agent_runs(updated_at DE
 	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN branch TEXT NOT NULL DEFAULT 'main'`); err != nil && !isDuplicateColumnError(err) {
 		return fmt.Errorf("add branch column: %w", err)
 	}
+	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN eval_scope TEXT`); err != nil && !isDuplicateColumnError(err) {
+		return fmt.Errorf("add eval_scope column: %w", err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN coverage_pct REAL`); err != nil && !isDuplicateColumnError(err) {
+		return fmt.Errorf("add coverage_pct column: %w", err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN score REAL`); err != nil && !isDuplicateColumnError(err) {
+		return fmt.Errorf("add score column: %w", err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN summary TEXT`); err != nil && !isDuplicateColumnError(err) {
+		return fmt.Errorf("add summary column: %w", err)
+	}
+	if _, err := tx.Exec(`ALTER TABLE cells ADD COLUMN origin TEXT`); err != nil && !isDuplicateColumnError(err) {
+		return fmt.Errorf("add origin column: %w", err)
+	}
+	for _, column := range []string{
+		`winner_reason TEXT NOT NULL DEFAULT ''`,
+		`risks TEXT NOT NULL DEFAULT '[]'`,
+		`file_notes TEXT NOT NULL DEFAULT '[]'`,
+	} {
+		if _, err := tx.Exec(`ALTER TABLE comparisons ADD COLUMN ` + column); err != nil && !isDuplicateColumnError(err) {
+			return fmt.Errorf("add comparisons column: %w", err)
+		}
+	}
 
 	if _, err := tx.Exec(`UPDATE cells SET branch = 'main' WHERE branch IS NULL OR TRIM(branch) = ''`); err != nil {
 		return fmt.Errorf("backfill cell branch: %w", err)
@@ -135,6 +286,9 @@ CREATE INDEX IF NOT EXISTS idx_agent_runs_updated_at ON agent_runs(updated_at DE
 	if _, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_cells_branch_sequence ON cells(branch, sequence DESC)`); err != nil {
 		return fmt.Errorf("create branch index: %w", err)
 	}
+	if err := migrateCellTagsTx(tx); err != nil {
+		return err
+	}
 
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	var latestID *string
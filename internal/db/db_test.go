@@ -148,6 +148,41 @@ func TestBranchingDefaultsAndHeadAdvance(t *testing.T) {
 	}
 }
 
+func TestInsertImportedBranchIsAllOrNothing(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	parent := "c_000001"
+	cells := []Cell{
+		{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "one", Source: "import", Branch: "review"},
+		{ID: "c_000002", Sequence: 2, Timestamp: "2026-02-28T00:00:01Z", Message: "two", Source: "import", Branch: "review", ParentID: &parent},
+	}
+	manifests := [][]ManifestEntry{
+		{{CellID: "c_000001", Path: "a.txt", Hash: "abc", Mode: 0o644, Size: 1}},
+		{{CellID: "c_000002", Path: "a.txt", Hash: "def", Mode: 0o644, Size: 1}},
+	}
+	if err := d.CreateBranch("review", nil, ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if err := d.InsertImportedBranch("review", cells, manifests); err == nil {
+		t.Fatalf("expected an existing branch to fail the import")
+	}
+	if _, err := d.GetCell("c_000001"); err != ErrNotFound {
+		t.Fatalf("expected failed import to leave no cells, got %v", err)
+	}
+
+	if err := d.InsertImportedBranch("review-2", cells, manifests); err != nil {
+		t.Fatalf("insert imported branch: %v", err)
+	}
+	branch, err := d.GetBranch("review-2")
+	if err != nil || branch.HeadCellID == nil || *branch.HeadCellID != "c_000002" {
+		t.Fatalf("expected review-2 at c_000002, got %+v (err %v)", branch, err)
+	}
+	if entries, err := d.GetManifest("c_000002"); err != nil || len(entries) != 1 || entries[0].Hash != "def" {
+		t.Fatalf("unexpected manifest %+v (err %v)", entries, err)
+	}
+}
+
 func TestOpenMigratesLegacySchemaWithoutBranchColumn(t *testing.T) {
 	tmp := t.TempDir()
 	dbPath := filepath.Join(tmp, "legacy.db")
@@ -286,3 +321,337 @@ func openTestDB(t *testing.T) *DB {
 	}
 	return d
 }
+
+func TestReplaceEvalProjectsRoundTrip(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cell := Cell{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "first", Source: "manual"}
+	if err := d.InsertCellWithManifest(cell, nil); err != nil {
+		t.Fatalf("insert cell: %v", err)
+	}
+
+	failed := 2
+	first := []EvalProject{
+		{Dir: "web", ProjectTypes: "node", TestsFailed: &failed},
+		{Dir: "services/api", ProjectTypes: "go"},
+	}
+	if err := d.ReplaceEvalProjects(cell.ID, first); err != nil {
+		t.Fatalf("replace eval projects: %v", err)
+	}
+	got, err := d.ListEvalProjects(cell.ID)
+	if err != nil {
+		t.Fatalf("list eval projects: %v", err)
+	}
+	if len(got) != 2 || got[0].Dir != "services/api" || got[1].Dir != "web" {
+		t.Fatalf("unexpected eval projects: %+v", got)
+	}
+	if got[1].TestsFailed == nil || *got[1].TestsFailed != 2 {
+		t.Fatalf("expected web tests_failed=2, got %+v", got[1].TestsFailed)
+	}
+
+	if err := d.ReplaceEvalProjects(cell.ID, []EvalProject{{Dir: ".", ProjectTypes: "go"}}); err != nil {
+		t.Fatalf("replace eval projects again: %v", err)
+	}
+	got, err = d.ListEvalProjects(cell.ID)
+	if err != nil {
+		t.Fatalf("list eval projects: %v", err)
+	}
+	if len(got) != 1 || got[0].Dir != "." {
+		t.Fatalf("expected replaced eval projects, got %+v", got)
+	}
+}
+
+func TestReplaceCellCoverage(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cell := Cell{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "first", Source: "manual"}
+	if err := d.InsertCellWithManifest(cell, nil); err != nil {
+		t.Fatalf("insert cell: %v", err)
+	}
+
+	pct := 62.5
+	files := []FileCoverage{
+		{Path: "b.go", Covered: 1, Total: 4},
+		{Path: "a.go", Covered: 4, Total: 4},
+	}
+	if err := d.ReplaceCellCoverage(cell.ID, &pct, files); err != nil {
+		t.Fatalf("replace coverage: %v", err)
+	}
+	got, err := d.GetCell(cell.ID)
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if got.CoveragePct == nil || *got.CoveragePct != 62.5 {
+		t.Fatalf("expected coverage_pct 62.5, got %v", got.CoveragePct)
+	}
+	listed, err := d.ListFileCoverage(cell.ID)
+	if err != nil {
+		t.Fatalf("list file coverage: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Path != "a.go" || listed[1].Covered != 1 {
+		t.Fatalf("unexpected file coverage: %+v", listed)
+	}
+
+	if err := d.ReplaceCellCoverage(cell.ID, nil, nil); err != nil {
+		t.Fatalf("clear coverage: %v", err)
+	}
+	got, err = d.GetCell(cell.ID)
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if got.CoveragePct != nil {
+		t.Fatalf("expected coverage cleared, got %v", *got.CoveragePct)
+	}
+}
+
+func TestReplaceBenchSamplesPreservesOrder(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cell := Cell{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "first", Source: "manual"}
+	if err := d.InsertCellWithManifest(cell, nil); err != nil {
+		t.Fatalf("insert cell: %v", err)
+	}
+	samples := []BenchSample{
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 3},
+		{Name: "BenchmarkA", Unit: "ns/op", Value: 1},
+		{Name: "BenchmarkA", Unit: "B/op", Value: 64},
+	}
+	if err := d.ReplaceBenchSamples(cell.ID, samples); err != nil {
+		t.Fatalf("replace bench samples: %v", err)
+	}
+	got, err := d.ListBenchSamples(cell.ID)
+	if err != nil {
+		t.Fatalf("list bench samples: %v", err)
+	}
+	if len(got) != 3 || got[0].Unit != "B/op" || got[1].Value != 3 || got[2].Value != 1 {
+		t.Fatalf("unexpected bench samples: %+v", got)
+	}
+	all, err := d.ListAllBenchSamples()
+	if err != nil {
+		t.Fatalf("list all bench samples: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 samples across cells, got %d", len(all))
+	}
+}
+
+func TestUpsertComparisonRoundTrip(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	for i, id := range []string{"c_000001", "c_000002", "c_000003"} {
+		cell := Cell{ID: id, Sequence: i + 1, Timestamp: "2026-02-28T00:00:00Z", Message: id, Source: "manual"}
+		if err := d.InsertCellWithManifest(cell, nil); err != nil {
+			t.Fatalf("insert cell %s: %v", id, err)
+		}
+	}
+	promptTokens := 1200
+	first := Comparison{
+		CellA: "c_000001", CellB: "c_000002", Model: "gpt-4o-mini", PromptHash: "abc",
+		Provider: "openai", Summary: "B is simpler", Winner: "c_000002",
+		WinnerReason: "fewer branches", Highlights: []string{"less code"},
+		Risks:        []string{"drops nil check"},
+		FileNotes:    []FileNote{{Path: "main.go", Note: "inlines helper"}},
+		PromptTokens: &promptTokens, CreatedAt: "2026-02-28T00:00:01Z",
+	}
+	if err := d.UpsertComparison(first); err != nil {
+		t.Fatalf("upsert comparison: %v", err)
+	}
+	first.Summary = "B is much simpler"
+	if err := d.UpsertComparison(first); err != nil {
+		t.Fatalf("re-upsert comparison: %v", err)
+	}
+	if err := d.UpsertComparison(Comparison{
+		CellA: "c_000002", CellB: "c_000003", Model: "gpt-4o-mini", PromptHash: "def",
+		CreatedAt: "2026-02-28T00:00:02Z",
+	}); err != nil {
+		t.Fatalf("upsert second comparison: %v", err)
+	}
+
+	got, err := d.GetComparison("c_000001", "c_000002", "gpt-4o-mini", "abc")
+	if err != nil {
+		t.Fatalf("get comparison: %v", err)
+	}
+	if got.Summary != "B is much simpler" || len(got.Highlights) != 1 || got.PromptTokens == nil || *got.PromptTokens != 1200 || got.CompletionTokens != nil {
+		t.Fatalf("unexpected comparison: %+v", got)
+	}
+	if got.WinnerReason != "fewer branches" || len(got.Risks) != 1 || len(got.FileNotes) != 1 || got.FileNotes[0].Path != "main.go" {
+		t.Fatalf("unexpected structured fields: %+v", got)
+	}
+	if _, err := d.GetComparison("c_000001", "c_000002", "gpt-4o-mini", "other"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for different prompt hash, got %v", err)
+	}
+
+	list, err := d.ListComparisonsForCell("c_000002")
+	if err != nil {
+		t.Fatalf("list comparisons: %v", err)
+	}
+	if len(list) != 2 || list[0].CellB != "c_000003" || list[1].Highlights == nil {
+		t.Fatalf("unexpected comparison list: %+v", list)
+	}
+}
+
+func TestSaveRankingReplacesSamePrompt(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	for i, id := range []string{"c_000001", "c_000002", "c_000003"} {
+		cell := Cell{ID: id, Sequence: i + 1, Timestamp: "2026-02-28T00:00:00Z", Message: id, Source: "manual"}
+		if err := d.InsertCellWithManifest(cell, nil); err != nil {
+			t.Fatalf("insert cell %s: %v", id, err)
+		}
+	}
+	metric := 2
+	ranking := Ranking{
+		Model: "gpt-4o-mini", PromptHash: "abc", Provider: "openai", Summary: "B leads", MetricBasis: "heuristic",
+		CreatedAt: "2026-02-28T00:00:01Z",
+		Entries: []RankingEntry{
+			{CellID: "c_000002", Position: 1, LLMPosition: 1, MetricPosition: &metric, Rationale: "simplest"},
+			{CellID: "c_000001", Position: 2, LLMPosition: 2, Rationale: "verbose"},
+		},
+	}
+	if _, err := d.SaveRanking(ranking); err != nil {
+		t.Fatalf("save ranking: %v", err)
+	}
+	ranking.Summary = "B still leads"
+	if _, err := d.SaveRanking(ranking); err != nil {
+		t.Fatalf("re-save ranking: %v", err)
+	}
+
+	got, err := d.GetRankingByPrompt("gpt-4o-mini", "abc")
+	if err != nil {
+		t.Fatalf("get ranking: %v", err)
+	}
+	if got.Summary != "B still leads" || len(got.Entries) != 2 || got.Entries[0].CellID != "c_000002" {
+		t.Fatalf("unexpected ranking: %+v", got)
+	}
+	if got.Entries[0].MetricPosition == nil || *got.Entries[0].MetricPosition != 2 || got.Entries[1].MetricPosition != nil {
+		t.Fatalf("unexpected metric positions: %+v", got.Entries)
+	}
+
+	all, err := d.ListRankings(0)
+	if err != nil {
+		t.Fatalf("list rankings: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("expected re-save to replace the ranking, got %d", len(all))
+	}
+	forCell, err := d.ListRankingsForCell("c_000003")
+	if err != nil {
+		t.Fatalf("list rankings for cell: %v", err)
+	}
+	if len(forCell) != 0 {
+		t.Fatalf("expected no rankings for c_000003, got %+v", forCell)
+	}
+}
+
+func TestCellTagsAndBookmarks(t *testing.T) {
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "converge.db")
+	d, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	tags := "wip, auth"
+	for i, cellTags := range []*string{&tags, nil} {
+		cell := Cell{ID: fmt.Sprintf("c_%06d", i+1), Sequence: i + 1, Timestamp: "2026-02-28T00:00:00Z", Message: "cell", Source: "manual", Tags: cellTags}
+		if err := d.InsertCellWithManifest(cell, nil); err != nil {
+			t.Fatalf("insert cell: %v", err)
+		}
+	}
+	if got, err := d.ListCellTags("c_000001"); err != nil || fmt.Sprint(got) != "[auth wip]" {
+		t.Fatalf("expected tags from insert, got %v err=%v", got, err)
+	}
+
+	if err := d.AddCellTags("c_000002", []string{"auth"}); err != nil {
+		t.Fatalf("add tags: %v", err)
+	}
+	if ids, err := d.ListCellIDsWithTag("auth"); err != nil || fmt.Sprint(ids) != "[c_000002 c_000001]" {
+		t.Fatalf("expected newest tagged cell first, got %v err=%v", ids, err)
+	}
+	removed, err := d.RemoveCellTags("c_000001", []string{"wip", "missing"})
+	if err != nil || removed != 1 {
+		t.Fatalf("expected 1 removed tag, got %d err=%v", removed, err)
+	}
+	cell, err := d.GetCell("c_000001")
+	if err != nil || cell.Tags == nil || *cell.Tags != "auth" {
+		t.Fatalf("expected tags column synced to auth, got %+v err=%v", cell, err)
+	}
+
+	if err := d.SetBookmark("good-auth", "c_000001"); err != nil {
+		t.Fatalf("set bookmark: %v", err)
+	}
+	if err := d.SetBookmark("good-auth", "c_000002"); err != nil {
+		t.Fatalf("move bookmark: %v", err)
+	}
+	if b, err := d.GetBookmark("good-auth"); err != nil || b.CellID != "c_000002" {
+		t.Fatalf("expected bookmark on c_000002, got %+v err=%v", b, err)
+	}
+	if err := d.DeleteBookmark("good-auth"); err != nil {
+		t.Fatalf("delete bookmark: %v", err)
+	}
+	if err := d.DeleteBookmark("good-auth"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// Simulate a database from before cell_tags existed and reopen it.
+	if _, err := d.sql.Exec(`DELETE FROM cell_tags; DELETE FROM meta WHERE key = 'cell_tags_migrated'; UPDATE cells SET tags = 'legacy,wip' WHERE id = 'c_000002'`); err != nil {
+		t.Fatalf("reset tags: %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	d, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+	counts, err := d.ListTagCounts()
+	if err != nil {
+		t.Fatalf("list tag counts: %v", err)
+	}
+	if fmt.Sprint(counts) != "[{auth 1} {legacy 1} {wip 1}]" {
+		t.Fatalf("unexpected migrated tag counts %v", counts)
+	}
+}
+
+func TestCellNotesRoundTrip(t *testing.T) {
+	d := openTestDB(t)
+	defer d.Close()
+
+	cell := Cell{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "cell", Source: "manual"}
+	if err := d.InsertCellWithManifest(cell, nil); err != nil {
+		t.Fatalf("insert cell: %v", err)
+	}
+	path, line := "main.go", 12
+	first, err := d.AddNote(Note{CellID: cell.ID, Body: "rejected: flaky"})
+	if err != nil {
+		t.Fatalf("add note: %v", err)
+	}
+	if _, err := d.AddNote(Note{CellID: cell.ID, Body: "leaks a goroutine", Path: &path, Line: &line}); err != nil {
+		t.Fatalf("add anchored note: %v", err)
+	}
+	if _, err := d.UpdateNote(first.ID, "rejected: flaky auth test"); err != nil {
+		t.Fatalf("update note: %v", err)
+	}
+
+	notes, err := d.ListNotes(cell.ID)
+	if err != nil {
+		t.Fatalf("list notes: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Body != "rejected: flaky auth test" {
+		t.Fatalf("unexpected notes %+v", notes)
+	}
+	if notes[1].Path == nil || *notes[1].Path != "main.go" || notes[1].Line == nil || *notes[1].Line != 12 {
+		t.Fatalf("expected anchor main.go:12, got %+v", notes[1])
+	}
+	if err := d.DeleteNote(first.ID); err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+	if _, err := d.UpdateNote(first.ID, "gone"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound updating a deleted note, got %v", err)
+	}
+}
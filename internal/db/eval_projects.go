@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+)
+
+// ReplaceEvalProjects swaps the per-subproject eval results stored for a cell.
+func (d *DB) ReplaceEvalProjects(cellID string, projects []EvalProject) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin eval projects tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM eval_projects WHERE cell_id = ?`, cellID); err != nil {
+		return fmt.Errorf("clear eval projects %s: %w", cellID, err)
+	}
+	for _, p := range projects {
+		_, err := tx.Exec(`
+INSERT INTO eval_projects (cell_id, dir, project_types, tests_passed, tests_failed, lint_errors, type_errors, eval_skipped)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`, cellID, p.Dir, p.ProjectTypes, p.TestsPassed, p.TestsFailed, p.LintErrors, p.TypeErrors, p.EvalSkipped)
+		if err != nil {
+			return fmt.Errorf("insert eval project %s %s: %w", cellID, p.Dir, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit eval projects tx: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) ListEvalProjects(cellID string) ([]EvalProject, error) {
+	rows, err := d.sql.Query(`
+SELECT cell_id, dir, project_types, tests_passed, tests_failed, lint_errors, type_errors, eval_skipped
+FROM eval_projects
+WHERE cell_id = ?
+ORDER BY dir ASC
+`, cellID)
+	if err != nil {
+		return nil, fmt.Errorf("list eval projects %s: %w", cellID, err)
+	}
+	defer rows.Close()
+
+	out := make([]EvalProject, 0)
+	for rows.Next() {
+		var p EvalProject
+		if err := rows.Scan(
+			&p.CellID,
+			&p.Dir,
+			&p.ProjectTypes,
+			&p.TestsPassed,
+			&p.TestsFailed,
+			&p.LintErrors,
+			&p.TypeErrors,
+			&p.EvalSkipped,
+		); err != nil {
+			return nil, fmt.Errorf("scan eval project: %w", err)
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate eval projects: %w", err)
+	}
+	return out, nil
+}
@@ -35,6 +35,14 @@ type Cell struct {
 	TypeErrors    *int
 	EvalSkipped   *string
 	EvalError     *string
+	EvalScope     *string
+	CoveragePct   *float64
+	Score         *float64
+	// Summary is a generated one-line description of the diff from the
+	// parent; it never replaces Message.
+	Summary *string
+	// Origin is the "<archive>:<cell>" ref a cell was imported from.
+	Origin *string
 }
 
 type Branch struct {
@@ -51,6 +59,77 @@ type ManifestEntry struct {
 	Size   int64
 }
 
+type EvalProject struct {
+	CellID       string
+	Dir          string
+	ProjectTypes string
+	TestsPassed  *int
+	TestsFailed  *int
+	LintErrors   *int
+	TypeErrors   *int
+	EvalSkipped  *string
+}
+
+type FileCoverage struct {
+	CellID  string
+	Path    string
+	Covered int
+	Total   int
+}
+
+type BenchSample struct {
+	CellID string
+	Name   string
+	Unit   string
+	Value  float64
+}
+
+type Comparison struct {
+	CellA            string
+	CellB            string
+	Model            string
+	PromptHash       string
+	Provider         string
+	Summary          string
+	Winner           string
+	WinnerReason     string
+	Highlights       []string
+	Risks            []string
+	FileNotes        []FileNote
+	PromptTokens     *int
+	CompletionTokens *int
+	CreatedAt        string
+}
+
+// FileNote is a model comment about one changed file in a comparison.
+type FileNote struct {
+	Path string `json:"path"`
+	Note string `json:"note"`
+}
+
+// Ranking is a stored leaderboard of several cells. Entries are ordered by
+// Position, which combines the model's order with the metric order.
+type Ranking struct {
+	ID               int64
+	Model            string
+	PromptHash       string
+	Provider         string
+	Summary          string
+	MetricBasis      string
+	PromptTokens     *int
+	CompletionTokens *int
+	CreatedAt        string
+	Entries          []RankingEntry
+}
+
+type RankingEntry struct {
+	CellID         string
+	Position       int
+	LLMPosition    int
+	MetricPosition *int
+	Rationale      string
+}
+
 type AgentRun struct {
 	RunID     string
 	Agent     string
@@ -129,6 +208,81 @@ func (d *DB) InsertCellWithManifestAndAdvanceBranch(cell Cell, entries []Manifes
 	return nil
 }
 
+// InsertImportedCell stores a cell and its manifest without moving any
+// branch head or the head_cell meta; importers place branches themselves.
+func (d *DB) InsertImportedCell(cell Cell, entries []ManifestEntry) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertCell(tx, cell); err != nil {
+		return err
+	}
+	if err := syncSequenceAllocatorTx(tx, cell.Sequence); err != nil {
+		return err
+	}
+	if err := insertManifest(tx, entries); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// InsertImportedBranch stores cells, oldest first, with their manifests and
+// creates branch at the last one, all in one transaction so a failure leaves
+// neither the cells nor the branch behind.
+func (d *DB) InsertImportedBranch(branch string, cells []Cell, manifests [][]ManifestEntry) error {
+	if len(cells) == 0 || len(cells) != len(manifests) {
+		return fmt.Errorf("import branch %s: expected one manifest per cell", branch)
+	}
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, cell := range cells {
+		if err := insertCell(tx, cell); err != nil {
+			return err
+		}
+		if err := syncSequenceAllocatorTx(tx, cell.Sequence); err != nil {
+			return err
+		}
+		if err := insertManifest(tx, manifests[i]); err != nil {
+			return err
+		}
+	}
+	head := cells[len(cells)-1]
+	if _, err := tx.Exec(
+		`INSERT INTO branches (name, head_cell_id, created_at) VALUES (?, ?, ?)`,
+		branch, head.ID, head.Timestamp,
+	); err != nil {
+		return fmt.Errorf("create branch %s: %w", branch, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// GetImportedCell returns the cell imported from origin on top of parentID
+// (nil for a root), if any.
+func (d *DB) GetImportedCell(origin string, parentID *string) (*Cell, error) {
+	row := d.sql.QueryRow(cellSelect+` WHERE origin = ? AND parent_id IS ? ORDER BY sequence ASC LIMIT 1`, origin, parentID)
+	cell, err := scanCell(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cell by origin %s: %w", origin, err)
+	}
+	return cell, nil
+}
+
 func insertCell(tx *sql.Tx, cell Cell) error {
 	evalReq := 0
 	if cell.EvalRequested {
@@ -144,8 +298,8 @@ INSERT INTO cells (
 	files_added, files_modified, files_removed, lines_added, lines_removed,
 	total_loc, loc_delta, total_files,
 	eval_requested, eval_ran, tests_passed, tests_failed, lint_errors, type_errors,
-	eval_skipped, eval_error
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	eval_skipped, eval_error, eval_scope, coverage_pct, score, summary, origin
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `,
 		cell.ID,
 		cell.Sequence,
@@ -172,10 +326,18 @@ INSERT INTO cells (
 		cell.TypeErrors,
 		cell.EvalSkipped,
 		cell.EvalError,
+		cell.EvalScope,
+		cell.CoveragePct,
+		cell.Score,
+		cell.Summary,
+		cell.Origin,
 	)
 	if err != nil {
 		return fmt.Errorf("insert cell: %w", err)
 	}
+	if cell.Tags != nil {
+		return insertCellTagsTx(tx, cell.ID, SplitTags(*cell.Tags))
+	}
 	return nil
 }
 
@@ -366,6 +528,48 @@ ORDER BY path ASC
 	return entries, nil
 }
 
+// ListPathHashes maps each cell containing path to that file's blob hash.
+func (d *DB) ListPathHashes(path string) (map[string]string, error) {
+	rows, err := d.sql.Query(`SELECT cell_id, hash FROM manifest_entries WHERE path = ?`, path)
+	if err != nil {
+		return nil, fmt.Errorf("list hashes for %s: %w", path, err)
+	}
+	defer rows.Close()
+	hashes := map[string]string{}
+	for rows.Next() {
+		var cellID, hash string
+		if err := rows.Scan(&cellID, &hash); err != nil {
+			return nil, fmt.Errorf("scan path hash: %w", err)
+		}
+		hashes[cellID] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate path hashes: %w", err)
+	}
+	return hashes, nil
+}
+
+// ListManifestHashes returns every blob hash referenced by any manifest.
+func (d *DB) ListManifestHashes() ([]string, error) {
+	rows, err := d.sql.Query(`SELECT DISTINCT hash FROM manifest_entries ORDER BY hash ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list manifest hashes: %w", err)
+	}
+	defer rows.Close()
+	hashes := make([]string, 0)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("scan manifest hash: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate manifest hashes: %w", err)
+	}
+	return hashes, nil
+}
+
 func (d *DB) GetMeta(key string) (string, error) {
 	var value string
 	err := d.sql.QueryRow(`SELECT value FROM meta WHERE key = ?`, key).Scan(&value)
@@ -476,11 +680,35 @@ WHERE id = ?
 	return nil
 }
 
+func (d *DB) SetCellEvalScope(id string, scope *string) error {
+	_, err := d.sql.Exec(`UPDATE cells SET eval_scope = ? WHERE id = ?`, scope, id)
+	if err != nil {
+		return fmt.Errorf("update cell eval scope %s: %w", id, err)
+	}
+	return nil
+}
+
+func (d *DB) SetCellScore(id string, score *float64) error {
+	_, err := d.sql.Exec(`UPDATE cells SET score = ? WHERE id = ?`, score, id)
+	if err != nil {
+		return fmt.Errorf("update cell score %s: %w", id, err)
+	}
+	return nil
+}
+
+func (d *DB) SetCellSummary(id string, summary *string) error {
+	_, err := d.sql.Exec(`UPDATE cells SET summary = ? WHERE id = ?`, summary, id)
+	if err != nil {
+		return fmt.Errorf("update cell summary %s: %w", id, err)
+	}
+	return nil
+}
+
 const cellSelect = `
 SELECT
 	id, sequence, parent_id, timestamp, message, source, agent, tags, branch,
 	files_added, files_modified, files_removed, lines_added, lines_removed,
 	total_loc, loc_delta, total_files,
 	eval_requested, eval_ran, tests_passed, tests_failed, lint_errors, type_errors,
-	eval_skipped, eval_error
+	eval_skipped, eval_error, eval_scope, coverage_pct, score, summary, origin
 FROM cells`
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Note is a timestamped review note on a cell, optionally anchored to a file
+// and line.
+type Note struct {
+	ID        int64   `json:"id"`
+	CellID    string  `json:"cell_id"`
+	Body      string  `json:"body"`
+	Path      *string `json:"path,omitempty"`
+	Line      *int    `json:"line,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+const noteSelect = `SELECT id, cell_id, body, path, line, created_at, updated_at FROM cell_notes`
+
+// AddNote appends a note and returns it with its ID and timestamps set.
+func (d *DB) AddNote(n Note) (*Note, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	n.CreatedAt = now
+	n.UpdatedAt = now
+	res, err := d.sql.Exec(`
+INSERT INTO cell_notes (cell_id, body, path, line, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+`, n.CellID, n.Body, n.Path, n.Line, n.CreatedAt, n.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("add note on %s: %w", n.CellID, err)
+	}
+	n.ID, err = res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read note id: %w", err)
+	}
+	return &n, nil
+}
+
+// ImportNotes copies notes onto cellID, keeping their timestamps.
+func (d *DB) ImportNotes(cellID string, notes []Note) error {
+	for _, n := range notes {
+		_, err := d.sql.Exec(`
+INSERT INTO cell_notes (cell_id, body, path, line, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+`, cellID, n.Body, n.Path, n.Line, n.CreatedAt, n.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("import note on %s: %w", cellID, err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) GetNote(id int64) (*Note, error) {
+	n, err := scanNote(d.sql.QueryRow(noteSelect+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get note %d: %w", id, err)
+	}
+	return n, nil
+}
+
+// UpdateNote replaces a note's body and bumps updated_at.
+func (d *DB) UpdateNote(id int64, body string) (*Note, error) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := d.sql.Exec(`UPDATE cell_notes SET body = ?, updated_at = ? WHERE id = ?`, body, now, id)
+	if err != nil {
+		return nil, fmt.Errorf("update note %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+	return d.GetNote(id)
+}
+
+func (d *DB) DeleteNote(id int64) error {
+	res, err := d.sql.Exec(`DELETE FROM cell_notes WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete note %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListNotes returns a cell's notes oldest first.
+func (d *DB) ListNotes(cellID string) ([]Note, error) {
+	rows, err := d.sql.Query(noteSelect+` WHERE cell_id = ? ORDER BY id ASC`, cellID)
+	if err != nil {
+		return nil, fmt.Errorf("list notes %s: %w", cellID, err)
+	}
+	defer rows.Close()
+	out := make([]Note, 0)
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan note: %w", err)
+		}
+		out = append(out, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate notes: %w", err)
+	}
+	return out, nil
+}
+
+func scanNote(row cellScanner) (*Note, error) {
+	var n Note
+	var path sql.NullString
+	var line sql.NullInt64
+	if err := row.Scan(&n.ID, &n.CellID, &n.Body, &path, &line, &n.CreatedAt, &n.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if path.Valid {
+		n.Path = &path.String
+	}
+	if line.Valid {
+		v := int(line.Int64)
+		n.Line = &v
+	}
+	return &n, nil
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const rankingSelect = `
+SELECT id, model, prompt_hash, provider, summary, metric_basis,
+	prompt_tokens, completion_tokens, created_at
+FROM rankings`
+
+// SaveRanking stores a ranking and its entries, replacing any earlier ranking
+// for the same (model, prompt_hash). It returns the new ranking ID.
+func (d *DB) SaveRanking(r Ranking) (int64, error) {
+	if r.CreatedAt == "" {
+		r.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin ranking tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rankings WHERE model = ? AND prompt_hash = ?`, r.Model, r.PromptHash); err != nil {
+		return 0, fmt.Errorf("clear ranking %s: %w", r.PromptHash, err)
+	}
+	res, err := tx.Exec(`
+INSERT INTO rankings (
+	model, prompt_hash, provider, summary, metric_basis,
+	prompt_tokens, completion_tokens, created_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`, r.Model, r.PromptHash, r.Provider, r.Summary, r.MetricBasis, r.PromptTokens, r.CompletionTokens, r.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("insert ranking: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("ranking id: %w", err)
+	}
+	for _, e := range r.Entries {
+		_, err := tx.Exec(`
+INSERT INTO ranking_entries (ranking_id, cell_id, position, llm_position, metric_position, rationale)
+VALUES (?, ?, ?, ?, ?, ?)
+`, id, e.CellID, e.Position, e.LLMPosition, e.MetricPosition, e.Rationale)
+		if err != nil {
+			return 0, fmt.Errorf("insert ranking entry %s: %w", e.CellID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit ranking tx: %w", err)
+	}
+	return id, nil
+}
+
+func (d *DB) GetRankingByPrompt(model, promptHash string) (*Ranking, error) {
+	rankings, err := d.queryRankings(rankingSelect+`
+WHERE model = ? AND prompt_hash = ?
+`, model, promptHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(rankings) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rankings[0], nil
+}
+
+// ListRankings returns the most recent rankings, newest first. A limit of
+// zero or less returns all of them.
+func (d *DB) ListRankings(limit int) ([]Ranking, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	return d.queryRankings(rankingSelect+`
+ORDER BY created_at DESC, id DESC
+LIMIT ?
+`, limit)
+}
+
+// ListRankingsForCell returns rankings that include the cell, newest first.
+func (d *DB) ListRankingsForCell(cellID string) ([]Ranking, error) {
+	return d.queryRankings(rankingSelect+`
+WHERE id IN (SELECT ranking_id FROM ranking_entries WHERE cell_id = ?)
+ORDER BY created_at DESC, id DESC
+`, cellID)
+}
+
+func (d *DB) queryRankings(query string, args ...any) ([]Ranking, error) {
+	rows, err := d.sql.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list rankings: %w", err)
+	}
+	out := make([]Ranking, 0)
+	for rows.Next() {
+		var r Ranking
+		if err := rows.Scan(
+			&r.ID,
+			&r.Model,
+			&r.PromptHash,
+			&r.Provider,
+			&r.Summary,
+			&r.MetricBasis,
+			&r.PromptTokens,
+			&r.CompletionTokens,
+			&r.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan ranking: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("iterate rankings: %w", err)
+	}
+	rows.Close()
+
+	for i := range out {
+		entries, err := d.listRankingEntries(out[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Entries = entries
+	}
+	return out, nil
+}
+
+func (d *DB) listRankingEntries(rankingID int64) ([]RankingEntry, error) {
+	rows, err := d.sql.Query(`
+SELECT cell_id, position, llm_position, metric_position, rationale
+FROM ranking_entries
+WHERE ranking_id = ?
+ORDER BY position ASC
+`, rankingID)
+	if err != nil {
+		return nil, fmt.Errorf("list ranking entries %d: %w", rankingID, err)
+	}
+	defer rows.Close()
+
+	out := make([]RankingEntry, 0)
+	for rows.Next() {
+		var e RankingEntry
+		var metric sql.NullInt64
+		if err := rows.Scan(&e.CellID, &e.Position, &e.LLMPosition, &metric, &e.Rationale); err != nil {
+			return nil, fmt.Errorf("scan ranking entry: %w", err)
+		}
+		if metric.Valid {
+			v := int(metric.Int64)
+			e.MetricPosition = &v
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ranking entries: %w", err)
+	}
+	return out, nil
+}
@@ -34,6 +34,11 @@ func scanCell(row cellScanner) (*Cell, error) {
 		&cell.TypeErrors,
 		&cell.EvalSkipped,
 		&cell.EvalError,
+		&cell.EvalScope,
+		&cell.CoveragePct,
+		&cell.Score,
+		&cell.Summary,
+		&cell.Origin,
 	); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TagCount struct {
+	Tag   string
+	Cells int
+}
+
+// Bookmark is a named pointer to a cell, usable as a revision.
+type Bookmark struct {
+	Name      string
+	CellID    string
+	CreatedAt string
+}
+
+// SplitTags parses a comma-separated tag list, dropping blanks and repeats.
+func SplitTags(csv string) []string {
+	seen := map[string]bool{}
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(csv, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// migrateCellTagsTx copies the legacy cells.tags CSV column into cell_tags
+// once per database.
+func migrateCellTagsTx(tx *sql.Tx) error {
+	var done string
+	err := tx.QueryRow(`SELECT value FROM meta WHERE key = 'cell_tags_migrated'`).Scan(&done)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("read cell_tags migration marker: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT id, tags FROM cells WHERE tags IS NOT NULL AND TRIM(tags) != ''`)
+	if err != nil {
+		return fmt.Errorf("read legacy tags: %w", err)
+	}
+	legacy := map[string]string{}
+	for rows.Next() {
+		var id, tags string
+		if err := rows.Scan(&id, &tags); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan legacy tags: %w", err)
+		}
+		legacy[id] = tags
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate legacy tags: %w", err)
+	}
+	for id, tags := range legacy {
+		if err := insertCellTagsTx(tx, id, SplitTags(tags)); err != nil {
+			return err
+		}
+	}
+	return setMetaTx(tx, "cell_tags_migrated", "1")
+}
+
+func insertCellTagsTx(tx *sql.Tx, cellID string, tags []string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO cell_tags (cell_id, tag, created_at) VALUES (?, ?, ?)`, cellID, tag, now); err != nil {
+			return fmt.Errorf("insert tag %s on %s: %w", tag, cellID, err)
+		}
+	}
+	return nil
+}
+
+// syncTagsColumnTx rewrites the cells.tags CSV from cell_tags so cell
+// listings keep showing current tags.
+func syncTagsColumnTx(tx *sql.Tx, cellID string) error {
+	_, err := tx.Exec(`
+UPDATE cells SET tags = (
+	SELECT group_concat(tag, ',') FROM (SELECT tag FROM cell_tags WHERE cell_id = ? ORDER BY tag)
+) WHERE id = ?
+`, cellID, cellID)
+	if err != nil {
+		return fmt.Errorf("sync tags column %s: %w", cellID, err)
+	}
+	return nil
+}
+
+// AddCellTags attaches tags to a cell; tags it already has are ignored.
+func (d *DB) AddCellTags(cellID string, tags []string) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertCellTagsTx(tx, cellID, tags); err != nil {
+		return err
+	}
+	if err := syncTagsColumnTx(tx, cellID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// RemoveCellTags detaches tags from a cell and returns how many it had.
+func (d *DB) RemoveCellTags(cellID string, tags []string) (int, error) {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed := 0
+	for _, tag := range tags {
+		res, err := tx.Exec(`DELETE FROM cell_tags WHERE cell_id = ? AND tag = ?`, cellID, tag)
+		if err != nil {
+			return 0, fmt.Errorf("remove tag %s from %s: %w", tag, cellID, err)
+		}
+		n, _ := res.RowsAffected()
+		removed += int(n)
+	}
+	if err := syncTagsColumnTx(tx, cellID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return removed, nil
+}
+
+func (d *DB) ListCellTags(cellID string) ([]string, error) {
+	return d.queryStrings(`SELECT tag FROM cell_tags WHERE cell_id = ? ORDER BY tag ASC`, cellID)
+}
+
+// ListCellIDsWithTag returns the cells carrying tag, newest first.
+func (d *DB) ListCellIDsWithTag(tag string) ([]string, error) {
+	return d.queryStrings(`
+SELECT c.id FROM cell_tags t JOIN cells c ON c.id = t.cell_id
+WHERE t.tag = ?
+ORDER BY c.sequence DESC
+`, tag)
+}
+
+func (d *DB) ListTagCounts() ([]TagCount, error) {
+	rows, err := d.sql.Query(`SELECT tag, COUNT(*) FROM cell_tags GROUP BY tag ORDER BY tag ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+	out := make([]TagCount, 0)
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Cells); err != nil {
+			return nil, fmt.Errorf("scan tag: %w", err)
+		}
+		out = append(out, tc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tags: %w", err)
+	}
+	return out, nil
+}
+
+// SetBookmark points name at cellID, creating or moving it.
+func (d *DB) SetBookmark(name, cellID string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := d.sql.Exec(`
+INSERT INTO bookmarks (name, cell_id, created_at) VALUES (?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET cell_id = excluded.cell_id, created_at = excluded.created_at
+`, name, cellID, now)
+	if err != nil {
+		return fmt.Errorf("set bookmark %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *DB) GetBookmark(name string) (*Bookmark, error) {
+	var b Bookmark
+	err := d.sql.QueryRow(`SELECT name, cell_id, created_at FROM bookmarks WHERE name = ?`, name).Scan(&b.Name, &b.CellID, &b.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get bookmark %s: %w", name, err)
+	}
+	return &b, nil
+}
+
+func (d *DB) DeleteBookmark(name string) error {
+	res, err := d.sql.Exec(`DELETE FROM bookmarks WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("delete bookmark %s: %w", name, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListBookmarks returns bookmarks by name, optionally only those on cellID.
+func (d *DB) ListBookmarks(cellID string) ([]Bookmark, error) {
+	query := `SELECT name, cell_id, created_at FROM bookmarks`
+	args := []any{}
+	if cellID != "" {
+		query += ` WHERE cell_id = ?`
+		args = append(args, cellID)
+	}
+	rows, err := d.sql.Query(query+` ORDER BY name ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list bookmarks: %w", err)
+	}
+	defer rows.Close()
+	out := make([]Bookmark, 0)
+	for rows.Next() {
+		var b Bookmark
+		if err := rows.Scan(&b.Name, &b.CellID, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan bookmark: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate bookmarks: %w", err)
+	}
+	return out, nil
+}
+
+func (d *DB) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := d.sql.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+	out := make([]string, 0)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,349 @@
+package diff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the change to one file parsed from a unified or git diff.
+// OldPath is empty for created files and NewPath is empty for deleted ones.
+type FilePatch struct {
+	OldPath string
+	NewPath string
+	// NewMode is the git mode the file should end up with, or "" to keep
+	// the existing mode.
+	NewMode string
+	Hunks   []Hunk
+	// Binary holds the full new content from a GIT binary patch literal.
+	Binary    []byte
+	HasBinary bool
+}
+
+// Hunk is one "@@" section of a text patch.
+type Hunk struct {
+	OldStart int
+	OldCount int
+	Lines    []HunkLine
+}
+
+// HunkLine is a context (' '), removed ('-'), or added ('+') line. Text keeps
+// its "\n" unless the patch marked it as missing.
+type HunkLine struct {
+	Op   byte
+	Text string
+}
+
+// ParsePatch parses every file section of a git-style or plain unified diff.
+// Text outside file sections, such as mail headers, is ignored.
+func ParsePatch(text string) ([]FilePatch, error) {
+	lines := splitLinesKeepEnds(text)
+	var patches []FilePatch
+	var current *FilePatch
+	flush := func() {
+		if current != nil {
+			patches = append(patches, *current)
+			current = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			oldPath, newPath, ok := parseGitDiffHeader(strings.TrimPrefix(line, "diff --git "))
+			if !ok {
+				return nil, fmt.Errorf("line %d: malformed diff header %q", i+1, line)
+			}
+			current = &FilePatch{OldPath: oldPath, NewPath: newPath}
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || len(current.Hunks) > 0 {
+				flush()
+				current = &FilePatch{}
+			}
+			current.OldPath = patchPath(strings.TrimPrefix(line, "--- "))
+			current.NewPath = patchPath(strings.TrimPrefix(strings.TrimRight(lines[i+1], "\r\n"), "+++ "))
+			i++
+		case current == nil:
+		case strings.HasPrefix(line, "new file mode "):
+			current.OldPath = ""
+			current.NewMode = strings.TrimPrefix(line, "new file mode ")
+		case strings.HasPrefix(line, "deleted file mode "):
+			current.NewPath = ""
+		case strings.HasPrefix(line, "new mode "):
+			current.NewMode = strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "rename from "):
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			current.NewPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "):
+			return nil, fmt.Errorf("line %d: binary change to %s has no data; export with full binary patches", i+1, current.NewPath)
+		case line == "GIT binary patch":
+			data, next, err := parseBinaryLiteral(lines, i+1)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			current.Binary, current.HasBinary = data, true
+			// Skip the reverse block that follows the forward one.
+			for i = next; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			}
+		case strings.HasPrefix(line, "@@ "):
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current.Hunks = append(current.Hunks, hunk)
+			i = next - 1
+		}
+	}
+	flush()
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file changes found in patch")
+	}
+	return patches, nil
+}
+
+// parseGitDiffHeader splits "a/<old> b/<new>". Paths with spaces are
+// resolved by requiring both sides to name the same file, as git does for
+// non-renames.
+func parseGitDiffHeader(rest string) (string, string, bool) {
+	if !strings.HasPrefix(rest, "a/") {
+		return "", "", false
+	}
+	if half := (len(rest) - 1) / 2; len(rest)%2 == 1 && rest[half] == ' ' && rest[:half][2:] == rest[half+1:][2:] {
+		return rest[2:half], rest[half+3:], true
+	}
+	idx := strings.Index(rest, " b/")
+	if idx < 0 {
+		return "", "", false
+	}
+	return rest[2:idx], rest[idx+3:], true
+}
+
+// patchPath strips the a/ or b/ prefix and any trailing timestamp from a
+// ---/+++ line; /dev/null becomes "".
+func patchPath(raw string) string {
+	if tab := strings.IndexByte(raw, '\t'); tab >= 0 {
+		raw = raw[:tab]
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(raw, "a/") || strings.HasPrefix(raw, "b/") {
+		return raw[2:]
+	}
+	return raw
+}
+
+func parseHunk(lines []string, i int) (Hunk, int, error) {
+	header := strings.TrimRight(lines[i], "\r\n")
+	var hunk Hunk
+	var newCount int
+	fields := strings.Fields(header)
+	if len(fields) < 4 || fields[3] != "@@" && !strings.HasPrefix(fields[3], "@@") {
+		return hunk, 0, fmt.Errorf("line %d: malformed hunk header %q", i+1, header)
+	}
+	var err error
+	if hunk.OldStart, hunk.OldCount, err = parseHunkRange(fields[1], "-"); err != nil {
+		return hunk, 0, fmt.Errorf("line %d: %w", i+1, err)
+	}
+	if _, newCount, err = parseHunkRange(fields[2], "+"); err != nil {
+		return hunk, 0, fmt.Errorf("line %d: %w", i+1, err)
+	}
+
+	oldSeen, newSeen := 0, 0
+	j := i + 1
+	for ; j < len(lines) && (oldSeen < hunk.OldCount || newSeen < newCount); j++ {
+		line := lines[j]
+		if line == "" {
+			continue
+		}
+		op, text := line[0], line[1:]
+		if op == '\n' || strings.HasPrefix(line, "\r\n") {
+			// Some mailers strip the space from empty context lines.
+			op, text = ' ', line
+		}
+		switch op {
+		case ' ':
+			oldSeen++
+			newSeen++
+		case '-':
+			oldSeen++
+		case '+':
+			newSeen++
+		case '\\':
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].Text = strings.TrimSuffix(hunk.Lines[n-1].Text, "\n")
+			}
+			continue
+		default:
+			return hunk, 0, fmt.Errorf("line %d: unexpected %q in hunk", j+1, strings.TrimRight(line, "\n"))
+		}
+		hunk.Lines = append(hunk.Lines, HunkLine{Op: op, Text: text})
+	}
+	if oldSeen != hunk.OldCount || newSeen != newCount {
+		return hunk, 0, fmt.Errorf("line %d: hunk is truncated", i+1)
+	}
+	if j < len(lines) && strings.HasPrefix(lines[j], "\\") {
+		last := &hunk.Lines[len(hunk.Lines)-1]
+		last.Text = strings.TrimSuffix(last.Text, "\n")
+		j++
+	}
+	return hunk, j, nil
+}
+
+func parseHunkRange(field, sign string) (int, int, error) {
+	if !strings.HasPrefix(field, sign) {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+	}
+	start, count, hasCount := strings.Cut(field[1:], ",")
+	s, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+	}
+	if !hasCount {
+		return s, 1, nil
+	}
+	c, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", field)
+	}
+	return s, c, nil
+}
+
+// parseBinaryLiteral decodes the forward "literal" block of a GIT binary
+// patch starting at lines[i] and returns the index just past it.
+func parseBinaryLiteral(lines []string, i int) ([]byte, int, error) {
+	if i >= len(lines) {
+		return nil, 0, fmt.Errorf("binary patch is truncated")
+	}
+	kind, sizeText, _ := strings.Cut(strings.TrimSpace(lines[i]), " ")
+	if kind != "literal" {
+		return nil, 0, fmt.Errorf("binary %s patches are not supported", kind)
+	}
+	size, err := strconv.Atoi(sizeText)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed binary patch size %q", sizeText)
+	}
+	var compressed []byte
+	j := i + 1
+	for ; j < len(lines); j++ {
+		line := strings.TrimRight(lines[j], "\r\n")
+		if line == "" {
+			break
+		}
+		n := 0
+		switch c := line[0]; {
+		case c >= 'A' && c <= 'Z':
+			n = int(c-'A') + 1
+		case c >= 'a' && c <= 'z':
+			n = int(c-'a') + 27
+		default:
+			return nil, 0, fmt.Errorf("malformed binary patch line %q", line)
+		}
+		decoded, err := decodeBase85(line[1:])
+		if err != nil || len(decoded) < n {
+			return nil, 0, fmt.Errorf("malformed binary patch line %q", line)
+		}
+		compressed = append(compressed, decoded[:n]...)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, 0, fmt.Errorf("inflate binary patch: %w", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("inflate binary patch: %w", err)
+	}
+	if len(data) != size {
+		return nil, 0, fmt.Errorf("binary patch holds %d bytes, expected %d", len(data), size)
+	}
+	return data, j + 1, nil
+}
+
+func decodeBase85(text string) ([]byte, error) {
+	if len(text)%5 != 0 {
+		return nil, fmt.Errorf("base85 length %d is not a multiple of 5", len(text))
+	}
+	out := make([]byte, 0, len(text)/5*4)
+	for i := 0; i < len(text); i += 5 {
+		var acc uint64
+		for j := 0; j < 5; j++ {
+			idx := strings.IndexByte(base85Alphabet, text[i+j])
+			if idx < 0 {
+				return nil, fmt.Errorf("invalid base85 character %q", text[i+j])
+			}
+			acc = acc*85 + uint64(idx)
+		}
+		if acc > 0xffffffff {
+			return nil, fmt.Errorf("base85 group overflows")
+		}
+		out = append(out, byte(acc>>24), byte(acc>>16), byte(acc>>8), byte(acc))
+	}
+	return out, nil
+}
+
+// Apply applies p's hunks to old. Each hunk must match at its stated line or,
+// failing that, at the nearest offset where its context and removed lines
+// appear.
+func (p FilePatch) Apply(old []byte) ([]byte, error) {
+	if p.HasBinary {
+		return p.Binary, nil
+	}
+	lines := splitLinesKeepEnds(string(old))
+	var out []string
+	pos := 0
+	for n, hunk := range p.Hunks {
+		var want, repl []string
+		for _, l := range hunk.Lines {
+			if l.Op != '+' {
+				want = append(want, l.Text)
+			}
+			if l.Op != '-' {
+				repl = append(repl, l.Text)
+			}
+		}
+		expected := hunk.OldStart - 1
+		if hunk.OldCount == 0 {
+			expected = hunk.OldStart
+		}
+		at := findHunk(lines, want, max(expected, pos), pos)
+		if at < 0 {
+			return nil, fmt.Errorf("hunk %d (line %d) does not apply", n+1, hunk.OldStart)
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, repl...)
+		pos = at + len(want)
+	}
+	out = append(out, lines[pos:]...)
+	return []byte(strings.Join(out, "")), nil
+}
+
+// findHunk returns where want matches lines, searching outward from
+// expected but never before floor, or -1.
+func findHunk(lines, want []string, expected, floor int) int {
+	matches := func(at int) bool {
+		if at < floor || at+len(want) > len(lines) {
+			return false
+		}
+		for k, w := range want {
+			if lines[at+k] != w {
+				return false
+			}
+		}
+		return true
+	}
+	for offset := 0; expected-offset >= floor || expected+offset <= len(lines); offset++ {
+		if matches(expected + offset) {
+			return expected + offset
+		}
+		if offset > 0 && matches(expected-offset) {
+			return expected - offset
+		}
+	}
+	return -1
+}
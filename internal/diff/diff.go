@@ -106,6 +106,28 @@ func ExpandedUnifiedDiff(filename, oldContent, newContent string, contextLines i
 	return out.String()
 }
 
+// MatchLines maps each new line to the index of the old line it was kept
+// from, or -1 for lines the change added.
+func MatchLines(oldLines, newLines []string) []int {
+	mapping := make([]int, len(newLines))
+	oldPos, newPos := 0, 0
+	keep := func(newEnd int) {
+		for ; newPos < newEnd; newPos++ {
+			mapping[newPos] = oldPos
+			oldPos++
+		}
+	}
+	for _, c := range computeChanges(oldLines, newLines) {
+		keep(c.newStart)
+		for ; newPos < c.newEnd; newPos++ {
+			mapping[newPos] = -1
+		}
+		oldPos = c.oldEnd
+	}
+	keep(len(newLines))
+	return mapping
+}
+
 type change struct {
 	oldStart int
 	oldEnd   int
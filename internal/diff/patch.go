@@ -0,0 +1,211 @@
+package diff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GitFile is one side of a file in a git patch. A nil *GitFile means the file
+// does not exist on that side.
+type GitFile struct {
+	Mode int
+	Data []byte
+}
+
+const (
+	gitNullHash      = "0000000000000000000000000000000000000000"
+	gitContextLines  = 3
+	gitNoNewlineNote = "\\ No newline at end of file\n"
+	base85Alphabet   = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+)
+
+// GitMode renders a file mode the way git records it.
+func GitMode(mode int) string {
+	if mode&0o111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// GitBlobHash returns the object ID git assigns to data as a blob.
+func GitBlobHash(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GitPatch returns a `git apply`-compatible diff for one path, with mode
+// lines and full index hashes. Binary files are written as a GIT binary
+// patch with literal forward and reverse data. It returns "" when nothing
+// changed.
+func GitPatch(path string, from, to *GitFile, binary bool) string {
+	if from == nil && to == nil {
+		return ""
+	}
+	if from != nil && to != nil && from.Mode == to.Mode && bytes.Equal(from.Data, to.Data) {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "diff --git a/%s b/%s\n", path, path)
+	oldHash, newHash := gitNullHash, gitNullHash
+	var oldData, newData []byte
+	switch {
+	case from == nil:
+		fmt.Fprintf(&out, "new file mode %s\n", GitMode(to.Mode))
+	case to == nil:
+		fmt.Fprintf(&out, "deleted file mode %s\n", GitMode(from.Mode))
+	case GitMode(from.Mode) != GitMode(to.Mode):
+		fmt.Fprintf(&out, "old mode %s\nnew mode %s\n", GitMode(from.Mode), GitMode(to.Mode))
+	}
+	if from != nil {
+		oldData, oldHash = from.Data, GitBlobHash(from.Data)
+	}
+	if to != nil {
+		newData, newHash = to.Data, GitBlobHash(to.Data)
+	}
+	if oldHash == newHash {
+		// Mode-only change.
+		return out.String()
+	}
+	fmt.Fprintf(&out, "index %s..%s", oldHash, newHash)
+	if from != nil && to != nil && GitMode(from.Mode) == GitMode(to.Mode) {
+		fmt.Fprintf(&out, " %s", GitMode(to.Mode))
+	}
+	out.WriteString("\n")
+
+	if binary {
+		out.WriteString("GIT binary patch\n")
+		writeBinaryLiteral(&out, newData)
+		writeBinaryLiteral(&out, oldData)
+		return out.String()
+	}
+	if len(oldData) == 0 && len(newData) == 0 {
+		// Empty files get no hunks, so git omits the file header too.
+		return out.String()
+	}
+	if from == nil {
+		out.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&out, "--- a/%s\n", path)
+	}
+	if to == nil {
+		out.WriteString("+++ /dev/null\n")
+	} else {
+		fmt.Fprintf(&out, "+++ b/%s\n", path)
+	}
+	writeGitHunks(&out, splitLinesKeepEnds(string(oldData)), splitLinesKeepEnds(string(newData)))
+	return out.String()
+}
+
+// splitLinesKeepEnds splits text into lines that keep their "\n"; only the
+// last line may lack one.
+func splitLinesKeepEnds(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeGitHunks writes unified hunks with git's context width, merging
+// changes whose context would overlap.
+func writeGitHunks(out *strings.Builder, oldLines, newLines []string) {
+	changes := computeChanges(oldLines, newLines)
+	for start := 0; start < len(changes); {
+		end := start + 1
+		for end < len(changes) && changes[end].oldStart-changes[end-1].oldEnd <= 2*gitContextLines {
+			end++
+		}
+		first, last := changes[start], changes[end-1]
+		oldFrom := max(0, first.oldStart-gitContextLines)
+		oldTo := min(len(oldLines), last.oldEnd+gitContextLines)
+		newFrom := first.newStart - (first.oldStart - oldFrom)
+		newTo := last.newEnd + (oldTo - last.oldEnd)
+		fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldFrom, oldTo-oldFrom), hunkRange(newFrom, newTo-newFrom))
+
+		oldPos := oldFrom
+		for _, c := range changes[start:end] {
+			for ; oldPos < c.oldStart; oldPos++ {
+				writeHunkLine(out, ' ', oldLines[oldPos])
+			}
+			for k := c.oldStart; k < c.oldEnd; k++ {
+				writeHunkLine(out, '-', oldLines[k])
+			}
+			for k := c.newStart; k < c.newEnd; k++ {
+				writeHunkLine(out, '+', newLines[k])
+			}
+			oldPos = c.oldEnd
+		}
+		for ; oldPos < oldTo; oldPos++ {
+			writeHunkLine(out, ' ', oldLines[oldPos])
+		}
+		start = end
+	}
+}
+
+// hunkRange formats a hunk side; an empty side names the line before it.
+func hunkRange(from, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
+
+func writeHunkLine(out *strings.Builder, prefix byte, line string) {
+	out.WriteByte(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n" + gitNoNewlineNote)
+	}
+}
+
+func writeBinaryLiteral(out *strings.Builder, data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+	fmt.Fprintf(out, "literal %d\n", len(data))
+	raw := compressed.Bytes()
+	for len(raw) > 0 {
+		n := min(52, len(raw))
+		if n <= 26 {
+			out.WriteByte(byte('A' + n - 1))
+		} else {
+			out.WriteByte(byte('a' + n - 27))
+		}
+		out.WriteString(encodeBase85(raw[:n]))
+		out.WriteString("\n")
+		raw = raw[n:]
+	}
+	out.WriteString("\n")
+}
+
+// encodeBase85 encodes data in git's base85 flavour, padding to 4 bytes.
+func encodeBase85(data []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(data); i += 4 {
+		var acc uint32
+		for j := 0; j < 4; j++ {
+			acc <<= 8
+			if i+j < len(data) {
+				acc |= uint32(data[i+j])
+			}
+		}
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = base85Alphabet[acc%85]
+			acc /= 85
+		}
+		out.Write(chunk[:])
+	}
+	return out.String()
+}
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitPatchRoundTripsThroughApply(t *testing.T) {
+	var old, updated strings.Builder
+	for i := 1; i <= 30; i++ {
+		line := strings.Repeat("x", i%7) + "\n"
+		old.WriteString(line)
+		switch i {
+		case 4:
+			updated.WriteString("changed near the top\n")
+		case 20, 21:
+		default:
+			updated.WriteString(line)
+		}
+	}
+	updated.WriteString("no trailing newline")
+	binOld := []byte{0x00, 0x01, 0x02, 0xff}
+	binNew := bytes.Repeat([]byte{0x00, 0x7f, 0xfe}, 40)
+
+	files := []struct {
+		path     string
+		from, to *GitFile
+		binary   bool
+	}{
+		{"text.txt", &GitFile{Mode: 0o644, Data: []byte(old.String())}, &GitFile{Mode: 0o644, Data: []byte(updated.String())}, false},
+		{"new.sh", nil, &GitFile{Mode: 0o755, Data: []byte("#!/bin/sh\necho hi\n")}, false},
+		{"gone.txt", &GitFile{Mode: 0o644, Data: []byte("bye\n")}, nil, false},
+		{"image.bin", &GitFile{Mode: 0o644, Data: binOld}, &GitFile{Mode: 0o644, Data: binNew}, true},
+	}
+	var patch strings.Builder
+	for _, f := range files {
+		patch.WriteString(GitPatch(f.path, f.from, f.to, f.binary))
+	}
+	if !strings.Contains(patch.String(), "new file mode 100755\n") || !strings.Contains(patch.String(), "GIT binary patch\nliteral 120\n") {
+		t.Fatalf("missing git headers in patch:\n%s", patch.String())
+	}
+
+	parsed, err := ParsePatch(patch.String())
+	if err != nil {
+		t.Fatalf("parse patch: %v", err)
+	}
+	if len(parsed) != len(files) {
+		t.Fatalf("expected %d file patches, got %d", len(files), len(parsed))
+	}
+	for i, f := range files {
+		p := parsed[i]
+		var from []byte
+		if f.from != nil {
+			from = f.from.Data
+		}
+		got, err := p.Apply(from)
+		if err != nil {
+			t.Fatalf("apply %s: %v", f.path, err)
+		}
+		if f.to == nil {
+			if p.NewPath != "" {
+				t.Fatalf("expected %s to be deleted, got new path %q", f.path, p.NewPath)
+			}
+			continue
+		}
+		if !bytes.Equal(got, f.to.Data) {
+			t.Fatalf("apply %s produced %q, want %q", f.path, got, f.to.Data)
+		}
+	}
+	if parsed[1].OldPath != "" || parsed[1].NewMode != "100755" {
+		t.Fatalf("unexpected new file patch %+v", parsed[1])
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return
+	}
+	dir := t.TempDir()
+	for _, f := range files {
+		if f.from != nil {
+			if err := os.WriteFile(filepath.Join(dir, f.path), f.from.Data, 0o644); err != nil {
+				t.Fatalf("write %s: %v", f.path, err)
+			}
+		}
+	}
+	cmd := exec.Command("git", "apply", "--check", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply --check rejected patch: %v\n%s\n%s", err, out, patch.String())
+	}
+}
+
+func TestApplyFindsOffsetHunks(t *testing.T) {
+	patches, err := ParsePatch("--- a/notes.txt\t2026-01-01\n+++ b/notes.txt\n@@ -1,2 +1,2 @@\n alpha\n-beta\n+gamma\n")
+	if err != nil {
+		t.Fatalf("parse plain unified diff: %v", err)
+	}
+	got, err := patches[0].Apply([]byte("header\nalpha\nbeta\n"))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if string(got) != "header\nalpha\ngamma\n" {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if _, err := patches[0].Apply([]byte("alpha\ndelta\n")); err == nil {
+		t.Fatalf("expected mismatched hunk to fail")
+	}
+}
@@ -0,0 +1,44 @@
+package eval
+
+import (
+	"context"
+	"os"
+
+	"github.com/prit3010/converge/internal/bench"
+)
+
+// MetricsFileEnv is set for bench commands to a path where they may write a
+// JSON metrics file (see bench.ParseMetricsJSON).
+const MetricsFileEnv = "CONVERGE_METRICS_FILE"
+
+func (r *Runner) runBenchmarks(ctx context.Context, projectDir string, res *Result) {
+	for _, command := range normalizeCommandList(r.policy.Bench) {
+		metricsPath, cleanup, err := tempFilePath("converge-metrics-*.json")
+		if err != nil {
+			res.Skipped = append(res.Skipped, "bench:"+command)
+			continue
+		}
+		out, runErr := runShellCmdEnv(ctx, projectDir, command, []string{MetricsFileEnv + "=" + metricsPath})
+		if isMissingShellCommand(runErr) {
+			cleanup()
+			res.Skipped = append(res.Skipped, "bench:"+command)
+			continue
+		}
+
+		samples := bench.ParseGoBench(out)
+		if data, readErr := os.ReadFile(metricsPath); readErr == nil && len(data) > 0 {
+			if metrics, parseErr := bench.ParseMetricsJSON(data); parseErr == nil {
+				samples = append(samples, metrics...)
+			} else {
+				res.Skipped = append(res.Skipped, "bench-metrics:"+command)
+			}
+		}
+		cleanup()
+
+		if runErr != nil && len(samples) == 0 {
+			res.Skipped = append(res.Skipped, "bench:"+command)
+			continue
+		}
+		res.Bench = append(res.Bench, samples...)
+	}
+}
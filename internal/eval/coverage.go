@@ -0,0 +1,245 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coverage is statement/line coverage collected from a test run. File paths
+// are slash-separated and relative to the eval root.
+type Coverage struct {
+	Covered int
+	Total   int
+	Files   []FileCoverage
+}
+
+type FileCoverage struct {
+	Path    string
+	Covered int
+	Total   int
+}
+
+func (c Coverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Covered) * 100 / float64(c.Total)
+}
+
+func (f FileCoverage) Percent() float64 {
+	if f.Total == 0 {
+		return 0
+	}
+	return float64(f.Covered) * 100 / float64(f.Total)
+}
+
+// CoveragePctPtr returns the total coverage percentage, or nil when no
+// coverage was collected.
+func (r Result) CoveragePctPtr() *float64 {
+	if r.Coverage == nil {
+		return nil
+	}
+	v := r.Coverage.Percent()
+	return &v
+}
+
+type checkOptions struct {
+	coverage bool
+}
+
+func (r *Runner) checkOptions() checkOptions {
+	return checkOptions{coverage: r.policy.Coverage}
+}
+
+// addCoverage merges files into the result's coverage, prefixing each path
+// with prefix (a project dir relative to the eval root).
+func (r *Result) addCoverage(prefix string, files []FileCoverage) {
+	if len(files) == 0 {
+		return
+	}
+	if r.Coverage == nil {
+		r.Coverage = &Coverage{}
+	}
+	for _, f := range files {
+		if prefix != "" && prefix != "." {
+			f.Path = path.Join(prefix, f.Path)
+		}
+		r.Coverage.Covered += f.Covered
+		r.Coverage.Total += f.Total
+		r.Coverage.Files = append(r.Coverage.Files, f)
+	}
+	sort.Slice(r.Coverage.Files, func(i, j int) bool { return r.Coverage.Files[i].Path < r.Coverage.Files[j].Path })
+}
+
+func tempFilePath(pattern string) (string, func(), error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", func() {}, fmt.Errorf("create temp file: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return name, func() { _ = os.Remove(name) }, nil
+}
+
+// parseGoCoverProfile reads a `go test -coverprofile` file. Block paths are
+// import paths; modulePath is stripped so files are relative to the module.
+func parseGoCoverProfile(data string, modulePath string) []FileCoverage {
+	type block struct {
+		stmts   int
+		covered bool
+	}
+	blocks := map[string]map[string]*block{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		colon := strings.LastIndex(line, ":")
+		if colon <= 0 {
+			continue
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			continue
+		}
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		file := line[:colon]
+		if modulePath != "" {
+			file = strings.TrimPrefix(strings.TrimPrefix(file, modulePath), "/")
+		}
+		if blocks[file] == nil {
+			blocks[file] = map[string]*block{}
+		}
+		key := fields[0]
+		b := blocks[file][key]
+		if b == nil {
+			b = &block{stmts: stmts}
+			blocks[file][key] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+
+	out := make([]FileCoverage, 0, len(blocks))
+	for file, fileBlocks := range blocks {
+		fc := FileCoverage{Path: file}
+		for _, b := range fileBlocks {
+			fc.Total += b.stmts
+			if b.covered {
+				fc.Covered += b.stmts
+			}
+		}
+		out = append(out, fc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// parseCoveragePyJSON reads the report written by `coverage json`.
+func parseCoveragePyJSON(data []byte) ([]FileCoverage, error) {
+	var report struct {
+		Files map[string]struct {
+			Summary struct {
+				CoveredLines  int `json:"covered_lines"`
+				NumStatements int `json:"num_statements"`
+			} `json:"summary"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse coverage json: %w", err)
+	}
+	out := make([]FileCoverage, 0, len(report.Files))
+	for file, entry := range report.Files {
+		out = append(out, FileCoverage{
+			Path:    filepath.ToSlash(file),
+			Covered: entry.Summary.CoveredLines,
+			Total:   entry.Summary.NumStatements,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// parseLCOV reads an lcov tracefile. Absolute source paths are made relative
+// to dir.
+func parseLCOV(data string, dir string) []FileCoverage {
+	out := make([]FileCoverage, 0)
+	var current *FileCoverage
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file := strings.TrimPrefix(line, "SF:")
+			if filepath.IsAbs(file) {
+				if rel, err := filepath.Rel(dir, file); err == nil {
+					file = rel
+				}
+			}
+			current = &FileCoverage{Path: filepath.ToSlash(file)}
+		case strings.HasPrefix(line, "LF:") && current != nil:
+			current.Total, _ = strconv.Atoi(strings.TrimPrefix(line, "LF:"))
+		case strings.HasPrefix(line, "LH:") && current != nil:
+			current.Covered, _ = strconv.Atoi(strings.TrimPrefix(line, "LH:"))
+		case line == "end_of_record" && current != nil:
+			out = append(out, *current)
+			current = nil
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// collectCoverageArtifacts picks up coverage reports that a test command wrote
+// into dir after since: `coverage/lcov.info` or a coverage.py `coverage.json`.
+func collectCoverageArtifacts(dir string, since time.Time) []FileCoverage {
+	lcovPath := filepath.Join(dir, "coverage", "lcov.info")
+	if freshFile(lcovPath, since) {
+		if data, err := os.ReadFile(lcovPath); err == nil {
+			return parseLCOV(string(data), dir)
+		}
+	}
+	jsonPath := filepath.Join(dir, "coverage.json")
+	if freshFile(jsonPath, since) {
+		if data, err := os.ReadFile(jsonPath); err == nil {
+			if files, err := parseCoveragePyJSON(data); err == nil {
+				return files
+			}
+		}
+	}
+	return nil
+}
+
+func freshFile(path string, since time.Time) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return !info.ModTime().Before(since.Truncate(time.Second))
+}
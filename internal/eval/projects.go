@@ -0,0 +1,164 @@
+package eval
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+// IgnoreFunc reports whether a slash-separated path relative to the eval root
+// should be excluded from project discovery.
+type IgnoreFunc func(relPath string, isDir bool) bool
+
+// Project is a directory that contains at least one recognized project manifest.
+type Project struct {
+	Dir   string
+	Types []ProjectType
+}
+
+// ProjectResult holds the checks that ran for a single discovered project.
+type ProjectResult struct {
+	Dir   string
+	Types []ProjectType
+	Result
+}
+
+// npmPlaceholderTest is the test script `npm init` writes, which runs nothing.
+const npmPlaceholderTest = "no test specified"
+
+// DiscoverProjects walks root and returns every directory that contains a
+// go.mod, Python project file, or package.json. Ignored directories, hidden
+// directories, and Go testdata fixtures are not descended into. A Python or
+// Node project inside an enclosing project of the same ecosystem whose test
+// runner already covers it (pytest, or an npm test script such as a workspace
+// root's) is not returned again, so its tests are not counted twice. The root
+// project, when present, is returned first with Dir ".".
+func DiscoverProjects(root string, ignore IgnoreFunc) []Project {
+	if ignore == nil {
+		ignore = config.DefaultPolicy().ShouldIgnore
+	}
+
+	out := make([]Project, 0, 4)
+	covering := map[ProjectType][]string{}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel := "."
+		if path != root {
+			r, relErr := filepath.Rel(root, path)
+			if relErr != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(r)
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || name == "testdata" || ignore(rel, true) {
+				return filepath.SkipDir
+			}
+		}
+
+		var types []ProjectType
+		for _, t := range DetectProjects(path) {
+			if coveredBy(covering[t], rel) {
+				continue
+			}
+			types = append(types, t)
+			if coversNested(path, t) {
+				covering[t] = append(covering[t], rel)
+			}
+		}
+		if len(types) > 0 {
+			out = append(out, Project{Dir: rel, Types: types})
+		}
+		return nil
+	})
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Dir == "." || out[j].Dir == "." {
+			return out[i].Dir == "."
+		}
+		return out[i].Dir < out[j].Dir
+	})
+	return out
+}
+
+// coveredBy reports whether rel lies beneath one of dirs.
+func coveredBy(dirs []string, rel string) bool {
+	for _, dir := range dirs {
+		if dir == "." || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// coversNested reports whether the project at dir runs the tests of nested
+// projects of the same type. pytest collects the whole tree; npm only does
+// when package.json defines a real test script. Nested Go modules are
+// separate from the enclosing module.
+func coversNested(dir string, t ProjectType) bool {
+	switch t {
+	case ProjectPython:
+		return true
+	case ProjectNode:
+		data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+		if err != nil {
+			return false
+		}
+		var manifest struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &manifest) != nil {
+			return false
+		}
+		test := strings.TrimSpace(manifest.Scripts["test"])
+		return test != "" && !strings.Contains(test, npmPlaceholderTest)
+	}
+	return false
+}
+
+// ProjectTypesString joins project types into a stable comma-separated label.
+func ProjectTypesString(types []ProjectType) string {
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, string(t))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r *Result) addProject(project Project, pr Result) {
+	r.TestsPassed += pr.TestsPassed
+	r.TestsFailed += pr.TestsFailed
+	r.LintErrors += pr.LintErrors
+	r.TypeErrors += pr.TypeErrors
+	r.HasTests = r.HasTests || pr.HasTests
+	r.HasLint = r.HasLint || pr.HasLint
+	r.HasTypes = r.HasTypes || pr.HasTypes
+	for _, skipped := range pr.Skipped {
+		if project.Dir == "." {
+			r.Skipped = append(r.Skipped, skipped)
+		} else {
+			r.Skipped = append(r.Skipped, project.Dir+":"+skipped)
+		}
+	}
+	if pr.Coverage != nil {
+		r.addCoverage(project.Dir, pr.Coverage.Files)
+	}
+	r.Projects = append(r.Projects, ProjectResult{
+		Dir:    project.Dir,
+		Types:  project.Types,
+		Result: pr,
+	})
+}
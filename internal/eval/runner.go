@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/prit3010/converge/internal/bench"
 	"github.com/prit3010/converge/internal/config"
 )
 
@@ -32,6 +34,17 @@ type Result struct {
 	HasTypes bool
 
 	Skipped []string
+
+	// Scope is the eval scope that produced the totals; changed-scope results
+	// only cover the packages affected by a cell's diff.
+	Scope config.EvalScope
+
+	// Coverage is nil unless coverage collection is enabled and a report was found.
+	Coverage *Coverage
+
+	Bench []bench.Sample
+
+	Projects []ProjectResult
 }
 
 func (r Result) TestsPassedPtr() *int {
@@ -76,6 +89,7 @@ func (r Result) SkippedPtr() *string {
 
 type Runner struct {
 	policy config.EvalPolicy
+	ignore IgnoreFunc
 }
 
 func NewRunner() *Runner {
@@ -86,31 +100,98 @@ func (r *Runner) SetPolicy(policy config.EvalPolicy) {
 	r.policy = policy
 }
 
+// SetIgnore sets the filter used to skip directories during project discovery.
+func (r *Runner) SetIgnore(ignore IgnoreFunc) {
+	r.ignore = ignore
+}
+
 func (r *Runner) Run(ctx context.Context, projectDir string) (Result, error) {
-	res := Result{}
+	res := r.runChecks(ctx, projectDir)
+	r.runBenchmarks(ctx, projectDir, &res)
+	return res, nil
+}
+
+// RunChanged evaluates only the projects affected by changed, a list of
+// slash-separated paths relative to projectDir. Configured command overrides
+// cannot be narrowed and fall back to a full run.
+func (r *Runner) RunChanged(ctx context.Context, projectDir string, changed []string) (Result, error) {
+	var res Result
+	if r.policy.HasOverrides() {
+		res = r.runChecks(ctx, projectDir)
+	} else {
+		res = r.runChangedChecks(ctx, projectDir, changed)
+	}
+	r.runBenchmarks(ctx, projectDir, &res)
+	return res, nil
+}
+
+func (r *Runner) runChecks(ctx context.Context, projectDir string) Result {
+	res := Result{Scope: config.EvalScopeFull}
+	opts := r.checkOptions()
 	if r.policy.HasOverrides() {
 		r.runConfiguredChecks(ctx, projectDir, &res)
-		return res, nil
+		return res
 	}
 
-	projects := DetectProjects(projectDir)
+	projects := DiscoverProjects(projectDir, r.ignore)
 	if len(projects) == 0 {
 		res.Skipped = append(res.Skipped, "no-project-detected")
-		return res, nil
+		return res
 	}
 
 	for _, project := range projects {
-		switch project {
-		case ProjectGo:
-			runGoChecks(ctx, projectDir, &res)
-		case ProjectPython:
-			runPythonChecks(ctx, projectDir, &res)
-		case ProjectNode:
-			runNodeChecks(ctx, projectDir, &res)
+		dir := filepath.Join(projectDir, filepath.FromSlash(project.Dir))
+		projectRes := Result{}
+		for _, projectType := range project.Types {
+			switch projectType {
+			case ProjectGo:
+				runGoChecks(ctx, dir, opts, &projectRes)
+			case ProjectPython:
+				runPythonChecks(ctx, dir, opts, &projectRes)
+			case ProjectNode:
+				runNodeChecks(ctx, dir, opts, &projectRes)
+			}
 		}
+		res.addProject(project, projectRes)
 	}
 
-	return res, nil
+	return res
+}
+
+func (r *Runner) runChangedChecks(ctx context.Context, projectDir string, changed []string) Result {
+	res := Result{Scope: config.EvalScopeChanged}
+	opts := r.checkOptions()
+	projects := DiscoverProjects(projectDir, r.ignore)
+	if len(projects) == 0 {
+		res.Skipped = append(res.Skipped, "no-project-detected")
+		return res
+	}
+
+	owned := assignChangedPaths(projects, changed)
+	for _, project := range projects {
+		paths := owned[project.Dir]
+		if len(paths) == 0 {
+			continue
+		}
+		dir := filepath.Join(projectDir, filepath.FromSlash(project.Dir))
+		projectRes := Result{}
+		for _, projectType := range project.Types {
+			switch projectType {
+			case ProjectGo:
+				runGoChangedChecks(ctx, dir, paths, opts, &projectRes)
+			case ProjectPython:
+				runPythonChangedChecks(ctx, dir, paths, opts, &projectRes)
+			case ProjectNode:
+				runNodeChecks(ctx, dir, opts, &projectRes)
+			}
+		}
+		res.addProject(project, projectRes)
+	}
+	if len(res.Projects) == 0 {
+		res.Skipped = append(res.Skipped, "no-affected-projects")
+	}
+
+	return res
 }
 
 func (r *Runner) runConfiguredChecks(ctx context.Context, projectDir string, res *Result) {
@@ -120,6 +201,7 @@ func (r *Runner) runConfiguredChecks(ctx context.Context, projectDir string, res
 
 	if len(runTests) > 0 {
 		res.HasTests = true
+		started := time.Now()
 		for _, command := range runTests {
 			out, err := runShellCmd(ctx, projectDir, command)
 			if isMissingShellCommand(err) {
@@ -140,6 +222,9 @@ func (r *Runner) runConfiguredChecks(ctx context.Context, projectDir string, res
 				res.TestsPassed++
 			}
 		}
+		if r.policy.Coverage {
+			res.addCoverage("", collectCoverageArtifacts(projectDir, started))
+		}
 	}
 
 	if len(runLint) > 0 {
@@ -186,62 +271,143 @@ func exists(path string) bool {
 	return err == nil
 }
 
-func runGoChecks(ctx context.Context, dir string, res *Result) {
-	if toolExists("go") {
-		out, err := runCmd(ctx, dir, "go", "test", "-json", "./...")
-		passed, failed := parseGoTestOutput(out)
-		if err != nil && failed == 0 {
-			failed = 1
-		}
-		res.HasTests = true
-		res.TestsPassed += passed
-		res.TestsFailed += failed
-	} else {
+func runGoChecks(ctx context.Context, dir string, opts checkOptions, res *Result) {
+	runGoTests(ctx, dir, []string{"./..."}, opts, res)
+	runGoLint(ctx, dir, []string{"./..."}, res)
+}
+
+func runGoTests(ctx context.Context, dir string, packages []string, opts checkOptions, res *Result) {
+	if !toolExists("go") {
 		res.Skipped = append(res.Skipped, "go")
+		return
+	}
+	args := []string{"test", "-json"}
+	profile := ""
+	if opts.coverage {
+		path, cleanup, err := tempFilePath("converge-cover-*.out")
+		if err == nil {
+			defer cleanup()
+			profile = path
+			args = append(args, "-coverprofile="+profile)
+		}
+	}
+	args = append(args, packages...)
+	out, err := runCmd(ctx, dir, "go", args...)
+	passed, failed := parseGoTestOutput(out)
+	if err != nil && failed == 0 {
+		failed = 1
+	}
+	res.HasTests = true
+	res.TestsPassed += passed
+	res.TestsFailed += failed
+	if profile != "" {
+		if data, readErr := os.ReadFile(profile); readErr == nil {
+			res.addCoverage("", parseGoCoverProfile(string(data), goModulePath(dir)))
+		}
 	}
+}
 
-	if toolExists("golangci-lint") {
-		out, err := runCmd(ctx, dir, "golangci-lint", "run", "./...")
-		res.HasLint = true
-		res.LintErrors += conservativeProblemCount(out, err)
-	} else {
+func runGoLint(ctx context.Context, dir string, packages []string, res *Result) {
+	if !toolExists("golangci-lint") {
 		res.Skipped = append(res.Skipped, "golangci-lint")
+		return
 	}
+	args := append([]string{"run"}, packages...)
+	out, err := runCmd(ctx, dir, "golangci-lint", args...)
+	res.HasLint = true
+	res.LintErrors += conservativeProblemCount(out, err)
 }
 
-func runPythonChecks(ctx context.Context, dir string, res *Result) {
-	if toolExists("pytest") {
-		out, err := runCmd(ctx, dir, "pytest", "-q", "--tb=no")
-		passed, failed := parsePytestSummary(out)
-		if err != nil && failed == 0 {
-			failed = 1
-		}
-		res.HasTests = true
-		res.TestsPassed += passed
-		res.TestsFailed += failed
-	} else {
+func runPythonChecks(ctx context.Context, dir string, opts checkOptions, res *Result) {
+	runPytest(ctx, dir, nil, opts, res)
+	runPythonLint(ctx, dir, []string{"."}, res)
+	runPythonTypes(ctx, dir, []string{"."}, res)
+}
+
+func runPytest(ctx context.Context, dir string, testFiles []string, opts checkOptions, res *Result) {
+	if !toolExists("pytest") {
 		res.Skipped = append(res.Skipped, "pytest")
+		return
+	}
+	args := append([]string{"-q", "--tb=no"}, testFiles...)
+	withCoverage := opts.coverage && toolExists("coverage")
+	if opts.coverage && !withCoverage {
+		res.Skipped = append(res.Skipped, "coverage")
 	}
 
-	if toolExists("ruff") {
-		out, err := runCmd(ctx, dir, "ruff", "check", ".")
-		res.HasLint = true
-		res.LintErrors += conservativeProblemCount(out, err)
+	// Keep coverage.py's data file out of the project so evals never leave a
+	// .coverage behind or pick up one from an earlier run.
+	var coverageEnv []string
+	if withCoverage {
+		dataFile, cleanup, tmpErr := tempFilePath("converge-coverage-*")
+		if tmpErr != nil {
+			withCoverage = false
+		} else {
+			defer cleanup()
+			coverageEnv = []string{"COVERAGE_FILE=" + dataFile}
+		}
+	}
+
+	var out string
+	var err error
+	if withCoverage {
+		out, err = runCmdEnv(ctx, dir, coverageEnv, "coverage", append([]string{"run", "-m", "pytest"}, args...)...)
 	} else {
+		out, err = runCmd(ctx, dir, "pytest", args...)
+	}
+	passed, failed := parsePytestSummary(out)
+	if err != nil && failed == 0 {
+		failed = 1
+	}
+	res.HasTests = true
+	res.TestsPassed += passed
+	res.TestsFailed += failed
+
+	if withCoverage {
+		report, cleanup, tmpErr := tempFilePath("converge-cover-*.json")
+		if tmpErr != nil {
+			return
+		}
+		defer cleanup()
+		if _, jsonErr := runCmdEnv(ctx, dir, coverageEnv, "coverage", "json", "-q", "-o", report); jsonErr != nil {
+			return
+		}
+		if data, readErr := os.ReadFile(report); readErr == nil {
+			if files, parseErr := parseCoveragePyJSON(data); parseErr == nil {
+				res.addCoverage("", files)
+			}
+		}
+	}
+}
+
+func runPythonLint(ctx context.Context, dir string, targets []string, res *Result) {
+	if !toolExists("ruff") {
 		res.Skipped = append(res.Skipped, "ruff")
+		return
 	}
+	args := append([]string{"check"}, targets...)
+	out, err := runCmd(ctx, dir, "ruff", args...)
+	res.HasLint = true
+	res.LintErrors += conservativeProblemCount(out, err)
+}
 
-	if toolExists("mypy") {
-		out, err := runCmd(ctx, dir, "mypy", ".")
-		res.HasTypes = true
-		res.TypeErrors += conservativeProblemCount(out, err)
-	} else {
+func runPythonTypes(ctx context.Context, dir string, targets []string, res *Result) {
+	if !toolExists("mypy") {
 		res.Skipped = append(res.Skipped, "mypy")
+		return
 	}
+	out, err := runCmd(ctx, dir, "mypy", targets...)
+	res.HasTypes = true
+	res.TypeErrors += conservativeProblemCount(out, err)
 }
 
-func runNodeChecks(ctx context.Context, dir string, res *Result) {
+// runNodeChecks runs `npm test` as the project defines it. Converge does not
+// instrument Node tests, so coverage is only recorded when the test script
+// itself writes coverage/lcov.info (e.g. `c8 --reporter=lcov` or
+// `jest --coverage --coverageReporters=lcov`).
+func runNodeChecks(ctx context.Context, dir string, opts checkOptions, res *Result) {
 	if toolExists("npm") {
+		started := time.Now()
 		_, err := runCmd(ctx, dir, "npm", "test", "--silent")
 		res.HasTests = true
 		if err != nil {
@@ -249,6 +415,9 @@ func runNodeChecks(ctx context.Context, dir string, res *Result) {
 		} else {
 			res.TestsPassed += 1
 		}
+		if opts.coverage {
+			res.addCoverage("", collectCoverageArtifacts(dir, started))
+		}
 	} else {
 		res.Skipped = append(res.Skipped, "npm")
 	}
@@ -272,19 +441,33 @@ func toolExists(name string) bool {
 }
 
 func runCmd(ctx context.Context, dir string, name string, args ...string) (string, error) {
+	return runCmdEnv(ctx, dir, nil, name, args...)
+}
+
+func runCmdEnv(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
 	tool, ok := resolveTool(name)
 	if !ok {
 		return "", fmt.Errorf("tool %s not found", name)
 	}
 	cmd := exec.CommandContext(ctx, tool, args...)
 	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	out, err := cmd.CombinedOutput()
 	return string(out), err
 }
 
 func runShellCmd(ctx context.Context, dir string, command string) (string, error) {
+	return runShellCmdEnv(ctx, dir, command, nil)
+}
+
+func runShellCmdEnv(ctx context.Context, dir string, command string, env []string) (string, error) {
 	cmd := exec.CommandContext(ctx, "bash", "-lc", command)
 	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	out, err := cmd.CombinedOutput()
 	return string(out), err
 }
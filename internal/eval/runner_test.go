@@ -48,7 +48,7 @@ func TestRunGoChecksConservativeFailureAccounting(t *testing.T) {
 	t.Setenv("PATH", toolsDir)
 
 	var result Result
-	runGoChecks(context.Background(), projectDir, &result)
+	runGoChecks(context.Background(), projectDir, checkOptions{}, &result)
 
 	if !result.HasTests {
 		t.Fatalf("expected tests to be marked as run")
@@ -74,7 +74,7 @@ func TestRunPythonChecksConservativeFailureAccounting(t *testing.T) {
 	t.Setenv("PATH", toolsDir)
 
 	var result Result
-	runPythonChecks(context.Background(), projectDir, &result)
+	runPythonChecks(context.Background(), projectDir, checkOptions{}, &result)
 
 	if !result.HasTests || result.TestsFailed != 1 {
 		t.Fatalf("expected conservative python test failure accounting, got %+v", result)
@@ -87,6 +87,42 @@ func TestRunPythonChecksConservativeFailureAccounting(t *testing.T) {
 	}
 }
 
+func TestRunPytestKeepsCoverageDataOutOfProject(t *testing.T) {
+	toolsDir := t.TempDir()
+	projectDir := t.TempDir()
+	dataLog := filepath.Join(t.TempDir(), "coverage-file.log")
+
+	writeExecutable(t, filepath.Join(toolsDir, "pytest"), "#!/bin/bash\nexit 0\n")
+	writeExecutable(t, filepath.Join(toolsDir, "coverage"), "#!/bin/bash\n"+
+		"data=\"${COVERAGE_FILE:-.coverage}\"\n"+
+		"if [[ \"$1\" == run ]]; then echo \"$data\" > "+dataLog+"; echo covered > \"$data\"; echo '2 passed in 0.01s'; exit 0; fi\n"+
+		"[[ -s \"$data\" ]] || exit 1\n"+
+		"while [[ $# -gt 0 ]]; do if [[ \"$1\" == -o ]]; then out=\"$2\"; fi; shift; done\n"+
+		"echo '{\"files\": {\"app.py\": {\"summary\": {\"covered_lines\": 1, \"num_statements\": 2}}}}' > \"$out\"\n")
+	t.Setenv("PATH", toolsDir)
+
+	var result Result
+	runPytest(context.Background(), projectDir, nil, checkOptions{coverage: true}, &result)
+
+	if result.TestsPassed != 2 || result.Coverage == nil || result.Coverage.Covered != 1 || result.Coverage.Total != 2 {
+		t.Fatalf("expected pytest coverage to be collected, got %+v", result)
+	}
+	logged, err := os.ReadFile(dataLog)
+	if err != nil {
+		t.Fatalf("read coverage log: %v", err)
+	}
+	dataFile := strings.TrimSpace(string(logged))
+	if dataFile == ".coverage" || strings.HasPrefix(dataFile, projectDir) {
+		t.Fatalf("expected COVERAGE_FILE outside the project, got %q", dataFile)
+	}
+	if _, err := os.Stat(dataFile); !os.IsNotExist(err) {
+		t.Fatalf("expected coverage data file to be removed, stat err %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".coverage")); !os.IsNotExist(err) {
+		t.Fatalf("expected no .coverage in the project, stat err %v", err)
+	}
+}
+
 func writeExecutable(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
@@ -139,3 +175,356 @@ func TestRunConfiguredCommandsMissingCommandIsSkipped(t *testing.T) {
 		t.Fatalf("expected skipped entry for missing configured command")
 	}
 }
+
+func TestDiscoverProjectsFindsNestedProjects(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"services/api/go.mod",
+		"web/package.json",
+		"web/node_modules/dep/package.json",
+		"tools/.cache/pyproject.toml",
+		"services/api/testdata/fixture/go.mod",
+		"build/pkg/go.mod",
+	}
+	for _, rel := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	ignore := func(rel string, isDir bool) bool {
+		return rel == "build" || config.DefaultPolicy().ShouldIgnore(rel, isDir)
+	}
+	projects := DiscoverProjects(dir, ignore)
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", projects)
+	}
+	if projects[0].Dir != "services/api" || projects[0].Types[0] != ProjectGo {
+		t.Fatalf("unexpected first project: %+v", projects[0])
+	}
+	if projects[1].Dir != "web" || projects[1].Types[0] != ProjectNode {
+		t.Fatalf("unexpected second project: %+v", projects[1])
+	}
+}
+
+func TestDiscoverProjectsSkipsProjectsCoveredByRoot(t *testing.T) {
+	discover := func(files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for rel, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("mkdir %s: %v", rel, err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("write %s: %v", rel, err)
+			}
+		}
+		var got []string
+		for _, project := range DiscoverProjects(dir, nil) {
+			got = append(got, project.Dir+"="+ProjectTypesString(project.Types))
+		}
+		return strings.Join(got, " ")
+	}
+
+	monorepo := discover(map[string]string{
+		"package.json":              `{"workspaces":["packages/*"],"scripts":{"test":"npm test --workspaces"}}`,
+		"packages/ui/package.json":  `{"scripts":{"test":"vitest run"}}`,
+		"packages/api/package.json": `{"scripts":{"test":"jest"}}`,
+		"packages/api/go.mod":       "module api",
+		"py/pyproject.toml":         "",
+		"py/plugins/pyproject.toml": "",
+	})
+	if want := ".=node packages/api=go py=python"; monorepo != want {
+		t.Fatalf("expected %q, got %q", want, monorepo)
+	}
+
+	placeholder := discover(map[string]string{
+		"package.json":     `{"scripts":{"test":"echo \"Error: no test specified\" && exit 1"}}`,
+		"app/package.json": `{"scripts":{"test":"jest"}}`,
+	})
+	if want := ".=node app=node"; placeholder != want {
+		t.Fatalf("expected %q, got %q", want, placeholder)
+	}
+}
+
+func TestRunRecordsResultsPerSubproject(t *testing.T) {
+	toolsDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	writeExecutable(t, filepath.Join(toolsDir, "go"), "#!/bin/bash\n"+
+		"if [[ \"$PWD\" == */broken ]]; then echo '{\"Action\":\"fail\",\"Test\":\"TestA\"}'; exit 1; fi\n"+
+		"echo '{\"Action\":\"pass\",\"Test\":\"TestA\"}'\n")
+	t.Setenv("PATH", toolsDir)
+
+	for _, sub := range []string{"ok", "broken"} {
+		if err := os.MkdirAll(filepath.Join(projectDir, sub), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", sub, err)
+		}
+		if err := os.WriteFile(filepath.Join(projectDir, sub, "go.mod"), []byte("module "+sub), 0o644); err != nil {
+			t.Fatalf("write go.mod: %v", err)
+		}
+	}
+
+	result, err := NewRunner().Run(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.TestsPassed != 1 || result.TestsFailed != 1 {
+		t.Fatalf("unexpected aggregate result: %+v", result)
+	}
+	if len(result.Projects) != 2 {
+		t.Fatalf("expected 2 project results, got %d", len(result.Projects))
+	}
+	broken := result.Projects[0]
+	if broken.Dir != "broken" || broken.TestsFailed != 1 || broken.TestsPassed != 0 {
+		t.Fatalf("unexpected broken project result: %+v", broken)
+	}
+	if result.Projects[1].Dir != "ok" || result.Projects[1].TestsPassed != 1 {
+		t.Fatalf("unexpected ok project result: %+v", result.Projects[1])
+	}
+	for _, skipped := range result.Skipped {
+		if !strings.HasPrefix(skipped, "broken:") && !strings.HasPrefix(skipped, "ok:") {
+			t.Fatalf("expected subproject skip entries to be prefixed, got %q", skipped)
+		}
+	}
+}
+
+func TestAssignChangedPathsUsesDeepestProject(t *testing.T) {
+	projects := []Project{
+		{Dir: ".", Types: []ProjectType{ProjectNode}},
+		{Dir: "services/api", Types: []ProjectType{ProjectGo}},
+		{Dir: "web", Types: []ProjectType{ProjectNode}},
+	}
+	owned := assignChangedPaths(projects, []string{"README.md", "services/api/handlers/user.go", "web/src/app.ts"})
+	if len(owned["."]) != 1 || owned["."][0] != "README.md" {
+		t.Fatalf("unexpected root paths: %+v", owned["."])
+	}
+	if len(owned["services/api"]) != 1 || owned["services/api"][0] != "handlers/user.go" {
+		t.Fatalf("unexpected api paths: %+v", owned["services/api"])
+	}
+	if len(owned["web"]) != 1 || owned["web"][0] != "src/app.ts" {
+		t.Fatalf("unexpected web paths: %+v", owned["web"])
+	}
+}
+
+func TestSelectPythonTestsMatchesChangedModules(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"pkg/billing.py", "pkg/test_local.py", "tests/test_billing.py", "tests/test_other.py", "tests/helper_test.py"} {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(""), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	got := selectPythonTests(dir, []string{"pkg/billing.py", "tests/helper_test.py"})
+	want := []string{"pkg/test_local.py", "tests/helper_test.py", "tests/test_billing.py"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("selectPythonTests = %v, want %v", got, want)
+	}
+}
+
+func TestRunChangedOnlyTestsAffectedGoPackages(t *testing.T) {
+	toolsDir := t.TempDir()
+	projectDir := t.TempDir()
+	argsLog := filepath.Join(t.TempDir(), "go-args.log")
+
+	writeExecutable(t, filepath.Join(toolsDir, "go"), "#!/bin/bash\n"+
+		"echo \"$*\" >> "+argsLog+"\n"+
+		"if [[ \"$1\" == test ]]; then echo '{\"Action\":\"pass\",\"Test\":\"TestA\"}'; fi\n")
+	t.Setenv("PATH", toolsDir)
+
+	files := map[string]string{
+		"svc/go.mod":                "module svc\n",
+		"svc/a/a.go":                "package a\n",
+		"svc/b/b.go":                "package b\n",
+		"svc/b/testdata/input.txt":  "x\n",
+		"web/package.json":          "{}\n",
+		"docs/unrelated/readme.txt": "x\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(projectDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	result, err := NewRunner().RunChanged(context.Background(), projectDir, []string{"svc/b/testdata/input.txt"})
+	if err != nil {
+		t.Fatalf("run changed: %v", err)
+	}
+	if result.Scope != config.EvalScopeChanged {
+		t.Fatalf("expected changed scope, got %q", result.Scope)
+	}
+	if len(result.Projects) != 1 || result.Projects[0].Dir != "svc" {
+		t.Fatalf("expected only svc to be evaluated, got %+v", result.Projects)
+	}
+	if result.TestsPassed != 1 {
+		t.Fatalf("unexpected changed-scope result: %+v", result)
+	}
+
+	logged, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatalf("read go args log: %v", err)
+	}
+	if !strings.Contains(string(logged), "test -json ./b\n") {
+		t.Fatalf("expected go test to target ./b only, got:\n%s", logged)
+	}
+
+	full, err := NewRunner().Run(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("run full: %v", err)
+	}
+	if full.Scope != config.EvalScopeFull {
+		t.Fatalf("expected full scope, got %q", full.Scope)
+	}
+}
+
+func TestGoDependentPackagesIncludesImporters(t *testing.T) {
+	if !toolExists("go") {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/m\n\ngo 1.22\n",
+		"core/core.go": "package core\n",
+		"api/api.go":   "package api\n\nimport _ \"example.com/m/core\"\n",
+		"cli/cli.go":   "package cli\n\nimport _ \"example.com/m/api\"\n",
+		"other/o.go":   "package other\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	got := goDependentPackages(context.Background(), dir, []string{"core"})
+	want := []string{"./api", "./cli", "./core"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("goDependentPackages = %v, want %v", got, want)
+	}
+}
+
+func TestParseGoCoverProfileStripsModulePath(t *testing.T) {
+	profile := "mode: set\n" +
+		"example.com/m/core/core.go:3.20,5.2 2 1\n" +
+		"example.com/m/core/core.go:7.20,9.2 3 0\n" +
+		"example.com/m/core/core.go:7.20,9.2 3 1\n" +
+		"example.com/m/api/api.go:3.20,5.2 4 0\n"
+	files := parseGoCoverProfile(profile, "example.com/m")
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %+v", files)
+	}
+	if files[0].Path != "api/api.go" || files[0].Covered != 0 || files[0].Total != 4 {
+		t.Fatalf("unexpected api coverage: %+v", files[0])
+	}
+	if files[1].Path != "core/core.go" || files[1].Covered != 5 || files[1].Total != 5 {
+		t.Fatalf("unexpected core coverage: %+v", files[1])
+	}
+}
+
+func TestParseCoverageReports(t *testing.T) {
+	pyJSON := []byte(`{"files": {"pkg/app.py": {"summary": {"covered_lines": 3, "num_statements": 4}}}}`)
+	files, err := parseCoveragePyJSON(pyJSON)
+	if err != nil {
+		t.Fatalf("parse coverage json: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "pkg/app.py" || files[0].Covered != 3 || files[0].Total != 4 {
+		t.Fatalf("unexpected coverage.py result: %+v", files)
+	}
+
+	dir := t.TempDir()
+	lcov := "TN:\nSF:" + filepath.Join(dir, "src", "a.ts") + "\nLF:10\nLH:7\nend_of_record\nSF:src/b.ts\nLF:2\nLH:0\nend_of_record\n"
+	files = parseLCOV(lcov, dir)
+	if len(files) != 2 || files[0].Path != "src/a.ts" || files[0].Covered != 7 || files[1].Total != 2 {
+		t.Fatalf("unexpected lcov result: %+v", files)
+	}
+}
+
+func TestRunCollectsCoveragePerSubproject(t *testing.T) {
+	toolsDir := t.TempDir()
+	projectDir := t.TempDir()
+
+	writeExecutable(t, filepath.Join(toolsDir, "go"), "#!/bin/bash\n"+
+		"for arg in \"$@\"; do\n"+
+		"  case \"$arg\" in -coverprofile=*) printf 'mode: set\\nexample.com/api/h.go:1.1,2.2 3 1\\nexample.com/api/h.go:3.1,4.2 1 0\\n' > \"${arg#-coverprofile=}\";; esac\n"+
+		"done\n"+
+		"echo '{\"Action\":\"pass\",\"Test\":\"TestA\"}'\n")
+	t.Setenv("PATH", toolsDir)
+
+	if err := os.MkdirAll(filepath.Join(projectDir, "api"), 0o755); err != nil {
+		t.Fatalf("mkdir api: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "api", "go.mod"), []byte("module example.com/api\n"), 0o644); err != nil {
+		t.Fatalf("write go.mod: %v", err)
+	}
+
+	runner := NewRunner()
+	runner.SetPolicy(config.EvalPolicy{Coverage: true})
+	result, err := runner.Run(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Coverage == nil {
+		t.Fatalf("expected coverage to be collected, got %+v", result)
+	}
+	if result.Coverage.Covered != 3 || result.Coverage.Total != 4 {
+		t.Fatalf("unexpected coverage totals: %+v", result.Coverage)
+	}
+	if len(result.Coverage.Files) != 1 || result.Coverage.Files[0].Path != "api/h.go" {
+		t.Fatalf("expected repo-relative file coverage, got %+v", result.Coverage.Files)
+	}
+	if pct := result.CoveragePctPtr(); pct == nil || *pct != 75 {
+		t.Fatalf("expected 75%% coverage, got %v", pct)
+	}
+}
+
+func TestRunBenchCollectsGoBenchAndMetricsFile(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewRunner()
+	runner.SetPolicy(config.EvalPolicy{
+		Tests: []string{"true"},
+		Bench: []string{
+			"printf 'BenchmarkA-8 \\t 100 \\t 250 ns/op\\n'",
+			"printf '{\"p95\": [12, 14]}' > \"$" + MetricsFileEnv + "\"",
+			"converge_nonexistent_bench_xyz",
+		},
+	})
+
+	result, err := runner.Run(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Bench) != 3 {
+		t.Fatalf("expected 3 bench samples, got %+v", result.Bench)
+	}
+	if result.Bench[0].Name != "BenchmarkA" || result.Bench[0].Value != 250 {
+		t.Fatalf("unexpected go bench sample: %+v", result.Bench[0])
+	}
+	if result.Bench[1].Name != "p95" || result.Bench[2].Value != 14 {
+		t.Fatalf("unexpected metrics file samples: %+v", result.Bench[1:])
+	}
+	found := false
+	for _, skipped := range result.Skipped {
+		if skipped == "bench:converge_nonexistent_bench_xyz" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected missing bench command to be skipped, got %v", result.Skipped)
+	}
+}
@@ -0,0 +1,286 @@
+package eval
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var goManifestFiles = map[string]bool{
+	"go.mod":  true,
+	"go.sum":  true,
+	"go.work": true,
+}
+
+var pythonConfigFiles = map[string]bool{
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"setup.cfg":        true,
+	"requirements.txt": true,
+	"conftest.py":      true,
+	"pytest.ini":       true,
+	"tox.ini":          true,
+}
+
+// assignChangedPaths maps each changed path to the deepest project containing
+// it, returning paths relative to that project's directory.
+func assignChangedPaths(projects []Project, changed []string) map[string][]string {
+	out := make(map[string][]string, len(projects))
+	for _, p := range changed {
+		p = path.Clean(filepath.ToSlash(p))
+		owner, ownerDepth := "", -1
+		for _, project := range projects {
+			depth := 0
+			if project.Dir != "." {
+				if p != project.Dir && !strings.HasPrefix(p, project.Dir+"/") {
+					continue
+				}
+				depth = strings.Count(project.Dir, "/") + 1
+			}
+			if depth > ownerDepth {
+				owner, ownerDepth = project.Dir, depth
+			}
+		}
+		if ownerDepth < 0 {
+			continue
+		}
+		rel := p
+		if owner != "." {
+			rel = strings.TrimPrefix(strings.TrimPrefix(p, owner), "/")
+		}
+		out[owner] = append(out[owner], rel)
+	}
+	return out
+}
+
+func runGoChangedChecks(ctx context.Context, dir string, changed []string, opts checkOptions, res *Result) {
+	for _, p := range changed {
+		if goManifestFiles[p] {
+			runGoChecks(ctx, dir, opts, res)
+			return
+		}
+	}
+
+	dirs := affectedGoDirs(dir, changed)
+	if len(dirs) == 0 {
+		return
+	}
+	runGoTests(ctx, dir, goDependentPackages(ctx, dir, dirs), opts, res)
+	runGoLint(ctx, dir, goPackagePatterns(dirs), res)
+}
+
+// affectedGoDirs returns the package directories touched by changed paths.
+// Files under testdata count against the package that owns the testdata dir.
+func affectedGoDirs(dir string, changed []string) []string {
+	seen := map[string]bool{}
+	for _, p := range changed {
+		pkgDir := path.Dir(p)
+		segments := strings.Split(pkgDir, "/")
+		for i, segment := range segments {
+			if segment == "testdata" {
+				pkgDir = path.Join(segments[:i]...)
+				if pkgDir == "" {
+					pkgDir = "."
+				}
+				break
+			}
+		}
+		if seen[pkgDir] {
+			continue
+		}
+		if hasGoFiles(filepath.Join(dir, filepath.FromSlash(pkgDir))) {
+			seen[pkgDir] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for d := range seen {
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+func goPackagePatterns(dirs []string) []string {
+	out := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		if d == "." {
+			out = append(out, ".")
+			continue
+		}
+		out = append(out, "./"+d)
+	}
+	return out
+}
+
+// goDependentPackages widens the changed package dirs to every package in the
+// module that imports one of them, directly, transitively, or from its tests.
+// If `go list` is unavailable the changed packages are returned unchanged.
+func goDependentPackages(ctx context.Context, dir string, dirs []string) []string {
+	fallback := goPackagePatterns(dirs)
+	if !toolExists("go") {
+		return fallback
+	}
+	out, err := runCmd(ctx, dir, "go", "list", "-e", "-f",
+		`{{.Dir}}|{{.ImportPath}}|{{join .Deps " "}} {{join .TestImports " "}} {{join .XTestImports " "}}`, "./...")
+	if err != nil {
+		return fallback
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		root = dir
+	}
+
+	type goPackage struct {
+		rel     string
+		imports []string
+	}
+	changedDirs := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		changedDirs[d] = true
+	}
+	changedImports := map[string]bool{}
+	packages := make([]goPackage, 0)
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		pkgDir, err := filepath.EvalSymlinks(parts[0])
+		if err != nil {
+			pkgDir = parts[0]
+		}
+		rel, err := filepath.Rel(root, pkgDir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if changedDirs[rel] {
+			changedImports[parts[1]] = true
+		}
+		packages = append(packages, goPackage{rel: rel, imports: strings.Fields(parts[2])})
+	}
+	if len(packages) == 0 {
+		return fallback
+	}
+
+	selected := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		selected[d] = true
+	}
+	for _, pkg := range packages {
+		for _, imp := range pkg.imports {
+			if changedImports[imp] {
+				selected[pkg.rel] = true
+				break
+			}
+		}
+	}
+	widened := make([]string, 0, len(selected))
+	for d := range selected {
+		widened = append(widened, d)
+	}
+	sort.Strings(widened)
+	return goPackagePatterns(widened)
+}
+
+func runPythonChangedChecks(ctx context.Context, dir string, changed []string, opts checkOptions, res *Result) {
+	for _, p := range changed {
+		if pythonConfigFiles[path.Base(p)] {
+			runPythonChecks(ctx, dir, opts, res)
+			return
+		}
+	}
+
+	sources := make([]string, 0, len(changed))
+	for _, p := range changed {
+		if strings.HasSuffix(p, ".py") && exists(filepath.Join(dir, filepath.FromSlash(p))) {
+			sources = append(sources, p)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+
+	if tests := selectPythonTests(dir, sources); len(tests) > 0 {
+		runPytest(ctx, dir, tests, opts, res)
+	}
+	runPythonLint(ctx, dir, sources, res)
+	runPythonTypes(ctx, dir, sources, res)
+}
+
+// selectPythonTests returns changed test files plus the tests that cover the
+// changed modules: test files named after a module, and test files that sit
+// next to it.
+func selectPythonTests(dir string, sources []string) []string {
+	stems := map[string]bool{}
+	dirs := map[string]bool{}
+	selected := map[string]bool{}
+	for _, p := range sources {
+		if isPythonTestFile(path.Base(p)) {
+			selected[p] = true
+			continue
+		}
+		stems[strings.TrimSuffix(path.Base(p), ".py")] = true
+		dirs[path.Dir(p)] = true
+	}
+
+	if len(stems) > 0 {
+		_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if p != dir && (strings.HasPrefix(name, ".") || name == "__pycache__" || name == "node_modules" || name == "venv") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isPythonTestFile(name) {
+				return nil
+			}
+			rel, relErr := filepath.Rel(dir, p)
+			if relErr != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			stem := strings.TrimSuffix(strings.TrimPrefix(name, "test_"), "_test.py")
+			stem = strings.TrimSuffix(stem, ".py")
+			if stems[stem] || dirs[path.Dir(rel)] {
+				selected[rel] = true
+			}
+			return nil
+		})
+	}
+
+	out := make([]string, 0, len(selected))
+	for p := range selected {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isPythonTestFile(name string) bool {
+	if !strings.HasSuffix(name, ".py") {
+		return false
+	}
+	return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py")
+}
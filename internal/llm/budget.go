@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/diff"
+	"github.com/prit3010/converge/internal/snapshot"
+)
+
+const (
+	// charsPerToken is a rough average for code and English; it only needs to
+	// keep prompts comfortably inside the model's window.
+	charsPerToken = 4
+
+	defaultContextWindow = 8192
+	// maxPromptBudget caps the default budget on large-window models so a
+	// compare stays fast and cheap; --max-tokens lifts it.
+	maxPromptBudget = 24000
+	// replyReserveTokens leaves room in the window for the model's answer.
+	replyReserveTokens = 2048
+	// minFileTokens is the smallest slice worth giving a file; below it the
+	// file is listed as omitted instead.
+	minFileTokens = 48
+)
+
+// contextWindows maps model name prefixes to context sizes in tokens. More
+// specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1000000},
+	{"gpt-5", 400000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"llama3.1", 128000},
+	{"llama3.2", 128000},
+	{"llama3.3", 128000},
+	{"qwen2.5", 32768},
+	{"mistral", 32768},
+}
+
+// estimateTokens approximates the token count of text.
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// contextWindow returns the known context size for model, ignoring any
+// "vendor/" prefix used by OpenAI-compatible routers.
+func contextWindow(model string) int {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// promptBudget returns the total tokens a request may use: the override when
+// set, otherwise the model's window minus room for the reply, capped at
+// maxPromptBudget.
+func promptBudget(model string, override int) int {
+	if override > 0 {
+		return override
+	}
+	budget := contextWindow(model) - replyReserveTokens
+	if budget > maxPromptBudget {
+		budget = maxPromptBudget
+	}
+	return budget
+}
+
+// SentPrompt is the exact request sent to the model, returned when an
+// option's ShowPrompt is set.
+type SentPrompt struct {
+	System          string `json:"system"`
+	User            string `json:"user"`
+	Schema          string `json:"schema"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	BudgetTokens    int    `json:"budget_tokens"`
+}
+
+func newSentPrompt(system, user string, schema []byte, budget int) *SentPrompt {
+	return &SentPrompt{
+		System:          system,
+		User:            user,
+		Schema:          string(schema),
+		EstimatedTokens: estimateTokens(system) + estimateTokens(user) + estimateTokens(string(schema)),
+		BudgetTokens:    budget,
+	}
+}
+
+// fileDiff is one changed file's section of a prompt.
+type fileDiff struct {
+	path     string
+	header   string
+	body     string
+	compact  string // body with less context, tried before truncating
+	changed  int
+	relevant bool
+}
+
+// writeManifestDiff writes file-level counts and per-file patches from mapA
+// to mapB within budget tokens. Files touched by evals (tests, or files whose
+// coverage changed) go first, then larger changes. The budget is shared so
+// small files are shown whole and large ones are truncated with an elision
+// marker; files that cannot get a useful share are listed as omitted.
+func (c *Comparer) writeManifestDiff(sb *strings.Builder, mapA, mapB map[string]string, budget int, relevant map[string]bool) {
+	start := sb.Len()
+	result := diff.CompareManifests(mapA, mapB)
+	fmt.Fprintf(sb, "High-level counts: +%d added, ~%d modified, -%d removed\n\n", len(result.Added), len(result.Modified), len(result.Removed))
+
+	if len(result.Added) > 0 {
+		sorted := append([]string(nil), result.Added...)
+		sort.Strings(sorted)
+		fmt.Fprintf(sb, "Added files: %s\n", strings.Join(sorted, ", "))
+	}
+	if len(result.Removed) > 0 {
+		sorted := append([]string(nil), result.Removed...)
+		sort.Strings(sorted)
+		fmt.Fprintf(sb, "Removed files: %s\n", strings.Join(sorted, ", "))
+	}
+	if len(result.Added) > 0 || len(result.Removed) > 0 {
+		sb.WriteString("\n")
+	}
+
+	files := make([]fileDiff, 0, len(result.Modified)+len(result.Added))
+	for _, p := range result.Modified {
+		oldData, errOld := c.store.Read(mapA[p])
+		newData, errNew := c.store.Read(mapB[p])
+		if errOld != nil || errNew != nil {
+			continue
+		}
+		if !snapshot.IsText(oldData) || !snapshot.IsText(newData) {
+			fmt.Fprintf(sb, "### %s (binary diff skipped)\n\n", p)
+			continue
+		}
+		patch := diff.ExpandedUnifiedDiff(p, string(oldData), string(newData), defaultMaxDiffContext)
+		files = append(files, fileDiff{
+			path:     p,
+			header:   "### " + p,
+			body:     patch,
+			compact:  diff.ExpandedUnifiedDiff(p, string(oldData), string(newData), fallbackMaxDiffContext),
+			changed:  countChangedLines(patch),
+			relevant: relevant[p] || isTestPath(p),
+		})
+	}
+	for _, p := range result.Added {
+		data, err := c.store.Read(mapB[p])
+		if err != nil || !snapshot.IsText(data) {
+			continue
+		}
+		files = append(files, fileDiff{
+			path:     p,
+			header:   "### " + p + " (new file)",
+			body:     string(data),
+			changed:  len(splitNonEmptyLines(string(data))),
+			relevant: relevant[p] || isTestPath(p),
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].relevant != files[j].relevant {
+			return files[i].relevant
+		}
+		if files[i].changed != files[j].changed {
+			return files[i].changed > files[j].changed
+		}
+		return files[i].path < files[j].path
+	})
+
+	remaining := budget - estimateTokens(sb.String()[start:])
+	keep := len(files)
+	if limit := remaining / minFileTokens; limit < keep {
+		keep = max(limit, 0)
+	}
+	// Reserve room for the omitted list before sharing out the rest.
+	omitted := make([]string, 0, len(files)-keep)
+	for _, f := range files[keep:] {
+		omitted = append(omitted, f.path)
+	}
+	if len(omitted) > 0 {
+		remaining -= estimateTokens("Omitted to fit the token budget: " + strings.Join(omitted, ", "))
+	}
+
+	shares := shareBudget(files[:keep], remaining)
+	for i, f := range files[:keep] {
+		writeFileDiff(sb, f, shares[i])
+	}
+	if len(omitted) > 0 {
+		fmt.Fprintf(sb, "Omitted to fit the token budget: %s\n", strings.Join(omitted, ", "))
+	}
+}
+
+// shareBudget splits budget across files by water-filling: files needing
+// less than an even share get all they need and the rest is split among the
+// larger ones.
+func shareBudget(files []fileDiff, budget int) []int {
+	shares := make([]int, len(files))
+	order := make([]int, len(files))
+	for i := range files {
+		order[i] = i
+	}
+	need := func(i int) int { return estimateTokens(files[i].header) + estimateTokens(files[i].body) + 1 }
+	sort.SliceStable(order, func(a, b int) bool { return need(order[a]) < need(order[b]) })
+	left := max(budget, 0)
+	for k, i := range order {
+		share := left / (len(order) - k)
+		shares[i] = min(need(i), share)
+		left -= shares[i]
+	}
+	return shares
+}
+
+// writeFileDiff writes one file section in at most share tokens, falling
+// back to less context and then to a truncated body with an elision marker.
+func writeFileDiff(sb *strings.Builder, f fileDiff, share int) {
+	headerCost := estimateTokens(f.header) + 1
+	if estimateTokens(f.body) <= share-headerCost {
+		fmt.Fprintf(sb, "%s\n%s\n", f.header, f.body)
+		return
+	}
+	body := f.body
+	if f.compact != "" {
+		if estimateTokens(f.compact) <= share-headerCost {
+			fmt.Fprintf(sb, "%s\n%s\n", f.header, f.compact)
+			return
+		}
+		body = f.compact
+	}
+
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	// Leave room for the marker line itself.
+	room := (share-headerCost-16)*charsPerToken - len(" (truncated)")
+	kept := 0
+	for kept < len(lines) && room >= len(lines[kept])+1 {
+		room -= len(lines[kept]) + 1
+		kept++
+	}
+	fmt.Fprintf(sb, "%s (truncated)\n", f.header)
+	if kept > 0 {
+		sb.WriteString(strings.Join(lines[:kept], "\n"))
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(sb, "... [%d more lines elided to fit the token budget]\n\n", len(lines)-kept)
+}
+
+// evalRelevantFiles returns paths whose per-file coverage differs between
+// two cells, i.e. files the evals exercised differently. fromID may be empty
+// for a first snapshot.
+func (c *Comparer) evalRelevantFiles(fromID, toID string) map[string]bool {
+	relevant := map[string]bool{}
+	before := map[string]db.FileCoverage{}
+	if fromID != "" {
+		rows, err := c.db.ListFileCoverage(fromID)
+		if err != nil {
+			return relevant
+		}
+		for _, f := range rows {
+			before[f.Path] = f
+		}
+	}
+	rows, err := c.db.ListFileCoverage(toID)
+	if err != nil {
+		return relevant
+	}
+	for _, f := range rows {
+		prev, ok := before[f.Path]
+		if !ok || prev.Covered != f.Covered || prev.Total != f.Total {
+			relevant[f.Path] = true
+		}
+		delete(before, f.Path)
+	}
+	for p := range before {
+		relevant[p] = true
+	}
+	return relevant
+}
+
+// isTestPath reports whether a path looks like a test file in the languages
+// converge evaluates.
+func isTestPath(p string) bool {
+	base := path.Base(p)
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"),
+		strings.HasSuffix(base, "_test.py"),
+		strings.Contains(base, ".test."),
+		strings.Contains(base, ".spec."):
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if dir == "tests" || dir == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
+
+func countChangedLines(patch string) int {
+	n := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestPromptBudgetFollowsModelWindow(t *testing.T) {
+	cases := map[string]int{
+		"gpt-4":                       8192 - replyReserveTokens,
+		"gpt-4o-mini":                 maxPromptBudget,
+		"openrouter/claude-3-5-haiku": maxPromptBudget,
+		"some-local-model":            defaultContextWindow - replyReserveTokens,
+	}
+	for model, want := range cases {
+		if got := promptBudget(model, 0); got != want {
+			t.Fatalf("%s: expected budget %d, got %d", model, want, got)
+		}
+	}
+	if got := promptBudget("gpt-4o", 500); got != 500 {
+		t.Fatalf("expected override to win, got %d", got)
+	}
+}
+
+func TestWriteManifestDiffPrioritizesAndElides(t *testing.T) {
+	database, objectStore := newCompareFixture(t)
+	write := func(content string) string {
+		hash, err := objectStore.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("write object: %v", err)
+		}
+		return hash
+	}
+	var big strings.Builder
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&big, "func f%d() int { return %d }\n", i, i)
+	}
+	mapA := map[string]string{
+		"main.go":      write("package main\n\nfunc main() {}\n"),
+		"main_test.go": write("package main\n\nfunc TestA() {}\n"),
+	}
+	mapB := map[string]string{
+		"main.go":      write("package main\n\nfunc main() { run() }\n"),
+		"main_test.go": write("package main\n\nfunc TestB() {}\n"),
+		"big.go":       write(big.String()),
+	}
+
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	var sb strings.Builder
+	comparer.writeManifestDiff(&sb, mapA, mapB, 800, nil)
+	out := sb.String()
+
+	if got := estimateTokens(out); got > 800 {
+		t.Fatalf("prompt used %d tokens, over the 800 budget:\n%s", got, out)
+	}
+	testAt, mainAt := strings.Index(out, "### main_test.go"), strings.Index(out, "### main.go")
+	if testAt < 0 || mainAt < 0 || testAt > mainAt {
+		t.Fatalf("expected the test file first and main.go whole:\n%s", out)
+	}
+	if !strings.Contains(out, "### big.go (new file) (truncated)") || !strings.Contains(out, "more lines elided to fit the token budget]") {
+		t.Fatalf("expected the large new file truncated with a marker:\n%s", out)
+	}
+
+	sb.Reset()
+	comparer.writeManifestDiff(&sb, mapA, mapB, 60, nil)
+	if !strings.Contains(sb.String(), "Omitted to fit the token budget:") {
+		t.Fatalf("expected files listed as omitted on a tiny budget:\n%s", sb.String())
+	}
+}
+
+func TestCompareShowPromptReturnsSentRequest(t *testing.T) {
+	database, objectStore := newCompareFixture(t)
+	stub := &stubProvider{reply: `{"summary":"Changes output.","winner":"tie","winner_reason":"same","highlights":[],"risks":[],"files":[]}`}
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	comparer.SetProvider(stub)
+
+	result, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{ShowPrompt: true, MaxTokens: 3000})
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if result.Prompt == nil || result.Prompt.User != stub.prompts[0] || result.Prompt.System != compareSystemPrompt {
+		t.Fatalf("expected the sent prompt back, got %+v", result.Prompt)
+	}
+	if result.Prompt.BudgetTokens != 3000 || result.Prompt.EstimatedTokens > 3000 {
+		t.Fatalf("unexpected prompt accounting %+v", result.Prompt)
+	}
+}
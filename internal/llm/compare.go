@@ -2,195 +2,305 @@ package llm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os"
-	"sort"
 	"strings"
+	"time"
 
-	openai "github.com/sashabaranov/go-openai"
-
+	"github.com/prit3010/converge/internal/config"
 	"github.com/prit3010/converge/internal/db"
-	"github.com/prit3010/converge/internal/diff"
-	"github.com/prit3010/converge/internal/snapshot"
 	"github.com/prit3010/converge/internal/store"
 )
 
 const (
 	defaultCompareModel    = "gpt-4o-mini"
-	defaultMaxDiffLines    = 800
 	defaultMaxDiffContext  = 120
 	fallbackMaxDiffContext = 30
 )
 
 type CompareOptions struct {
-	Model        string
-	MaxDiffLines int
+	Model string
+	// MaxTokens is the whole prompt's budget; zero derives it from the
+	// model's context window.
+	MaxTokens int
+	// ShowPrompt returns the exact request in CompareResult.Prompt.
+	ShowPrompt bool
+
+	// Refresh skips the stored result for this prompt and calls the model.
+	Refresh bool
+	// NoStore leaves the database untouched, for read-only archive sources.
+	NoStore bool
 }
 
+const compareSystemPrompt = "You are a precise code-change reviewer. Compare Cell A to Cell B only. In unified diffs, '-' lines belong to Cell A and '+' lines belong to Cell B. Do not reverse this direction and do not infer edits that are not shown. Reply with JSON only: summary (2-3 sentences), winner (Cell A's id, Cell B's id, or \"tie\"), winner_reason (one sentence), highlights (up to 5 key differences), risks (regressions or concerns introduced by Cell B, empty if none), and files (one short note per changed file worth commenting on)."
+
+// WinnerTie is the compare winner when neither cell is better.
+const WinnerTie = "tie"
+
+const compareSchemaName = "cell_comparison"
+
 type CompareResult struct {
-	Summary    string   `json:"summary"`
-	Winner     string   `json:"winner"`
-	Highlights []string `json:"highlights"`
-	Error      string   `json:"error,omitempty"`
+	Summary      string        `json:"summary"`
+	Winner       string        `json:"winner"`
+	WinnerReason string        `json:"winner_reason,omitempty"`
+	Highlights   []string      `json:"highlights"`
+	Risks        []string      `json:"risks"`
+	Files        []db.FileNote `json:"files"`
+	Provider     string        `json:"provider,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	Cached       bool          `json:"cached"`
+	CreatedAt    string        `json:"created_at,omitempty"`
+	Usage        *Usage        `json:"usage,omitempty"`
+	Prompt       *SentPrompt   `json:"prompt,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens     *int `json:"prompt_tokens,omitempty"`
+	CompletionTokens *int `json:"completion_tokens,omitempty"`
 }
 
 type Comparer struct {
-	db    *db.DB
-	store *store.Store
+	db       *db.DB
+	store    store.Reader
+	policy   config.LLMPolicy
+	provider Provider
+	external map[string]externalCell
 }
 
-func NewComparer(database *db.DB, objectStore *store.Store) *Comparer {
-	return &Comparer{db: database, store: objectStore}
+// externalCell is a cell outside c.db, such as the working tree or a cell
+// from another archive, that Compare and Rank accept by ID.
+type externalCell struct {
+	cell     db.Cell
+	manifest []db.ManifestEntry
+}
+
+// NewComparer returns a comparer that talks to the provider selected by
+// policy. The provider is resolved on each Compare so a missing API key
+// surfaces as a compare error rather than at startup.
+func NewComparer(database *db.DB, objectStore *store.Store, policy config.LLMPolicy) *Comparer {
+	return &Comparer{db: database, store: objectStore, policy: policy}
+}
+
+// SetProvider overrides the configured provider.
+func (c *Comparer) SetProvider(provider Provider) {
+	c.provider = provider
 }
 
 func (c *Comparer) Compare(ctx context.Context, cellAID, cellBID string, opts CompareOptions) (*CompareResult, error) {
-	apiKey := strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
-	if apiKey == "" {
-		res := &CompareResult{Error: "OPENAI_API_KEY is not set"}
-		return res, fmt.Errorf(res.Error)
+	model, err := c.resolveModel(opts.Model)
+	if err != nil {
+		return &CompareResult{Error: err.Error()}, err
 	}
 
-	cellA, err := c.db.GetCell(cellAID)
+	cellA, err := c.getCell(cellAID)
 	if err != nil {
 		return nil, fmt.Errorf("cell %s not found", cellAID)
 	}
-	cellB, err := c.db.GetCell(cellBID)
+	cellB, err := c.getCell(cellBID)
 	if err != nil {
 		return nil, fmt.Errorf("cell %s not found", cellBID)
 	}
 
-	prompt, err := c.buildPrompt(cellA, cellB, opts)
+	schema := compareSchema(cellA.ID, cellB.ID)
+	budget := promptBudget(model, opts.MaxTokens)
+	prompt, err := c.buildPrompt(cellA, cellB, budget-estimateTokens(compareSystemPrompt)-estimateTokens(string(schema)))
 	if err != nil {
 		return nil, fmt.Errorf("build compare prompt: %w", err)
 	}
+	var sent *SentPrompt
+	if opts.ShowPrompt {
+		sent = newSentPrompt(compareSystemPrompt, prompt, schema, budget)
+	}
 
-	model := strings.TrimSpace(opts.Model)
-	if model == "" {
-		model = defaultCompareModel
+	promptHash := hashPrompt(compareSystemPrompt, string(schema), prompt)
+	if c.isExternal(cellA.ID) || c.isExternal(cellB.ID) {
+		opts.Refresh, opts.NoStore = true, true
+	}
+	if !opts.Refresh {
+		stored, err := c.db.GetComparison(cellA.ID, cellB.ID, model, promptHash)
+		if err == nil {
+			result := resultFromComparison(stored)
+			result.Prompt = sent
+			return result, nil
+		}
+		if err != db.ErrNotFound {
+			return nil, err
+		}
 	}
 
-	client := openai.NewClient(apiKey)
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a precise code-change reviewer. Compare Cell A to Cell B only. In unified diffs, '-' lines belong to Cell A and '+' lines belong to Cell B. Do not reverse this direction and do not infer edits that are not shown. Return EXACT format: SUMMARY: <2-3 sentences>\nWINNER: <cell_id or tie> - <one sentence why>\nHIGHLIGHTS:\n- <bullet>\n- <bullet>\n- <bullet>",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+	// Stored results stay readable without credentials; only a model call
+	// needs a working provider.
+	provider, err := c.resolveProvider()
+	if err != nil {
+		return &CompareResult{Prompt: sent, Error: err.Error()}, err
+	}
+	resp, err := provider.Complete(ctx, ChatRequest{
+		Model:       model,
+		System:      compareSystemPrompt,
+		Prompt:      prompt,
 		Temperature: 0.2,
+		SchemaName:  compareSchemaName,
+		Schema:      schema,
 	})
 	if err != nil {
-		res := &CompareResult{Error: err.Error()}
-		return res, fmt.Errorf("openai compare call: %w", err)
+		res := &CompareResult{Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s compare call: %w", provider.Name(), err)
+	}
+
+	result, err := parseCompareResponse(resp.Content, cellA.ID, cellB.ID)
+	if err != nil {
+		res := &CompareResult{Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s compare reply: %w", provider.Name(), err)
 	}
-	if len(resp.Choices) == 0 {
-		res := &CompareResult{Error: "model returned no choices"}
-		return res, fmt.Errorf(res.Error)
+	result.Provider = provider.Name()
+	result.Model = model
+	result.Prompt = sent
+	result.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if resp.PromptTokens != nil || resp.CompletionTokens != nil {
+		result.Usage = &Usage{PromptTokens: resp.PromptTokens, CompletionTokens: resp.CompletionTokens}
 	}
+	if !opts.NoStore {
+		if err := c.db.UpsertComparison(db.Comparison{
+			CellA:            cellA.ID,
+			CellB:            cellB.ID,
+			Model:            model,
+			PromptHash:       promptHash,
+			Provider:         result.Provider,
+			Summary:          result.Summary,
+			Winner:           result.Winner,
+			WinnerReason:     result.WinnerReason,
+			Highlights:       result.Highlights,
+			Risks:            result.Risks,
+			FileNotes:        result.Files,
+			PromptTokens:     resp.PromptTokens,
+			CompletionTokens: resp.CompletionTokens,
+			CreatedAt:        result.CreatedAt,
+		}); err != nil {
+			return result, fmt.Errorf("store comparison: %w", err)
+		}
+	}
+	return result, nil
+}
 
-	return parseCompareResponse(resp.Choices[0].Message.Content), nil
+// AddCell makes a cell outside the comparer's database addressable by its
+// ID, reading its contents from blobs. Results involving it are never stored
+// or reused: the working tree changes underneath them, and the comparisons
+// table can only reference cells in this database.
+func (c *Comparer) AddCell(cell db.Cell, manifest []db.ManifestEntry, blobs store.Reader) {
+	if c.external == nil {
+		c.external = make(map[string]externalCell)
+	}
+	c.external[cell.ID] = externalCell{cell: cell, manifest: manifest}
+	c.store = store.Chain{blobs, c.store}
 }
 
-func (c *Comparer) buildPrompt(cellA, cellB *db.Cell, opts CompareOptions) (string, error) {
-	manifestA, err := c.db.GetManifest(cellA.ID)
-	if err != nil {
-		return "", err
+func (c *Comparer) isExternal(cellID string) bool {
+	_, ok := c.external[cellID]
+	return ok
+}
+
+func (c *Comparer) getCell(cellID string) (*db.Cell, error) {
+	if ext, ok := c.external[cellID]; ok {
+		cell := ext.cell
+		return &cell, nil
 	}
-	manifestB, err := c.db.GetManifest(cellB.ID)
-	if err != nil {
-		return "", err
+	return c.db.GetCell(cellID)
+}
+
+func (c *Comparer) getManifest(cellID string) ([]db.ManifestEntry, error) {
+	if ext, ok := c.external[cellID]; ok {
+		return ext.manifest, nil
 	}
+	return c.db.GetManifest(cellID)
+}
 
-	mapA := make(map[string]string, len(manifestA))
-	for _, e := range manifestA {
-		mapA[e.Path] = e.Hash
+// resolveModel picks the --model override, then llm.model, then the
+// provider default.
+func (c *Comparer) resolveModel(override string) (string, error) {
+	providerName, defaultModel := providerLabel(c.policy.Provider), defaultModelFor(c.policy.Provider)
+	if c.provider != nil {
+		providerName, defaultModel = c.provider.Name(), c.provider.DefaultModel()
 	}
-	mapB := make(map[string]string, len(manifestB))
-	for _, e := range manifestB {
-		mapB[e.Path] = e.Hash
+	model := strings.TrimSpace(override)
+	if model == "" {
+		model = c.policy.Model
 	}
-
-	result := diff.CompareManifests(mapA, mapB)
-	maxDiffLines := opts.MaxDiffLines
-	if maxDiffLines <= 0 {
-		maxDiffLines = defaultMaxDiffLines
+	if model == "" {
+		model = defaultModel
+	}
+	if model == "" {
+		return "", fmt.Errorf("llm.model is required for provider %s", providerName)
 	}
+	return model, nil
+}
 
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "Cell A: %s (branch=%s, msg=%q, loc=%d, files=%d)\n", cellA.ID, cellA.Branch, cellA.Message, cellA.TotalLOC, cellA.TotalFiles)
-	fmt.Fprintf(&sb, "Cell B: %s (branch=%s, msg=%q, loc=%d, files=%d)\n", cellB.ID, cellB.Branch, cellB.Message, cellB.TotalLOC, cellB.TotalFiles)
-	sb.WriteString("Diff direction: A -> B. In each patch, '-' lines are from Cell A and '+' lines are from Cell B.\n")
-	fmt.Fprintf(&sb, "High-level counts: +%d added, ~%d modified, -%d removed\n\n", len(result.Added), len(result.Modified), len(result.Removed))
+func (c *Comparer) resolveProvider() (Provider, error) {
+	if c.provider != nil {
+		return c.provider, nil
+	}
+	return NewProvider(c.policy)
+}
 
-	if len(result.Added) > 0 {
-		sorted := append([]string(nil), result.Added...)
-		sort.Strings(sorted)
-		fmt.Fprintf(&sb, "Added files: %s\n", strings.Join(sorted, ", "))
+// hashPrompt identifies the exact prompt sent, so a stored result is only
+// reused while the cells, diff budget, and instructions are unchanged.
+func hashPrompt(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
 	}
-	if len(result.Removed) > 0 {
-		sorted := append([]string(nil), result.Removed...)
-		sort.Strings(sorted)
-		fmt.Fprintf(&sb, "Removed files: %s\n", strings.Join(sorted, ", "))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func resultFromComparison(c *db.Comparison) *CompareResult {
+	result := &CompareResult{
+		Summary:      c.Summary,
+		Winner:       c.Winner,
+		WinnerReason: c.WinnerReason,
+		Highlights:   c.Highlights,
+		Risks:        c.Risks,
+		Files:        c.FileNotes,
+		Provider:     c.Provider,
+		Model:        c.Model,
+		Cached:       true,
+		CreatedAt:    c.CreatedAt,
 	}
-	if len(result.Added) > 0 || len(result.Removed) > 0 {
-		sb.WriteString("\n")
+	if c.PromptTokens != nil || c.CompletionTokens != nil {
+		result.Usage = &Usage{PromptTokens: c.PromptTokens, CompletionTokens: c.CompletionTokens}
 	}
+	return result
+}
 
-	remainingDiffLines := maxDiffLines
-	for _, path := range result.Modified {
-		if remainingDiffLines <= 0 {
-			break
-		}
-		oldData, errOld := c.store.Read(mapA[path])
-		newData, errNew := c.store.Read(mapB[path])
-		if errOld != nil || errNew != nil {
-			continue
-		}
-		if !snapshot.IsText(oldData) || !snapshot.IsText(newData) {
-			fmt.Fprintf(&sb, "### %s (binary diff skipped)\n\n", path)
-			continue
-		}
-
-		patch := diff.ExpandedUnifiedDiff(path, string(oldData), string(newData), defaultMaxDiffContext)
-		lines := splitNonEmptyLines(patch)
-		if len(lines) > remainingDiffLines {
-			patch = diff.ExpandedUnifiedDiff(path, string(oldData), string(newData), fallbackMaxDiffContext)
-			lines = splitNonEmptyLines(patch)
-		}
-		if len(lines) > remainingDiffLines {
-			fmt.Fprintf(&sb, "### %s (diff omitted due to max-diff-lines limit)\n\n", path)
-			continue
-		}
-		fmt.Fprintf(&sb, "### %s\n%s\n", path, patch)
-		remainingDiffLines -= len(lines)
-	}
-
-	if remainingDiffLines > 0 {
-		for _, path := range result.Added {
-			if remainingDiffLines <= 0 {
-				break
-			}
-			data, err := c.store.Read(mapB[path])
-			if err != nil || !snapshot.IsText(data) {
-				continue
-			}
-			contentLines := splitNonEmptyLines(string(data))
-			if len(contentLines) > remainingDiffLines {
-				continue
-			}
-			fmt.Fprintf(&sb, "### %s (new file)\n%s\n", path, string(data))
-			remainingDiffLines -= len(contentLines)
-		}
+// buildPrompt writes the compare prompt within budget tokens.
+func (c *Comparer) buildPrompt(cellA, cellB *db.Cell, budget int) (string, error) {
+	manifestA, err := c.getManifest(cellA.ID)
+	if err != nil {
+		return "", err
+	}
+	manifestB, err := c.getManifest(cellB.ID)
+	if err != nil {
+		return "", err
 	}
 
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Cell A: %s (branch=%s, msg=%q, loc=%d, files=%d)\n", cellA.ID, cellA.Branch, cellA.Message, cellA.TotalLOC, cellA.TotalFiles)
+	fmt.Fprintf(&sb, "Cell B: %s (branch=%s, msg=%q, loc=%d, files=%d)\n", cellB.ID, cellB.Branch, cellB.Message, cellB.TotalLOC, cellB.TotalFiles)
+	sb.WriteString("Diff direction: A -> B. In each patch, '-' lines are from Cell A and '+' lines are from Cell B.\n")
+	c.writeManifestDiff(&sb, manifestMap(manifestA), manifestMap(manifestB), budget-estimateTokens(sb.String()), c.evalRelevantFiles(cellA.ID, cellB.ID))
 	return sb.String(), nil
 }
 
+func manifestMap(entries []db.ManifestEntry) map[string]string {
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		out[e.Path] = e.Hash
+	}
+	return out
+}
+
 func splitNonEmptyLines(in string) []string {
 	lines := strings.Split(in, "\n")
 	out := make([]string, 0, len(lines))
@@ -203,42 +313,110 @@ func splitNonEmptyLines(in string) []string {
 	return out
 }
 
-func parseCompareResponse(content string) *CompareResult {
-	result := &CompareResult{}
-	lines := strings.Split(content, "\n")
-	section := ""
-	for _, raw := range lines {
-		line := strings.TrimSpace(raw)
-		if line == "" {
-			continue
-		}
-		switch {
-		case strings.HasPrefix(line, "SUMMARY:"):
-			result.Summary = strings.TrimSpace(strings.TrimPrefix(line, "SUMMARY:"))
-			section = "summary"
-		case strings.HasPrefix(line, "WINNER:"):
-			result.Winner = strings.TrimSpace(strings.TrimPrefix(line, "WINNER:"))
-			section = "winner"
-		case strings.HasPrefix(line, "HIGHLIGHTS:"):
-			section = "highlights"
-		case section == "summary":
-			if result.Summary == "" {
-				result.Summary = line
-			} else {
-				result.Summary += " " + line
-			}
-		case section == "highlights" && strings.HasPrefix(line, "-"):
-			highlight := strings.TrimSpace(strings.TrimPrefix(line, "-"))
-			if highlight != "" {
-				result.Highlights = append(result.Highlights, highlight)
-			}
+// compareSchema is the JSON schema for a compare reply. The winner enum is
+// built per pair so providers with strict decoding cannot name another cell.
+func compareSchema(cellAID, cellBID string) json.RawMessage {
+	stringList := map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"summary":       map[string]any{"type": "string"},
+			"winner":        map[string]any{"type": "string", "enum": []string{cellAID, cellBID, WinnerTie}},
+			"winner_reason": map[string]any{"type": "string"},
+			"highlights":    stringList,
+			"risks":         stringList,
+			"files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"path": map[string]any{"type": "string"},
+						"note": map[string]any{"type": "string"},
+					},
+					"required":             []string{"path", "note"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"summary", "winner", "winner_reason", "highlights", "risks", "files"},
+		"additionalProperties": false,
+	}
+	encoded, _ := json.Marshal(schema)
+	return encoded
+}
+
+// parseCompareResponse decodes a structured compare reply and rejects a
+// winner that is not one of the compared cells or "tie".
+func parseCompareResponse(content, cellAID, cellBID string) (*CompareResult, error) {
+	var reply struct {
+		Summary      string        `json:"summary"`
+		Winner       string        `json:"winner"`
+		WinnerReason string        `json:"winner_reason"`
+		Highlights   []string      `json:"highlights"`
+		Risks        []string      `json:"risks"`
+		Files        []db.FileNote `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(content)), &reply); err != nil {
+		return nil, fmt.Errorf("model reply is not valid JSON: %w", err)
+	}
+	summary := strings.TrimSpace(reply.Summary)
+	if summary == "" {
+		return nil, fmt.Errorf("model reply has no summary")
+	}
+	winner := strings.TrimSpace(reply.Winner)
+	switch {
+	case winner == cellAID, winner == cellBID:
+	case strings.EqualFold(winner, WinnerTie):
+		winner = WinnerTie
+	default:
+		return nil, fmt.Errorf("model named winner %q; expected %s, %s, or %s", winner, cellAID, cellBID, WinnerTie)
+	}
+
+	files := make([]db.FileNote, 0, len(reply.Files))
+	for _, f := range reply.Files {
+		path, note := strings.TrimSpace(f.Path), strings.TrimSpace(f.Note)
+		if path != "" && note != "" {
+			files = append(files, db.FileNote{Path: path, Note: note})
 		}
 	}
+	return &CompareResult{
+		Summary:      summary,
+		Winner:       winner,
+		WinnerReason: strings.TrimSpace(reply.WinnerReason),
+		Highlights:   nonEmptyStrings(reply.Highlights),
+		Risks:        nonEmptyStrings(reply.Risks),
+		Files:        files,
+	}, nil
+}
 
-	if result.Summary == "" {
-		trimmed := strings.TrimSpace(content)
-		result.Summary = trimmed
+// stripCodeFence tolerates a reply wrapped in a ```json fence, or a JSON
+// object surrounded by prose, which models without enforced JSON output
+// sometimes produce.
+func stripCodeFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "```") {
+		trimmed = strings.TrimPrefix(trimmed, "```")
+		if nl := strings.IndexByte(trimmed, '\n'); nl >= 0 {
+			trimmed = trimmed[nl+1:]
+		}
+		trimmed = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(trimmed), "```"))
+	}
+	if strings.HasPrefix(trimmed, "{") {
+		return trimmed
 	}
+	start, end := strings.IndexByte(trimmed, '{'), strings.LastIndexByte(trimmed, '}')
+	if start < 0 || end < start {
+		return trimmed
+	}
+	return trimmed[start : end+1]
+}
 
-	return result
+func nonEmptyStrings(items []string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
 }
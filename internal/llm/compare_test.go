@@ -1,25 +1,65 @@
 package llm
 
 import (
+	"context"
+	"encoding/json"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/prit3010/converge/internal/config"
 	"github.com/prit3010/converge/internal/db"
 	"github.com/prit3010/converge/internal/store"
 )
 
 func TestParseCompareResponse(t *testing.T) {
-	content := "SUMMARY: Cell B simplifies auth and keeps tests green.\nWINNER: c_000002 - cleaner middleware architecture\nHIGHLIGHTS:\n- Extracted inline checks into middleware\n- Removed duplicate logic\n- Kept test pass rate"
-	result := parseCompareResponse(content)
-	if !strings.Contains(result.Summary, "simplifies auth") {
-		t.Fatalf("unexpected summary: %q", result.Summary)
+	content := "```json\n" + `{"summary":"Cell B simplifies auth and keeps tests green.","winner":"c_000002","winner_reason":"cleaner middleware architecture","highlights":["Extracted inline checks into middleware","Removed duplicate logic"," "],"risks":["Token refresh path is untested"],"files":[{"path":"auth.go","note":"moves checks into middleware"},{"path":"","note":"dropped"}]}` + "\n```"
+	result, err := parseCompareResponse(content, "c_000001", "c_000002")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !strings.Contains(result.Summary, "simplifies auth") || result.Winner != "c_000002" || result.WinnerReason != "cleaner middleware architecture" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Highlights) != 2 || len(result.Risks) != 1 {
+		t.Fatalf("unexpected highlights/risks: %+v", result)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "auth.go" {
+		t.Fatalf("unexpected file notes: %+v", result.Files)
+	}
+
+	tie, err := parseCompareResponse(`{"summary":"Equivalent.","winner":"TIE","winner_reason":"","highlights":[],"risks":[],"files":[]}`, "c_000001", "c_000002")
+	if err != nil || tie.Winner != WinnerTie {
+		t.Fatalf("expected tie, got %+v (%v)", tie, err)
+	}
+}
+
+func TestParseCompareResponseRejectsInvalidReplies(t *testing.T) {
+	cases := map[string]string{
+		"free text":      "SUMMARY: Cell B is better.\nWINNER: c_000002 - cleaner",
+		"unknown winner": `{"summary":"B is better.","winner":"c_000009","winner_reason":"","highlights":[],"risks":[],"files":[]}`,
+		"no summary":     `{"summary":" ","winner":"tie","winner_reason":"","highlights":[],"risks":[],"files":[]}`,
+	}
+	for name, content := range cases {
+		if _, err := parseCompareResponse(content, "c_000001", "c_000002"); err == nil {
+			t.Fatalf("%s: expected parse error", name)
+		}
 	}
-	if !strings.Contains(result.Winner, "c_000002") {
-		t.Fatalf("unexpected winner: %q", result.Winner)
+}
+
+func TestCompareSchemaRestrictsWinner(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			Winner struct {
+				Enum []string `json:"enum"`
+			} `json:"winner"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(compareSchema("c_000001", "c_000002"), &schema); err != nil {
+		t.Fatalf("decode schema: %v", err)
 	}
-	if len(result.Highlights) != 3 {
-		t.Fatalf("expected 3 highlights, got %d", len(result.Highlights))
+	if got := strings.Join(schema.Properties.Winner.Enum, ","); got != "c_000001,c_000002,tie" {
+		t.Fatalf("unexpected winner enum %q", got)
 	}
 }
 
@@ -52,8 +92,8 @@ func TestBuildPromptIncludesMetadataAndDiffs(t *testing.T) {
 		t.Fatalf("insert c2: %v", err)
 	}
 
-	comparer := NewComparer(database, objectStore)
-	prompt, err := comparer.buildPrompt(&c1, &c2, CompareOptions{MaxDiffLines: 200})
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	prompt, err := comparer.buildPrompt(&c1, &c2, 2000)
 	if err != nil {
 		t.Fatalf("build prompt: %v", err)
 	}
@@ -71,3 +111,27 @@ func TestBuildPromptIncludesMetadataAndDiffs(t *testing.T) {
 func strPtr(v string) *string {
 	return &v
 }
+
+func TestCompareAgainstWorktreeIsNotStored(t *testing.T) {
+	database, objectStore := newCompareFixture(t)
+	overlay := store.NewOverlay(objectStore)
+	hash, _ := overlay.Write([]byte("package main\n\nfunc main() {\n\tprintln(\"wt\")\n}\n"))
+	wt := db.Cell{ID: "WORKTREE", ParentID: strPtr("c_000002"), Message: "uncommitted working tree", Source: "worktree", Branch: "main"}
+
+	stub := &stubProvider{reply: `{"summary":"Prints wt.","winner":"tie","winner_reason":"same","highlights":[],"risks":[],"files":[]}`}
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	comparer.SetProvider(stub)
+	comparer.AddCell(wt, []db.ManifestEntry{{CellID: wt.ID, Path: "main.go", Hash: hash}}, overlay)
+
+	for i := 0; i < 2; i++ {
+		if _, err := comparer.Compare(context.Background(), "c_000002", "WORKTREE", CompareOptions{}); err != nil {
+			t.Fatalf("compare: %v", err)
+		}
+	}
+	if len(stub.prompts) != 2 || !strings.Contains(stub.prompts[0], `+	println("wt")`) {
+		t.Fatalf("expected two uncached calls with the worktree diff, got %d: %v", len(stub.prompts), stub.prompts)
+	}
+	if stored, err := database.ListComparisonsForCell("c_000002"); err != nil || len(stored) != 0 {
+		t.Fatalf("expected nothing stored, got %+v (%v)", stored, err)
+	}
+}
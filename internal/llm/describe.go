@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+const (
+	describeSystemPrompt = "You write commit-style messages for code snapshots. Given the diff of a snapshot against its parent ('-' lines are from the parent, '+' lines are from the snapshot), reply with JSON only: summary, a single imperative line under 72 characters describing what changed. Do not mention cell IDs and do not infer edits that are not shown."
+	describeSchemaName   = "cell_summary"
+	// describeMaxTokens keeps summaries cheap; a one-line message rarely
+	// needs the whole diff.
+	describeMaxTokens = 6000
+	maxSummaryRunes   = 100
+)
+
+type DescribeOptions struct {
+	Model string
+	// MaxTokens is the whole prompt's budget; zero uses the smaller of the
+	// model's budget and describeMaxTokens.
+	MaxTokens int
+	// ShowPrompt returns the exact request in DescribeResult.Prompt.
+	ShowPrompt bool
+	// NoStore returns the summary without saving it to the cell.
+	NoStore bool
+}
+
+type DescribeResult struct {
+	CellID   string      `json:"cell_id"`
+	Summary  string      `json:"summary"`
+	Provider string      `json:"provider,omitempty"`
+	Model    string      `json:"model,omitempty"`
+	Usage    *Usage      `json:"usage,omitempty"`
+	Prompt   *SentPrompt `json:"prompt,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Describe summarizes a cell's diff against its parent into a one-line
+// message and stores it in the cell's summary field. The user's message is
+// left untouched.
+func (c *Comparer) Describe(ctx context.Context, cellID string, opts DescribeOptions) (*DescribeResult, error) {
+	model, err := c.resolveModel(opts.Model)
+	if err != nil {
+		return &DescribeResult{CellID: cellID, Error: err.Error()}, err
+	}
+	cell, err := c.db.GetCell(cellID)
+	if err != nil {
+		return nil, fmt.Errorf("cell %s not found", cellID)
+	}
+	schema := describeSchema()
+	budget := opts.MaxTokens
+	if budget <= 0 {
+		budget = min(promptBudget(model, 0), describeMaxTokens)
+	}
+	prompt, err := c.buildDescribePrompt(cell, budget-estimateTokens(describeSystemPrompt)-estimateTokens(string(schema)))
+	if err != nil {
+		return nil, fmt.Errorf("build describe prompt: %w", err)
+	}
+	var sent *SentPrompt
+	if opts.ShowPrompt {
+		sent = newSentPrompt(describeSystemPrompt, prompt, schema, budget)
+	}
+
+	provider, err := c.resolveProvider()
+	if err != nil {
+		return &DescribeResult{CellID: cell.ID, Prompt: sent, Error: err.Error()}, err
+	}
+	resp, err := provider.Complete(ctx, ChatRequest{
+		Model:       model,
+		System:      describeSystemPrompt,
+		Prompt:      prompt,
+		Temperature: 0.2,
+		SchemaName:  describeSchemaName,
+		Schema:      schema,
+	})
+	if err != nil {
+		res := &DescribeResult{CellID: cell.ID, Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s describe call: %w", provider.Name(), err)
+	}
+	summary, err := parseDescribeResponse(resp.Content)
+	if err != nil {
+		res := &DescribeResult{CellID: cell.ID, Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s describe reply: %w", provider.Name(), err)
+	}
+
+	result := &DescribeResult{CellID: cell.ID, Summary: summary, Provider: provider.Name(), Model: model, Prompt: sent}
+	if resp.PromptTokens != nil || resp.CompletionTokens != nil {
+		result.Usage = &Usage{PromptTokens: resp.PromptTokens, CompletionTokens: resp.CompletionTokens}
+	}
+	if !opts.NoStore {
+		if err := c.db.SetCellSummary(cell.ID, &summary); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Comparer) buildDescribePrompt(cell *db.Cell, budget int) (string, error) {
+	parentMap := map[string]string{}
+	parentID := ""
+	var sb strings.Builder
+	if cell.ParentID != nil {
+		manifest, err := c.db.GetManifest(*cell.ParentID)
+		if err != nil {
+			return "", err
+		}
+		parentMap = manifestMap(manifest)
+		parentID = *cell.ParentID
+		fmt.Fprintf(&sb, "Parent message: %q\n", cellMessageForPrompt(c.db, *cell.ParentID))
+	} else {
+		sb.WriteString("This is the first snapshot; every file is new.\n")
+	}
+	fmt.Fprintf(&sb, "Snapshot source: %s, branch: %s\n", cell.Source, cell.Branch)
+	manifest, err := c.db.GetManifest(cell.ID)
+	if err != nil {
+		return "", err
+	}
+	c.writeManifestDiff(&sb, parentMap, manifestMap(manifest), budget-estimateTokens(sb.String()), c.evalRelevantFiles(parentID, cell.ID))
+	return sb.String(), nil
+}
+
+func cellMessageForPrompt(database *db.DB, cellID string) string {
+	cell, err := database.GetCell(cellID)
+	if err != nil {
+		return ""
+	}
+	if cell.Summary != nil && strings.TrimSpace(*cell.Summary) != "" {
+		return *cell.Summary
+	}
+	return cell.Message
+}
+
+func describeSchema() json.RawMessage {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+		},
+		"required":             []string{"summary"},
+		"additionalProperties": false,
+	}
+	encoded, _ := json.Marshal(schema)
+	return encoded
+}
+
+// parseDescribeResponse flattens the reply to a single line and caps its
+// length so it fits a log row.
+func parseDescribeResponse(content string) (string, error) {
+	var reply struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(content)), &reply); err != nil {
+		return "", fmt.Errorf("model reply is not valid JSON: %w", err)
+	}
+	summary := strings.Join(strings.Fields(reply.Summary), " ")
+	if summary == "" {
+		return "", fmt.Errorf("model reply has no summary")
+	}
+	if runes := []rune(summary); len(runes) > maxSummaryRunes {
+		summary = strings.TrimSpace(string(runes[:maxSummaryRunes-3])) + "..."
+	}
+	return summary, nil
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+func TestDescribeStoresSummaryWithoutTouchingMessage(t *testing.T) {
+	database, objectStore := newCompareFixture(t)
+	stub := &stubProvider{reply: `{"summary":"  Print b instead of a\nin main  "}`}
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	comparer.SetProvider(stub)
+
+	result, err := comparer.Describe(context.Background(), "c_000002", DescribeOptions{})
+	if err != nil {
+		t.Fatalf("describe: %v", err)
+	}
+	if result.Summary != "Print b instead of a in main" {
+		t.Fatalf("unexpected summary %q", result.Summary)
+	}
+	if !strings.Contains(stub.prompts[0], `Parent message: "base"`) || !strings.Contains(stub.prompts[0], `+	println("b")`) {
+		t.Fatalf("prompt missing parent diff: %s", stub.prompts[0])
+	}
+	cell, err := database.GetCell("c_000002")
+	if err != nil {
+		t.Fatalf("get cell: %v", err)
+	}
+	if cell.Message != "update" || cell.Summary == nil || *cell.Summary != result.Summary {
+		t.Fatalf("expected summary stored beside message, got %+v", cell)
+	}
+}
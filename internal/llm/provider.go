@@ -0,0 +1,346 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/prit3010/converge/internal/config"
+)
+
+const (
+	defaultAnthropicModel   = "claude-3-5-haiku-latest"
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	anthropicAPIVersion     = "2023-06-01"
+	defaultAnthropicTokens  = 2048
+
+	defaultOllamaModel   = "llama3.1"
+	defaultOllamaBaseURL = "http://localhost:11434"
+)
+
+// ChatRequest is a single-turn prompt with a system instruction. When Schema
+// is set the reply must be a JSON object matching it; providers enforce this
+// with their native structured-output mechanism.
+type ChatRequest struct {
+	Model       string
+	System      string
+	Prompt      string
+	Temperature float32
+	SchemaName  string
+	Schema      json.RawMessage
+}
+
+// ChatResponse is the model reply plus token usage when the backend reports it.
+type ChatResponse struct {
+	Content          string
+	PromptTokens     *int
+	CompletionTokens *int
+}
+
+// Provider sends a chat prompt to a model backend and returns the reply.
+type Provider interface {
+	Name() string
+	DefaultModel() string
+	Complete(ctx context.Context, req ChatRequest) (ChatResponse, error)
+}
+
+// NewProvider builds the provider selected by policy. API keys are read from
+// the environment: OPENAI_API_KEY, ANTHROPIC_API_KEY, or policy.APIKeyEnv.
+func NewProvider(policy config.LLMPolicy) (Provider, error) {
+	httpClient := http.DefaultClient
+	switch policy.Provider {
+	case "", config.LLMProviderOpenAI:
+		key, err := requireAPIKey(policy.APIKeyEnv, "OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		cfg := openai.DefaultConfig(key)
+		if policy.BaseURL != "" {
+			cfg.BaseURL = policy.BaseURL
+		}
+		return &openAIProvider{name: string(config.LLMProviderOpenAI), client: openai.NewClientWithConfig(cfg)}, nil
+	case config.LLMProviderOpenAICompatible:
+		if policy.BaseURL == "" {
+			return nil, fmt.Errorf("llm.base_url is required for provider %q", policy.Provider)
+		}
+		// Local servers such as llama.cpp usually need no key.
+		key := ""
+		if policy.APIKeyEnv != "" {
+			key = strings.TrimSpace(os.Getenv(policy.APIKeyEnv))
+		}
+		cfg := openai.DefaultConfig(key)
+		cfg.BaseURL = policy.BaseURL
+		return &openAIProvider{name: string(config.LLMProviderOpenAICompatible), client: openai.NewClientWithConfig(cfg)}, nil
+	case config.LLMProviderAnthropic:
+		key, err := requireAPIKey(policy.APIKeyEnv, "ANTHROPIC_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		return &anthropicProvider{baseURL: baseURLOr(policy.BaseURL, defaultAnthropicBaseURL), apiKey: key, http: httpClient}, nil
+	case config.LLMProviderOllama:
+		return &ollamaProvider{baseURL: baseURLOr(policy.BaseURL, defaultOllamaBaseURL), http: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider %q", policy.Provider)
+	}
+}
+
+func providerLabel(provider config.LLMProvider) string {
+	if provider == "" {
+		return string(config.LLMProviderOpenAI)
+	}
+	return string(provider)
+}
+
+// defaultModelFor is the model used when neither --model nor llm.model is
+// set. OpenAI-compatible servers have no sensible default.
+func defaultModelFor(provider config.LLMProvider) string {
+	switch provider {
+	case "", config.LLMProviderOpenAI:
+		return defaultCompareModel
+	case config.LLMProviderAnthropic:
+		return defaultAnthropicModel
+	case config.LLMProviderOllama:
+		return defaultOllamaModel
+	default:
+		return ""
+	}
+}
+
+func requireAPIKey(override, fallback string) (string, error) {
+	name := fallback
+	if override != "" {
+		name = override
+	}
+	key := strings.TrimSpace(os.Getenv(name))
+	if key == "" {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return key, nil
+}
+
+func baseURLOr(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return strings.TrimRight(strings.TrimSpace(value), "/")
+}
+
+type openAIProvider struct {
+	name   string
+	client *openai.Client
+}
+
+func (p *openAIProvider) Name() string         { return p.name }
+func (p *openAIProvider) DefaultModel() string { return defaultModelFor(config.LLMProvider(p.name)) }
+
+func (p *openAIProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	chatReq := openai.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: req.System},
+			{Role: openai.ChatMessageRoleUser, Content: req.Prompt},
+		},
+		Temperature: req.Temperature,
+	}
+	if len(req.Schema) > 0 {
+		// Strict schemas are an OpenAI feature; compatible servers get the
+		// schema as a hint they may apply loosely.
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.SchemaName,
+				Schema: req.Schema,
+				Strict: p.name == string(config.LLMProviderOpenAI),
+			},
+		}
+	}
+	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil && len(req.Schema) > 0 && isRejectedRequest(err) {
+		// Many servers (and older OpenAI models) reject json_schema. Retry in
+		// plain JSON mode with the schema spelled out in the instructions; the
+		// reply is parsed as text either way.
+		chatReq.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+		chatReq.Messages[0].Content = req.System + "\n\nReply with a single JSON object matching this JSON schema:\n" + string(req.Schema)
+		resp, err = p.client.CreateChatCompletion(ctx, chatReq)
+	}
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("%s chat completion: %w", p.name, err)
+	}
+	if len(resp.Choices) == 0 {
+		return ChatResponse{}, fmt.Errorf("model returned no choices")
+	}
+	return ChatResponse{
+		Content:          resp.Choices[0].Message.Content,
+		PromptTokens:     positiveInt(resp.Usage.PromptTokens),
+		CompletionTokens: positiveInt(resp.Usage.CompletionTokens),
+	}, nil
+}
+
+// isRejectedRequest reports whether the server refused the request itself
+// (400 or 422), as opposed to failing to serve it.
+func isRejectedRequest(err error) bool {
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+type anthropicProvider struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func (p *anthropicProvider) Name() string { return string(config.LLMProviderAnthropic) }
+func (p *anthropicProvider) DefaultModel() string {
+	return defaultModelFor(config.LLMProviderAnthropic)
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	body := map[string]any{
+		"model":       req.Model,
+		"max_tokens":  defaultAnthropicTokens,
+		"system":      req.System,
+		"temperature": req.Temperature,
+		"messages": []map[string]string{
+			{"role": "user", "content": req.Prompt},
+		},
+	}
+	if len(req.Schema) > 0 {
+		// Anthropic has no JSON mode; a single forced tool call carries the
+		// structured reply as its input.
+		body["tools"] = []map[string]any{
+			{"name": req.SchemaName, "description": "Record the structured result.", "input_schema": req.Schema},
+		}
+		body["tool_choice"] = map[string]string{"type": "tool", "name": req.SchemaName}
+	}
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+	var resp struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := postJSON(ctx, p.http, p.baseURL+"/v1/messages", headers, body, &resp); err != nil {
+		return ChatResponse{}, fmt.Errorf("anthropic messages: %w", err)
+	}
+	var sb strings.Builder
+	for _, block := range resp.Content {
+		switch {
+		case block.Type == "tool_use" && len(req.Schema) > 0:
+			sb.Reset()
+			sb.Write(block.Input)
+		case block.Type == "text" && len(req.Schema) == 0:
+			sb.WriteString(block.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return ChatResponse{}, fmt.Errorf("model returned no text")
+	}
+	return ChatResponse{
+		Content:          sb.String(),
+		PromptTokens:     positiveInt(resp.Usage.InputTokens),
+		CompletionTokens: positiveInt(resp.Usage.OutputTokens),
+	}, nil
+}
+
+type ollamaProvider struct {
+	baseURL string
+	http    *http.Client
+}
+
+func (p *ollamaProvider) Name() string         { return string(config.LLMProviderOllama) }
+func (p *ollamaProvider) DefaultModel() string { return defaultModelFor(config.LLMProviderOllama) }
+
+func (p *ollamaProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	body := map[string]any{
+		"model":  req.Model,
+		"stream": false,
+		"messages": []map[string]string{
+			{"role": "system", "content": req.System},
+			{"role": "user", "content": req.Prompt},
+		},
+		"options": map[string]any{"temperature": req.Temperature},
+	}
+	if len(req.Schema) > 0 {
+		body["format"] = req.Schema
+	}
+	var resp struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		PromptEvalCount int `json:"prompt_eval_count"`
+		EvalCount       int `json:"eval_count"`
+	}
+	if err := postJSON(ctx, p.http, p.baseURL+"/api/chat", nil, body, &resp); err != nil {
+		return ChatResponse{}, fmt.Errorf("ollama chat: %w", err)
+	}
+	if strings.TrimSpace(resp.Message.Content) == "" {
+		return ChatResponse{}, fmt.Errorf("model returned no text")
+	}
+	return ChatResponse{
+		Content:          resp.Message.Content,
+		PromptTokens:     positiveInt(resp.PromptEvalCount),
+		CompletionTokens: positiveInt(resp.EvalCount),
+	}, nil
+}
+
+func positiveInt(v int) *int {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
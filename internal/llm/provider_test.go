@@ -0,0 +1,316 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/store"
+)
+
+const fakeReply = `{"summary":"Cell B prints b.","winner":"c_000002","winner_reason":"clearer output","highlights":["Changed println argument"],"risks":[],"files":[{"path":"main.go","note":"prints b instead of a"}]}`
+
+func TestAnthropicProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing anthropic headers: %v", r.Header)
+		}
+		var body struct {
+			Model    string `json:"model"`
+			System   string `json:"system"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.Model != "claude-test" || body.System != "sys" || len(body.Messages) != 1 || body.Messages[0].Content != "hello" {
+			t.Errorf("unexpected body: %+v", body)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"hi there"}],"usage":{"input_tokens":42,"output_tokens":7}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	provider, err := NewProvider(config.LLMPolicy{Provider: config.LLMProviderAnthropic, BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	got, err := provider.Complete(context.Background(), ChatRequest{Model: "claude-test", System: "sys", Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got.Content != "hi there" {
+		t.Fatalf("unexpected reply %q", got.Content)
+	}
+	if got.PromptTokens == nil || *got.PromptTokens != 42 || got.CompletionTokens == nil || *got.CompletionTokens != 7 {
+		t.Fatalf("unexpected usage: %+v", got)
+	}
+}
+
+func TestAnthropicProviderRequiresKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := NewProvider(config.LLMPolicy{Provider: config.LLMProviderAnthropic}); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestAnthropicProviderForcesSchemaTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Tools []struct {
+				Name        string         `json:"name"`
+				InputSchema map[string]any `json:"input_schema"`
+			} `json:"tools"`
+			ToolChoice map[string]string `json:"tool_choice"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if len(body.Tools) != 1 || body.Tools[0].Name != "result" || body.ToolChoice["name"] != "result" || body.Tools[0].InputSchema["type"] != "object" {
+			t.Errorf("unexpected tool setup: %+v", body)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Here you go"},{"type":"tool_use","name":"result","input":{"ok":true}}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	provider, err := NewProvider(config.LLMPolicy{Provider: config.LLMProviderAnthropic, BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	got, err := provider.Complete(context.Background(), ChatRequest{
+		Model: "claude-test", Prompt: "hello", SchemaName: "result", Schema: json.RawMessage(`{"type":"object"}`),
+	})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got.Content != `{"ok":true}` {
+		t.Fatalf("expected tool input as content, got %q", got.Content)
+	}
+}
+
+func TestOpenAICompatibleProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer local-key" {
+			t.Errorf("unexpected auth header %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"x","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"compatible reply"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("LOCAL_LLM_KEY", "local-key")
+	provider, err := NewProvider(config.LLMPolicy{
+		Provider:  config.LLMProviderOpenAICompatible,
+		BaseURL:   server.URL + "/v1",
+		APIKeyEnv: "LOCAL_LLM_KEY",
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	got, err := provider.Complete(context.Background(), ChatRequest{
+		Model: "qwen", System: "sys", Prompt: "hello", SchemaName: "result", Schema: json.RawMessage(`{"type":"object"}`),
+	})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got.Content != "compatible reply" {
+		t.Fatalf("unexpected reply %q", got.Content)
+	}
+}
+
+func TestOpenAICompatibleProviderFallsBackToJSONMode(t *testing.T) {
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+			ResponseFormat struct {
+				Type       string `json:"type"`
+				JSONSchema struct {
+					Strict bool `json:"strict"`
+				} `json:"json_schema"`
+			} `json:"response_format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		formats = append(formats, body.ResponseFormat.Type)
+		w.Header().Set("Content-Type", "application/json")
+		if body.ResponseFormat.Type == "json_schema" {
+			if body.ResponseFormat.JSONSchema.Strict {
+				t.Errorf("expected non-strict schema for a compatible server")
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"response_format json_schema is not supported","type":"invalid_request_error"}}`))
+			return
+		}
+		if !strings.Contains(body.Messages[0].Content, `"summary"`) {
+			t.Errorf("expected schema in system prompt, got %q", body.Messages[0].Content)
+		}
+		_, _ = w.Write([]byte(`{"id":"x","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Here you go:\n{\"summary\": \"Adds login\"}\nThanks"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(config.LLMPolicy{Provider: config.LLMProviderOpenAICompatible, BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	got, err := provider.Complete(context.Background(), ChatRequest{
+		Model: "qwen", System: "sys", Prompt: "hello", SchemaName: "summary", Schema: json.RawMessage(`{"type":"object","properties":{"summary":{"type":"string"}}}`),
+	})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if strings.Join(formats, ",") != "json_schema,json_object" {
+		t.Fatalf("expected a json_object retry, got formats %v", formats)
+	}
+	if summary, err := parseDescribeResponse(got.Content); err != nil || summary != "Adds login" {
+		t.Fatalf("expected JSON parsed out of prose, got %q (err %v)", summary, err)
+	}
+}
+
+func TestCompareUsesOllamaProvider(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Stream   bool   `json:"stream"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+			Format map[string]any `json:"format"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if body.Model != "llama-test" || body.Stream || len(body.Messages) != 2 {
+			t.Errorf("unexpected body: %+v", body)
+		}
+		if !strings.Contains(body.Messages[1].Content, "Cell A: c_000001") {
+			t.Errorf("prompt missing cell metadata: %q", body.Messages[1].Content)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message":           map[string]string{"role": "assistant", "content": fakeReply},
+			"prompt_eval_count": 300,
+			"eval_count":        40,
+		})
+	}))
+	defer server.Close()
+
+	database, objectStore := newCompareFixture(t)
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{
+		Provider: config.LLMProviderOllama,
+		Model:    "llama-test",
+		BaseURL:  server.URL,
+	})
+	result, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{})
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if result.Provider != "ollama" || result.Model != "llama-test" {
+		t.Fatalf("unexpected provider/model: %+v", result)
+	}
+	if !strings.Contains(result.Winner, "c_000002") || len(result.Highlights) != 1 {
+		t.Fatalf("unexpected parsed result: %+v", result)
+	}
+	if result.Cached || result.Usage == nil || *result.Usage.PromptTokens != 300 {
+		t.Fatalf("expected fresh result with usage, got %+v", result)
+	}
+
+	again, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{})
+	if err != nil {
+		t.Fatalf("repeat compare: %v", err)
+	}
+	if calls != 1 || !again.Cached || again.Summary != result.Summary || *again.Usage.CompletionTokens != 40 {
+		t.Fatalf("expected stored result on repeat (calls=%d), got %+v", calls, again)
+	}
+
+	if _, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{Refresh: true}); err != nil {
+		t.Fatalf("refresh compare: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected refresh to call the model again, calls=%d", calls)
+	}
+	stored, err := database.ListComparisonsForCell("c_000001")
+	if err != nil {
+		t.Fatalf("list comparisons: %v", err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("expected refresh to replace the stored row, got %d rows", len(stored))
+	}
+
+	if _, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{MaxTokens: 200}); err != nil {
+		t.Fatalf("compare with different budget: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected a different prompt to miss the stored result, calls=%d", calls)
+	}
+}
+
+func TestCompareReportsProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	database, objectStore := newCompareFixture(t)
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{Provider: config.LLMProviderOllama, BaseURL: server.URL})
+	result, err := comparer.Compare(context.Background(), "c_000001", "c_000002", CompareOptions{})
+	if err == nil {
+		t.Fatalf("expected provider error")
+	}
+	if result == nil || !strings.Contains(result.Error, "model not found") {
+		t.Fatalf("expected error text in result, got %+v", result)
+	}
+}
+
+func newCompareFixture(t *testing.T) (*db.DB, *store.Store) {
+	t.Helper()
+	tmp := t.TempDir()
+	database, err := db.Open(filepath.Join(tmp, "converge.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	objectStore := store.New(filepath.Join(tmp, "objects"))
+
+	oldHash, err := objectStore.Write([]byte("package main\n\nfunc main() {\n\tprintln(\"a\")\n}\n"))
+	if err != nil {
+		t.Fatalf("write old object: %v", err)
+	}
+	newHash, err := objectStore.Write([]byte("package main\n\nfunc main() {\n\tprintln(\"b\")\n}\n"))
+	if err != nil {
+		t.Fatalf("write new object: %v", err)
+	}
+	c1 := db.Cell{ID: "c_000001", Sequence: 1, Timestamp: "2026-02-28T00:00:00Z", Message: "base", Source: "manual", Branch: "main", TotalLOC: 4, LOCDelta: 4, TotalFiles: 1}
+	c2 := db.Cell{ID: "c_000002", Sequence: 2, ParentID: strPtr("c_000001"), Timestamp: "2026-02-28T00:00:01Z", Message: "update", Source: "manual", Branch: "main", TotalLOC: 4, LOCDelta: 0, TotalFiles: 1}
+	if err := database.InsertCellWithManifestAndAdvanceBranch(c1, []db.ManifestEntry{{CellID: c1.ID, Path: "main.go", Hash: oldHash, Mode: 0o644, Size: 32}}); err != nil {
+		t.Fatalf("insert c1: %v", err)
+	}
+	if err := database.InsertCellWithManifestAndAdvanceBranch(c2, []db.ManifestEntry{{CellID: c2.ID, Path: "main.go", Hash: newHash, Mode: 0o644, Size: 32}}); err != nil {
+		t.Fatalf("insert c2: %v", err)
+	}
+	return database, objectStore
+}
@@ -0,0 +1,411 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+const rankSystemPrompt = "You are a precise code-change reviewer ranking several attempts at the same task. Each candidate is shown as a diff from a shared base cell: '-' lines are from the base and '+' lines are from the candidate. Judge correctness, clarity, and risk from the diffs and the eval metrics given; do not infer edits that are not shown. Reply with JSON only: summary (2-3 sentences on how the candidates differ) and ranking (every candidate exactly once, best first, each with its cell_id and a one-sentence rationale)."
+
+const rankSchemaName = "cell_ranking"
+
+type RankOptions struct {
+	Model string
+	// MaxTokens is the whole prompt's budget, with the diff share split
+	// evenly across candidates; zero derives it from the model.
+	MaxTokens int
+	// ShowPrompt returns the exact request in RankResult.Prompt.
+	ShowPrompt bool
+	// MetricOrder lists the candidates best-first by eval metrics; when set it
+	// is averaged with the model's order to produce the final positions.
+	MetricOrder []string
+	MetricBasis string
+
+	Refresh bool
+	NoStore bool
+}
+
+type RankResult struct {
+	Summary     string      `json:"summary"`
+	BaseCellID  string      `json:"base_cell_id,omitempty"`
+	MetricBasis string      `json:"metric_basis,omitempty"`
+	Entries     []RankEntry `json:"entries"`
+	Provider    string      `json:"provider,omitempty"`
+	Model       string      `json:"model,omitempty"`
+	Cached      bool        `json:"cached"`
+	CreatedAt   string      `json:"created_at,omitempty"`
+	Usage       *Usage      `json:"usage,omitempty"`
+	Prompt      *SentPrompt `json:"prompt,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+type RankEntry struct {
+	CellID         string `json:"cell_id"`
+	Position       int    `json:"position"`
+	LLMPosition    int    `json:"llm_position"`
+	MetricPosition *int   `json:"metric_position,omitempty"`
+	Rationale      string `json:"rationale"`
+}
+
+// Rank orders two or more cells in a single multi-candidate prompt. Each
+// candidate is diffed against the nearest common ancestor so the model sees
+// comparable changes, and the result is stored like a pairwise compare.
+func (c *Comparer) Rank(ctx context.Context, cellIDs []string, opts RankOptions) (*RankResult, error) {
+	if len(cellIDs) < 2 {
+		return nil, fmt.Errorf("rank needs at least two cells")
+	}
+	model, err := c.resolveModel(opts.Model)
+	if err != nil {
+		return &RankResult{Error: err.Error()}, err
+	}
+
+	cells := make([]*db.Cell, 0, len(cellIDs))
+	seen := make(map[string]bool, len(cellIDs))
+	for _, id := range cellIDs {
+		cell, err := c.getCell(id)
+		if err != nil {
+			return nil, fmt.Errorf("cell %s not found", id)
+		}
+		if seen[cell.ID] {
+			return nil, fmt.Errorf("cell %s listed more than once", cell.ID)
+		}
+		seen[cell.ID] = true
+		cells = append(cells, cell)
+		if c.isExternal(cell.ID) {
+			opts.Refresh, opts.NoStore = true, true
+		}
+	}
+
+	base, err := c.commonAncestor(cells)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		ids = append(ids, cell.ID)
+	}
+	schema := rankSchema(ids)
+	budget := promptBudget(model, opts.MaxTokens)
+	prompt, err := c.buildRankPrompt(base, cells, budget-estimateTokens(rankSystemPrompt)-estimateTokens(string(schema)))
+	if err != nil {
+		return nil, fmt.Errorf("build rank prompt: %w", err)
+	}
+	var sent *SentPrompt
+	if opts.ShowPrompt {
+		sent = newSentPrompt(rankSystemPrompt, prompt, schema, budget)
+	}
+	promptHash := hashPrompt(rankSystemPrompt, string(schema), prompt, opts.MetricBasis, strings.Join(opts.MetricOrder, ","))
+	baseID := ""
+	if base != nil {
+		baseID = base.ID
+	}
+
+	if !opts.Refresh {
+		stored, err := c.db.GetRankingByPrompt(model, promptHash)
+		if err == nil {
+			result := resultFromRanking(stored)
+			result.BaseCellID = baseID
+			result.Prompt = sent
+			return result, nil
+		}
+		if err != db.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	provider, err := c.resolveProvider()
+	if err != nil {
+		return &RankResult{Prompt: sent, Error: err.Error()}, err
+	}
+	resp, err := provider.Complete(ctx, ChatRequest{
+		Model:       model,
+		System:      rankSystemPrompt,
+		Prompt:      prompt,
+		Temperature: 0.2,
+		SchemaName:  rankSchemaName,
+		Schema:      schema,
+	})
+	if err != nil {
+		res := &RankResult{Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s rank call: %w", provider.Name(), err)
+	}
+	result, err := parseRankResponse(resp.Content, ids)
+	if err != nil {
+		res := &RankResult{Provider: provider.Name(), Model: model, Prompt: sent, Error: err.Error()}
+		return res, fmt.Errorf("%s rank reply: %w", provider.Name(), err)
+	}
+	combineRankOrders(result.Entries, opts.MetricOrder)
+	result.BaseCellID = baseID
+	result.Provider = provider.Name()
+	result.Model = model
+	result.Prompt = sent
+	result.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if len(opts.MetricOrder) > 0 {
+		result.MetricBasis = opts.MetricBasis
+	}
+	if resp.PromptTokens != nil || resp.CompletionTokens != nil {
+		result.Usage = &Usage{PromptTokens: resp.PromptTokens, CompletionTokens: resp.CompletionTokens}
+	}
+
+	if !opts.NoStore {
+		entries := make([]db.RankingEntry, 0, len(result.Entries))
+		for _, e := range result.Entries {
+			entries = append(entries, db.RankingEntry{
+				CellID:         e.CellID,
+				Position:       e.Position,
+				LLMPosition:    e.LLMPosition,
+				MetricPosition: e.MetricPosition,
+				Rationale:      e.Rationale,
+			})
+		}
+		if _, err := c.db.SaveRanking(db.Ranking{
+			Model:            model,
+			PromptHash:       promptHash,
+			Provider:         result.Provider,
+			Summary:          result.Summary,
+			MetricBasis:      result.MetricBasis,
+			PromptTokens:     resp.PromptTokens,
+			CompletionTokens: resp.CompletionTokens,
+			CreatedAt:        result.CreatedAt,
+			Entries:          entries,
+		}); err != nil {
+			return result, fmt.Errorf("store ranking: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// ResultFromRanking converts a stored ranking for display.
+func ResultFromRanking(r db.Ranking) *RankResult {
+	return resultFromRanking(&r)
+}
+
+func resultFromRanking(r *db.Ranking) *RankResult {
+	result := &RankResult{
+		Summary:     r.Summary,
+		MetricBasis: r.MetricBasis,
+		Entries:     make([]RankEntry, 0, len(r.Entries)),
+		Provider:    r.Provider,
+		Model:       r.Model,
+		Cached:      true,
+		CreatedAt:   r.CreatedAt,
+	}
+	for _, e := range r.Entries {
+		result.Entries = append(result.Entries, RankEntry{
+			CellID:         e.CellID,
+			Position:       e.Position,
+			LLMPosition:    e.LLMPosition,
+			MetricPosition: e.MetricPosition,
+			Rationale:      e.Rationale,
+		})
+	}
+	if r.PromptTokens != nil || r.CompletionTokens != nil {
+		result.Usage = &Usage{PromptTokens: r.PromptTokens, CompletionTokens: r.CompletionTokens}
+	}
+	return result
+}
+
+// commonAncestor returns the nearest cell that every candidate descends from
+// (a candidate counts as its own ancestor), or nil when the histories do not
+// meet.
+func (c *Comparer) commonAncestor(cells []*db.Cell) (*db.Cell, error) {
+	chains := make([][]*db.Cell, 0, len(cells))
+	for _, cell := range cells {
+		chain := []*db.Cell{cell}
+		for cur := cell; cur.ParentID != nil; {
+			parent, err := c.getCell(*cur.ParentID)
+			if err == db.ErrNotFound {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, parent)
+			cur = parent
+		}
+		chains = append(chains, chain)
+	}
+
+	counts := make(map[string]int)
+	for _, chain := range chains {
+		for _, cell := range chain {
+			counts[cell.ID]++
+		}
+	}
+	for _, cell := range chains[0] {
+		if counts[cell.ID] == len(chains) {
+			return cell, nil
+		}
+	}
+	return nil, nil
+}
+
+// buildRankPrompt writes the rank prompt within budget tokens, giving each
+// candidate an equal share of what the headers leave.
+func (c *Comparer) buildRankPrompt(base *db.Cell, cells []*db.Cell, budget int) (string, error) {
+	baseMap := map[string]string{}
+	baseID := ""
+	var sb strings.Builder
+	if base != nil {
+		manifest, err := c.getManifest(base.ID)
+		if err != nil {
+			return "", err
+		}
+		baseMap = manifestMap(manifest)
+		baseID = base.ID
+		fmt.Fprintf(&sb, "Base: %s (branch=%s, msg=%q, loc=%d, files=%d)\n", base.ID, base.Branch, base.Message, base.TotalLOC, base.TotalFiles)
+	} else {
+		sb.WriteString("Base: none (candidates share no history; diffs are against an empty tree)\n")
+	}
+	fmt.Fprintf(&sb, "Candidates: %d\n\n", len(cells))
+	perCandidate := (budget - estimateTokens(sb.String())) / len(cells)
+
+	for _, cell := range cells {
+		manifest, err := c.getManifest(cell.ID)
+		if err != nil {
+			return "", err
+		}
+		start := sb.Len()
+		fmt.Fprintf(&sb, "## Candidate %s (branch=%s, msg=%q, loc=%d, files=%d)\n", cell.ID, cell.Branch, cell.Message, cell.TotalLOC, cell.TotalFiles)
+		fmt.Fprintf(&sb, "Eval: %s\n", candidateMetrics(cell))
+		if base != nil && base.ID == cell.ID {
+			sb.WriteString("This candidate is the base; it has no changes of its own.\n\n")
+			continue
+		}
+		diffBudget := perCandidate - estimateTokens(sb.String()[start:]) - 1
+		c.writeManifestDiff(&sb, baseMap, manifestMap(manifest), diffBudget, c.evalRelevantFiles(baseID, cell.ID))
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func candidateMetrics(cell *db.Cell) string {
+	parts := []string{
+		"tests_passed=" + optionalInt(cell.TestsPassed),
+		"tests_failed=" + optionalInt(cell.TestsFailed),
+		"lint_errors=" + optionalInt(cell.LintErrors),
+		"type_errors=" + optionalInt(cell.TypeErrors),
+	}
+	if cell.CoveragePct != nil {
+		parts = append(parts, fmt.Sprintf("coverage=%.1f%%", *cell.CoveragePct))
+	}
+	if cell.Score != nil {
+		parts = append(parts, fmt.Sprintf("score=%.2f", *cell.Score))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return "n/a"
+	}
+	return strconv.Itoa(*v)
+}
+
+func rankSchema(cellIDs []string) json.RawMessage {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+			"ranking": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"cell_id":   map[string]any{"type": "string", "enum": cellIDs},
+						"rationale": map[string]any{"type": "string"},
+					},
+					"required":             []string{"cell_id", "rationale"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"summary", "ranking"},
+		"additionalProperties": false,
+	}
+	encoded, _ := json.Marshal(schema)
+	return encoded
+}
+
+// parseRankResponse decodes a structured rank reply and requires every
+// candidate to appear exactly once.
+func parseRankResponse(content string, cellIDs []string) (*RankResult, error) {
+	var reply struct {
+		Summary string `json:"summary"`
+		Ranking []struct {
+			CellID    string `json:"cell_id"`
+			Rationale string `json:"rationale"`
+		} `json:"ranking"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFence(content)), &reply); err != nil {
+		return nil, fmt.Errorf("model reply is not valid JSON: %w", err)
+	}
+	expected := make(map[string]bool, len(cellIDs))
+	for _, id := range cellIDs {
+		expected[id] = true
+	}
+	entries := make([]RankEntry, 0, len(reply.Ranking))
+	for _, item := range reply.Ranking {
+		id := strings.TrimSpace(item.CellID)
+		if !expected[id] {
+			return nil, fmt.Errorf("model ranked %q, which is not a candidate or appears twice", id)
+		}
+		delete(expected, id)
+		entries = append(entries, RankEntry{
+			CellID:      id,
+			LLMPosition: len(entries) + 1,
+			Position:    len(entries) + 1,
+			Rationale:   strings.TrimSpace(item.Rationale),
+		})
+	}
+	if len(expected) > 0 {
+		missing := make([]string, 0, len(expected))
+		for id := range expected {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("model ranking is missing %s", strings.Join(missing, ", "))
+	}
+	return &RankResult{Summary: strings.TrimSpace(reply.Summary), Entries: entries}, nil
+}
+
+// combineRankOrders sets each entry's final position from the mean of its
+// model and metric positions, breaking ties by the model's order, and sorts
+// entries by it. Without a metric order the model's order stands.
+func combineRankOrders(entries []RankEntry, metricOrder []string) {
+	if len(metricOrder) == 0 {
+		return
+	}
+	metricPos := make(map[string]int, len(metricOrder))
+	for i, id := range metricOrder {
+		metricPos[id] = i + 1
+	}
+	for i := range entries {
+		if pos, ok := metricPos[entries[i].CellID]; ok {
+			entries[i].MetricPosition = &pos
+		}
+	}
+	combined := func(e RankEntry) int {
+		if e.MetricPosition == nil {
+			return 2 * e.LLMPosition
+		}
+		return e.LLMPosition + *e.MetricPosition
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ci, cj := combined(entries[i]), combined(entries[j])
+		if ci != cj {
+			return ci < cj
+		}
+		return entries[i].LLMPosition < entries[j].LLMPosition
+	})
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+}
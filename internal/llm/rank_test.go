@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/config"
+	"github.com/prit3010/converge/internal/db"
+)
+
+type stubProvider struct {
+	reply   string
+	prompts []string
+}
+
+func (p *stubProvider) Name() string         { return "stub" }
+func (p *stubProvider) DefaultModel() string { return "stub-model" }
+
+func (p *stubProvider) Complete(_ context.Context, req ChatRequest) (ChatResponse, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	return ChatResponse{Content: p.reply}, nil
+}
+
+func TestRankCombinesModelAndMetricOrder(t *testing.T) {
+	database, objectStore := newCompareFixture(t)
+	hash, err := objectStore.Write([]byte("package main\n\nfunc main() {\n\tprintln(\"c\")\n}\n"))
+	if err != nil {
+		t.Fatalf("write object: %v", err)
+	}
+	c3 := db.Cell{ID: "c_000003", Sequence: 3, ParentID: strPtr("c_000001"), Timestamp: "2026-02-28T00:00:02Z", Message: "other", Source: "manual", Branch: "alt", TotalLOC: 4, TotalFiles: 1}
+	if err := database.InsertCellWithManifest(c3, []db.ManifestEntry{{CellID: c3.ID, Path: "main.go", Hash: hash, Mode: 0o644, Size: 32}}); err != nil {
+		t.Fatalf("insert c3: %v", err)
+	}
+
+	stub := &stubProvider{reply: `{"summary":"Both edit main.","ranking":[{"cell_id":"c_000003","rationale":"prints c"},{"cell_id":"c_000002","rationale":"prints b"}]}`}
+	comparer := NewComparer(database, objectStore, config.LLMPolicy{})
+	comparer.SetProvider(stub)
+
+	result, err := comparer.Rank(context.Background(), []string{"c_000002", "c_000003"}, RankOptions{
+		MetricOrder: []string{"c_000002", "c_000003"},
+		MetricBasis: "heuristic",
+	})
+	if err != nil {
+		t.Fatalf("rank: %v", err)
+	}
+	if result.BaseCellID != "c_000001" || !strings.Contains(stub.prompts[0], "Base: c_000001") {
+		t.Fatalf("expected c_000001 as base, got %q", result.BaseCellID)
+	}
+	if !strings.Contains(stub.prompts[0], "## Candidate c_000003") || !strings.Contains(stub.prompts[0], "println(\"c\")") {
+		t.Fatalf("prompt missing candidate diff: %s", stub.prompts[0])
+	}
+	// Tied on combined position (1+2 each), so the model's order wins.
+	if len(result.Entries) != 2 || result.Entries[0].CellID != "c_000003" || result.Entries[0].MetricPosition == nil || *result.Entries[0].MetricPosition != 2 {
+		t.Fatalf("unexpected entries: %+v", result.Entries)
+	}
+
+	again, err := comparer.Rank(context.Background(), []string{"c_000002", "c_000003"}, RankOptions{
+		MetricOrder: []string{"c_000002", "c_000003"},
+		MetricBasis: "heuristic",
+	})
+	if err != nil {
+		t.Fatalf("repeat rank: %v", err)
+	}
+	if len(stub.prompts) != 1 || !again.Cached || again.Entries[0].CellID != "c_000003" {
+		t.Fatalf("expected stored ranking on repeat, got %+v", again)
+	}
+	stored, err := database.ListRankingsForCell("c_000002")
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("expected one stored ranking, got %+v (%v)", stored, err)
+	}
+}
+
+func TestParseRankResponseRequiresEveryCandidate(t *testing.T) {
+	ids := []string{"c_000001", "c_000002"}
+	cases := map[string]string{
+		"missing":   `{"summary":"x","ranking":[{"cell_id":"c_000001","rationale":"a"}]}`,
+		"duplicate": `{"summary":"x","ranking":[{"cell_id":"c_000001","rationale":"a"},{"cell_id":"c_000001","rationale":"b"}]}`,
+		"unknown":   `{"summary":"x","ranking":[{"cell_id":"c_000009","rationale":"a"},{"cell_id":"c_000001","rationale":"b"}]}`,
+	}
+	for name, content := range cases {
+		if _, err := parseRankResponse(content, ids); err == nil {
+			t.Fatalf("%s: expected parse error", name)
+		}
+	}
+}
+
+func TestCombineRankOrdersUsesMetricPositions(t *testing.T) {
+	entries := []RankEntry{
+		{CellID: "a", LLMPosition: 1},
+		{CellID: "b", LLMPosition: 2},
+		{CellID: "c", LLMPosition: 3},
+	}
+	combineRankOrders(entries, []string{"c", "b", "a"})
+	got := []string{entries[0].CellID, entries[1].CellID, entries[2].CellID}
+	// a: 1+3, b: 2+2, c: 3+1 all tie at 4, so the model's order stands.
+	if strings.Join(got, ",") != "a,b,c" || entries[2].Position != 3 {
+		t.Fatalf("unexpected tie handling: %+v", entries)
+	}
+
+	entries = []RankEntry{
+		{CellID: "a", LLMPosition: 1},
+		{CellID: "b", LLMPosition: 2},
+		{CellID: "c", LLMPosition: 3},
+	}
+	combineRankOrders(entries, []string{"b", "c", "a"})
+	if entries[0].CellID != "b" || entries[0].Position != 1 {
+		t.Fatalf("expected b first after combining, got %+v", entries)
+	}
+}
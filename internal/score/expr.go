@@ -0,0 +1,380 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The expression language is arithmetic over metric names:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | metric | call | "(" expr ")"
+//	call    = name "(" [ arg { "," arg } ] ")"
+//
+// Functions: min, max, abs, default(x, fallback), and bench("name"[, "unit"]).
+// String literals are only valid as bench arguments.
+
+type node interface {
+	eval(m Metrics) (float64, bool)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(Metrics) (float64, bool) { return float64(n), true }
+
+type metricNode string
+
+func (n metricNode) eval(m Metrics) (float64, bool) { return m.value(string(n)) }
+
+type negNode struct{ x node }
+
+func (n negNode) eval(m Metrics) (float64, bool) {
+	v, ok := n.x.eval(m)
+	return -v, ok
+}
+
+type binaryNode struct {
+	op   byte
+	l, r node
+}
+
+func (n binaryNode) eval(m Metrics) (float64, bool) {
+	l, ok := n.l.eval(m)
+	if !ok {
+		return 0, false
+	}
+	r, ok := n.r.eval(m)
+	if !ok {
+		return 0, false
+	}
+	switch n.op {
+	case '+':
+		return l + r, true
+	case '-':
+		return l - r, true
+	case '*':
+		return l * r, true
+	default:
+		if r == 0 {
+			return 0, false
+		}
+		return l / r, true
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(m Metrics) (float64, bool) {
+	if n.name == "default" {
+		if v, ok := n.args[0].eval(m); ok {
+			return v, true
+		}
+		return n.args[1].eval(m)
+	}
+	values := make([]float64, 0, len(n.args))
+	for _, arg := range n.args {
+		v, ok := arg.eval(m)
+		if !ok {
+			return 0, false
+		}
+		values = append(values, v)
+	}
+	switch n.name {
+	case "abs":
+		return math.Abs(values[0]), true
+	case "min":
+		out := values[0]
+		for _, v := range values[1:] {
+			out = math.Min(out, v)
+		}
+		return out, true
+	default:
+		out := values[0]
+		for _, v := range values[1:] {
+			out = math.Max(out, v)
+		}
+		return out, true
+	}
+}
+
+type benchNode struct {
+	name string
+	unit string
+}
+
+func (n benchNode) eval(m Metrics) (float64, bool) { return m.benchMean(n.name, n.unit) }
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	out := make([]token, 0)
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			out = append(out, token{kind: tokNumber, text: src[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			out = append(out, token{kind: tokIdent, text: src[start:i], pos: start})
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			out = append(out, token{kind: tokString, text: src[start+1 : i], pos: start})
+			i++
+		case strings.ContainsRune("+-*/(),", c):
+			out = append(out, token{kind: tokPunct, text: string(c), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+	}
+	out = append(out, token{kind: tokEOF, pos: len(src)})
+	return out, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) punct(text string) bool {
+	tok := p.peek()
+	if tok.kind == tokPunct && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if p.punct(text) {
+		return nil
+	}
+	tok := p.peek()
+	if tok.kind == tokEOF {
+		return fmt.Errorf("expected %q at end of expression", text)
+	}
+	return fmt.Errorf("expected %q at %d, got %q", text, tok.pos, tok.text)
+}
+
+func (p *parser) expr() (node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.punct("+"):
+			op = '+'
+		case p.punct("-"):
+			op = '-'
+		default:
+			return left, nil
+		}
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) term() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.punct("*"):
+			op = '*'
+		case p.punct("/"):
+			op = '/'
+		default:
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, l: left, r: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if p.punct("-") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", tok.text, tok.pos)
+		}
+		return numberNode(v), nil
+	case tokIdent:
+		if p.punct("(") {
+			return p.call(tok)
+		}
+		if !knownMetrics[tok.text] {
+			return nil, fmt.Errorf("unknown metric %q at %d", tok.text, tok.pos)
+		}
+		return metricNode(tok.text), nil
+	case tokPunct:
+		if tok.text == "(" {
+			n, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	case tokString:
+		return nil, fmt.Errorf("string %q at %d is only valid as a bench argument", tok.text, tok.pos)
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+func (p *parser) call(name token) (node, error) {
+	if name.text == "bench" {
+		return p.benchCall(name)
+	}
+
+	args := make([]node, 0)
+	if !p.punct(")") {
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.punct(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch name.text {
+	case "abs":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("abs takes 1 argument, got %d", len(args))
+		}
+	case "default":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("default takes 2 arguments, got %d", len(args))
+		}
+	case "min", "max":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s needs at least 1 argument", name.text)
+		}
+	default:
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+	return callNode{name: name.text, args: args}, nil
+}
+
+func (p *parser) benchCall(name token) (node, error) {
+	args := make([]string, 0, 2)
+	for {
+		tok := p.next()
+		if tok.kind != tokString {
+			return nil, fmt.Errorf("bench expects string arguments at %d", tok.pos)
+		}
+		args = append(args, tok.text)
+		if p.punct(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 2 || strings.TrimSpace(args[0]) == "" {
+		return nil, fmt.Errorf("bench takes a name and an optional unit at %d", name.pos)
+	}
+	n := benchNode{name: strings.TrimSpace(args[0])}
+	if len(args) == 2 {
+		n.unit = strings.TrimSpace(args[1])
+	}
+	return n, nil
+}
@@ -0,0 +1,178 @@
+package score
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/prit3010/converge/internal/bench"
+)
+
+// Metric names available to weights and expressions.
+const (
+	TestsPassed   = "tests_passed"
+	TestsFailed   = "tests_failed"
+	TestsTotal    = "tests_total"
+	PassRate      = "pass_rate"
+	LintErrors    = "lint_errors"
+	TypeErrors    = "type_errors"
+	Coverage      = "coverage"
+	TotalLOC      = "total_loc"
+	LOCDelta      = "loc_delta"
+	TotalFiles    = "total_files"
+	FilesAdded    = "files_added"
+	FilesModified = "files_modified"
+	FilesRemoved  = "files_removed"
+	LinesAdded    = "lines_added"
+	LinesRemoved  = "lines_removed"
+)
+
+var knownMetrics = map[string]bool{
+	TestsPassed:   true,
+	TestsFailed:   true,
+	TestsTotal:    true,
+	PassRate:      true,
+	LintErrors:    true,
+	TypeErrors:    true,
+	Coverage:      true,
+	TotalLOC:      true,
+	LOCDelta:      true,
+	TotalFiles:    true,
+	FilesAdded:    true,
+	FilesModified: true,
+	FilesRemoved:  true,
+	LinesAdded:    true,
+	LinesRemoved:  true,
+}
+
+// benchWeightPrefix marks a weight key that refers to a benchmark metric, as
+// in "bench:BenchmarkParse" or "bench:BenchmarkParse:ns/op".
+const benchWeightPrefix = "bench:"
+
+// MetricNames returns the plain metric names in sorted order.
+func MetricNames() []string {
+	out := make([]string, 0, len(knownMetrics))
+	for name := range knownMetrics {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Metrics holds the inputs for scoring one cell. A name missing from Values
+// is unavailable for that cell (for example coverage when it was not
+// collected).
+type Metrics struct {
+	Values map[string]float64
+	Bench  []bench.Summary
+}
+
+func (m Metrics) value(name string) (float64, bool) {
+	v, ok := m.Values[name]
+	return v, ok
+}
+
+// benchMean returns the mean of the named benchmark. With an empty unit the
+// first recorded unit wins, which for Go benchmarks is ns/op.
+func (m Metrics) benchMean(name, unit string) (float64, bool) {
+	for _, s := range m.Bench {
+		if s.Name == name && (unit == "" || s.Unit == unit) {
+			return s.Mean, true
+		}
+	}
+	return 0, false
+}
+
+// Scorer computes a single number per cell from either a weighted sum of
+// metrics or an expression. Higher scores are better.
+type Scorer struct {
+	expr    node
+	weights []weight
+}
+
+type weight struct {
+	metric string
+	bench  string
+	unit   string
+	factor float64
+}
+
+// New compiles a scorer. An expression takes precedence over weights; it
+// returns nil when neither is set.
+func New(expression string, weights map[string]float64) (*Scorer, error) {
+	expression = strings.TrimSpace(expression)
+	if expression != "" {
+		expr, err := parse(expression)
+		if err != nil {
+			return nil, fmt.Errorf("parse score expression: %w", err)
+		}
+		return &Scorer{expr: expr}, nil
+	}
+	if len(weights) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s := &Scorer{}
+	for _, key := range keys {
+		w := weight{factor: weights[key]}
+		name := strings.TrimSpace(key)
+		if strings.HasPrefix(name, benchWeightPrefix) {
+			parts := strings.SplitN(strings.TrimPrefix(name, benchWeightPrefix), ":", 2)
+			w.bench = strings.TrimSpace(parts[0])
+			if len(parts) == 2 {
+				w.unit = strings.TrimSpace(parts[1])
+			}
+			if w.bench == "" {
+				return nil, fmt.Errorf("score weight %q: missing benchmark name", key)
+			}
+		} else {
+			if !knownMetrics[name] {
+				return nil, fmt.Errorf("score weight %q: unknown metric", key)
+			}
+			w.metric = name
+		}
+		s.weights = append(s.weights, w)
+	}
+	return s, nil
+}
+
+// Score evaluates the scorer for m. The second result is false when a metric
+// the scorer needs is unavailable or the result is not a finite number.
+func (s *Scorer) Score(m Metrics) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	var total float64
+	if s.expr != nil {
+		v, ok := s.expr.eval(m)
+		if !ok {
+			return 0, false
+		}
+		total = v
+	} else {
+		for _, w := range s.weights {
+			var v float64
+			var ok bool
+			if w.bench != "" {
+				v, ok = m.benchMean(w.bench, w.unit)
+			} else {
+				v, ok = m.value(w.metric)
+			}
+			if !ok {
+				return 0, false
+			}
+			total += w.factor * v
+		}
+	}
+	if math.IsNaN(total) || math.IsInf(total, 0) {
+		return 0, false
+	}
+	return total, true
+}
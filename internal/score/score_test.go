@@ -0,0 +1,113 @@
+package score
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prit3010/converge/internal/bench"
+)
+
+func TestExpressionScore(t *testing.T) {
+	s, err := New(`100 - 10*tests_failed - 2*(lint_errors + type_errors) + default(coverage, 0)/10 - max(loc_delta, 0)/100`, nil)
+	if err != nil {
+		t.Fatalf("new scorer: %v", err)
+	}
+	got, ok := s.Score(Metrics{Values: map[string]float64{
+		TestsFailed: 1,
+		LintErrors:  2,
+		TypeErrors:  1,
+		LOCDelta:    250,
+	}})
+	if !ok {
+		t.Fatalf("expected score")
+	}
+	// 100 - 10 - 6 + 0 - 2.5
+	if got != 81.5 {
+		t.Fatalf("expected 81.5, got %v", got)
+	}
+}
+
+func TestExpressionMissingMetricIsUnscored(t *testing.T) {
+	s, err := New("coverage - tests_failed", nil)
+	if err != nil {
+		t.Fatalf("new scorer: %v", err)
+	}
+	if _, ok := s.Score(Metrics{Values: map[string]float64{TestsFailed: 0}}); ok {
+		t.Fatalf("expected missing coverage to leave the cell unscored")
+	}
+}
+
+func TestExpressionBench(t *testing.T) {
+	s, err := New(`-bench("BenchmarkParse") - bench("p95", "ms")`, nil)
+	if err != nil {
+		t.Fatalf("new scorer: %v", err)
+	}
+	got, ok := s.Score(Metrics{Bench: []bench.Summary{
+		{Name: "BenchmarkParse", Unit: "ns/op", Mean: 120},
+		{Name: "BenchmarkParse", Unit: "B/op", Mean: 64},
+		{Name: "p95", Unit: "ms", Mean: 8},
+	}})
+	if !ok || got != -128 {
+		t.Fatalf("expected -128, got %v (ok=%v)", got, ok)
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	cases := map[string]string{
+		"tests_failed +":      "unexpected end",
+		"tests_fialed":        `unknown metric "tests_fialed"`,
+		"sqrt(coverage)":      `unknown function "sqrt"`,
+		"abs(1, 2)":           "abs takes 1 argument",
+		`bench(tests_failed)`: "bench expects string arguments",
+		"(1 + 2":              `expected ")"`,
+		"1 $ 2":               `unexpected '$'`,
+	}
+	for expr, want := range cases {
+		_, err := New(expr, nil)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("New(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+}
+
+func TestWeightedScore(t *testing.T) {
+	s, err := New("", map[string]float64{
+		TestsFailed:           -10,
+		Coverage:              0.5,
+		"bench:BenchmarkScan": -0.01,
+	})
+	if err != nil {
+		t.Fatalf("new scorer: %v", err)
+	}
+	got, ok := s.Score(Metrics{
+		Values: map[string]float64{TestsFailed: 2, Coverage: 80},
+		Bench:  []bench.Summary{{Name: "BenchmarkScan", Unit: "ns/op", Mean: 1000}},
+	})
+	if !ok || got != 10 {
+		t.Fatalf("expected 10, got %v (ok=%v)", got, ok)
+	}
+
+	if _, err := New("", map[string]float64{"speed": 1}); err == nil {
+		t.Fatalf("expected unknown weight metric to fail")
+	}
+}
+
+func TestNewWithoutConfigReturnsNil(t *testing.T) {
+	s, err := New("  ", nil)
+	if err != nil || s != nil {
+		t.Fatalf("expected nil scorer, got %v, %v", s, err)
+	}
+	if _, ok := s.Score(Metrics{}); ok {
+		t.Fatalf("nil scorer should not score")
+	}
+}
+
+func TestDivisionByZeroIsUnscored(t *testing.T) {
+	s, err := New("tests_passed / tests_total", nil)
+	if err != nil {
+		t.Fatalf("new scorer: %v", err)
+	}
+	if _, ok := s.Score(Metrics{Values: map[string]float64{TestsPassed: 0, TestsTotal: 0}}); ok {
+		t.Fatalf("expected division by zero to be unscored")
+	}
+}
@@ -55,6 +55,20 @@ func (s *Snapshot) LastSkipped() []SkipReason {
 }
 
 func (s *Snapshot) Capture(projectDir string) (Manifest, error) {
+	return s.capture(projectDir, s.store)
+}
+
+// CaptureOverlay walks the project like Capture but keeps file contents in
+// overlay, so the working tree can be inspected without persisting objects.
+func (s *Snapshot) CaptureOverlay(projectDir string, overlay *store.Overlay) (Manifest, error) {
+	return s.capture(projectDir, overlay)
+}
+
+type blobWriter interface {
+	Write(data []byte) (string, error)
+}
+
+func (s *Snapshot) capture(projectDir string, blobs blobWriter) (Manifest, error) {
 	manifest := make(Manifest)
 	skipped := make([]SkipReason, 0)
 	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
@@ -110,7 +124,7 @@ func (s *Snapshot) Capture(projectDir string) (Manifest, error) {
 			}
 		}
 
-		hash, err := s.store.Write(data)
+		hash, err := blobs.Write(data)
 		if err != nil {
 			return fmt.Errorf("store %s: %w", relPath, err)
 		}
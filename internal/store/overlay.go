@@ -0,0 +1,80 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Overlay serves in-memory blobs ahead of a Store, for content that is
+// inspected but never persisted, such as the uncommitted working tree.
+type Overlay struct {
+	base  *Store
+	blobs map[string][]byte
+}
+
+func NewOverlay(base *Store) *Overlay {
+	return &Overlay{base: base, blobs: make(map[string][]byte)}
+}
+
+// Write keeps data in memory and returns its hash. It never touches disk, so
+// it cannot fail; the error matches Store.Write.
+func (o *Overlay) Write(data []byte) (string, error) {
+	hash := Hash(data)
+	if _, ok := o.blobs[hash]; !ok {
+		o.blobs[hash] = data
+	}
+	return hash, nil
+}
+
+func (o *Overlay) Read(hash string) ([]byte, error) {
+	if data, ok := o.blobs[hash]; ok {
+		return data, nil
+	}
+	return o.base.Read(hash)
+}
+
+func (o *Overlay) Open(hash string) (io.ReadCloser, error) {
+	if data, ok := o.blobs[hash]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return o.base.Open(hash)
+}
+
+// Chain reads each blob from the first reader that has it, for content spread
+// across stores such as the active history and an archive.
+type Chain []Reader
+
+func (c Chain) Read(hash string) ([]byte, error) {
+	var firstErr error
+	for _, r := range c {
+		data, err := r.Read(hash)
+		if err == nil {
+			return data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("read object %s: no stores", hash)
+	}
+	return nil, firstErr
+}
+
+func (c Chain) Open(hash string) (io.ReadCloser, error) {
+	var firstErr error
+	for _, r := range c {
+		rc, err := r.Open(hash)
+		if err == nil {
+			return rc, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("read object %s: no stores", hash)
+	}
+	return nil, firstErr
+}
@@ -4,19 +4,40 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
 
 // Store is a content-addressed object store of raw file blobs.
 type Store struct {
-	root string
+	root     string
+	fallback *Store
+}
+
+// Reader reads blobs by hash; both Store and Overlay satisfy it. Open streams
+// a blob instead of loading it into memory.
+type Reader interface {
+	Read(hash string) ([]byte, error)
+	Open(hash string) (io.ReadCloser, error)
+}
+
+// Hash returns the object hash Write would store data under.
+func Hash(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
 }
 
 func New(root string) *Store {
 	return &Store{root: root}
 }
 
+// WithFallback returns a store that writes to s and reads blobs missing from
+// s out of fallback, such as an archive backed by the shared archive store.
+func (s *Store) WithFallback(fallback *Store) *Store {
+	return &Store{root: s.root, fallback: fallback}
+}
+
 func (s *Store) blobPath(hash string) string {
 	if len(hash) < 2 {
 		return filepath.Join(s.root, hash)
@@ -25,8 +46,7 @@ func (s *Store) blobPath(hash string) string {
 }
 
 func (s *Store) Write(data []byte) (string, error) {
-	digest := sha256.Sum256(data)
-	hash := hex.EncodeToString(digest[:])
+	hash := Hash(data)
 	if s.Has(hash) {
 		return hash, nil
 	}
@@ -43,12 +63,81 @@ func (s *Store) Write(data []byte) (string, error) {
 func (s *Store) Read(hash string) ([]byte, error) {
 	data, err := os.ReadFile(s.blobPath(hash))
 	if err != nil {
+		if s.fallback != nil && s.fallback.Has(hash) {
+			return s.fallback.Read(hash)
+		}
 		return nil, fmt.Errorf("read object %s: %w", hash, err)
 	}
 	return data, nil
 }
 
+// Open returns a reader over a blob's contents; the caller closes it.
+func (s *Store) Open(hash string) (io.ReadCloser, error) {
+	file, err := os.Open(s.blobPath(hash))
+	if err != nil {
+		if s.fallback != nil && s.fallback.Has(hash) {
+			return s.fallback.Open(hash)
+		}
+		return nil, fmt.Errorf("read object %s: %w", hash, err)
+	}
+	return file, nil
+}
+
 func (s *Store) Has(hash string) bool {
-	_, err := os.Stat(s.blobPath(hash))
-	return err == nil
+	if _, err := os.Stat(s.blobPath(hash)); err == nil {
+		return true
+	}
+	return s.fallback != nil && s.fallback.Has(hash)
+}
+
+// Hashes lists the blobs stored directly under s, ignoring any fallback.
+func (s *Store) Hashes() ([]string, error) {
+	var hashes []string
+	err := filepath.WalkDir(s.root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == s.root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			hashes = append(hashes, d.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+	return hashes, nil
+}
+
+// Remove deletes a blob stored directly under s and returns its size.
+func (s *Store) Remove(hash string) (int64, error) {
+	path := s.blobPath(hash)
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("remove object %s: %w", hash, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("remove object %s: %w", hash, err)
+	}
+	return info.Size(), nil
+}
+
+// MoveTo moves a blob from s into dst. When dst already holds it the copy in
+// s is removed instead, and MoveTo reports it as a duplicate with the bytes
+// reclaimed.
+func (s *Store) MoveTo(dst *Store, hash string) (duplicate bool, freed int64, err error) {
+	if dst.Has(hash) {
+		freed, err = s.Remove(hash)
+		return true, freed, err
+	}
+	target := dst.blobPath(hash)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return false, 0, fmt.Errorf("create object dir: %w", err)
+	}
+	if err := os.Rename(s.blobPath(hash), target); err != nil {
+		return false, 0, fmt.Errorf("move object %s: %w", hash, err)
+	}
+	return false, 0, nil
 }
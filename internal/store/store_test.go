@@ -1,6 +1,7 @@
 package store
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -44,3 +45,78 @@ func TestWriteReadAndDedup(t *testing.T) {
 		t.Fatalf("expected 1 stored blob, got %d", files)
 	}
 }
+
+func TestOverlayKeepsBlobsInMemory(t *testing.T) {
+	tmp := t.TempDir()
+	s := New(tmp)
+	stored, err := s.Write([]byte("on disk"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	overlay := NewOverlay(s)
+	hash, err := overlay.Write([]byte("in memory"))
+	if err != nil {
+		t.Fatalf("overlay write: %v", err)
+	}
+	if s.Has(hash) {
+		t.Fatalf("overlay write reached the store")
+	}
+	for h, want := range map[string]string{hash: "in memory", stored: "on disk"} {
+		data, err := overlay.Read(h)
+		if err != nil || string(data) != want {
+			t.Fatalf("overlay read %s: %q, %v", h, data, err)
+		}
+	}
+}
+
+func TestMoveToDeduplicatesIntoFallback(t *testing.T) {
+	shared := New(t.TempDir())
+	a := New(t.TempDir()).WithFallback(shared)
+	b := New(t.TempDir()).WithFallback(shared)
+
+	hash, err := a.Write([]byte("same"))
+	if err != nil {
+		t.Fatalf("write a: %v", err)
+	}
+	if _, err := b.Write([]byte("same")); err != nil {
+		t.Fatalf("write b: %v", err)
+	}
+	if dup, _, err := a.MoveTo(shared, hash); err != nil || dup {
+		t.Fatalf("move a: dup=%v err=%v", dup, err)
+	}
+	dup, freed, err := b.MoveTo(shared, hash)
+	if err != nil || !dup || freed != 4 {
+		t.Fatalf("move b: dup=%v freed=%d err=%v", dup, freed, err)
+	}
+	for _, s := range []*Store{a, b} {
+		if hashes, _ := s.Hashes(); len(hashes) != 0 {
+			t.Fatalf("expected no local blobs, got %v", hashes)
+		}
+		if data, err := s.Read(hash); err != nil || string(data) != "same" {
+			t.Fatalf("fallback read: %q, %v", data, err)
+		}
+	}
+}
+
+func TestOpenStreamsBlobsWithFallback(t *testing.T) {
+	shared := New(t.TempDir())
+	hash, err := shared.Write([]byte("shared blob"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	s := New(t.TempDir()).WithFallback(shared)
+
+	blob, err := s.Open(hash)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer blob.Close()
+	data, err := io.ReadAll(blob)
+	if err != nil || string(data) != "shared blob" {
+		t.Fatalf("unexpected streamed payload %q (err %v)", data, err)
+	}
+	if _, err := s.Open(Hash([]byte("missing"))); err == nil {
+		t.Fatalf("expected opening a missing blob to fail")
+	}
+}
@@ -61,7 +61,7 @@ func (s *Server) openDataSource(archiveID string) (*dataSource, error) {
 		ArchiveID: archiveID,
 		ReadOnly:  true,
 		DB:        archiveDB,
-		Store:     store.New(objectsPath),
+		Store:     s.svc.ArchiveStore(objectsPath),
 		closeFn:   archiveDB.Close,
 	}, nil
 }
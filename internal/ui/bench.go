@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"net/http"
+
+	"github.com/prit3010/converge/internal/bench"
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+)
+
+type benchPointJSON struct {
+	CellID   string  `json:"cell_id"`
+	Sequence int     `json:"sequence"`
+	Branch   string  `json:"branch"`
+	Mean     float64 `json:"mean"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	N        int     `json:"n"`
+}
+
+type benchSeriesJSON struct {
+	Name   string           `json:"name"`
+	Unit   string           `json:"unit"`
+	Points []benchPointJSON `json:"points"`
+}
+
+func (s *Server) handleAPIBench(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.dataSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	cells, err := src.DB.ListAllCells()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	samples, err := src.DB.ListAllBenchSamples()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, buildBenchSeries(cells, samples))
+}
+
+// buildBenchSeries turns stored samples into one series per (name, unit),
+// with a point per cell in sequence order.
+func buildBenchSeries(cells []db.Cell, samples []db.BenchSample) []benchSeriesJSON {
+	byCell := make(map[string][]db.BenchSample)
+	for _, sample := range samples {
+		byCell[sample.CellID] = append(byCell[sample.CellID], sample)
+	}
+
+	type key struct{ name, unit string }
+	index := map[key]int{}
+	out := make([]benchSeriesJSON, 0)
+	for _, cell := range cells {
+		rows, ok := byCell[cell.ID]
+		if !ok {
+			continue
+		}
+		for _, summary := range bench.Summarize(core.BenchSamplesFromRows(rows)) {
+			k := key{summary.Name, summary.Unit}
+			i, seen := index[k]
+			if !seen {
+				i = len(out)
+				index[k] = i
+				out = append(out, benchSeriesJSON{Name: summary.Name, Unit: summary.Unit, Points: []benchPointJSON{}})
+			}
+			out[i].Points = append(out[i].Points, benchPointJSON{
+				CellID:   cell.ID,
+				Sequence: cell.Sequence,
+				Branch:   cell.Branch,
+				Mean:     summary.Mean,
+				Min:      summary.Min,
+				Max:      summary.Max,
+				N:        len(summary.Values),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/prit3010/converge/internal/core"
+	"github.com/prit3010/converge/internal/db"
+)
+
+type fileChangeJSON struct {
+	cellJSON
+	Status string `json:"status"`
+}
+
+type fileHistoryJSON struct {
+	Path    string           `json:"path"`
+	Changes []fileChangeJSON `json:"changes"`
+	CellID  string           `json:"cell_id,omitempty"`
+	Blame   []core.BlameLine `json:"blame"`
+}
+
+// handleAPIFileHistory lists the cells that changed ?path= and, when ?cell=
+// names a cell containing the file, blames that version.
+func (s *Server) handleAPIFileHistory(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.dataSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	filePath := path.Clean(strings.TrimSpace(r.URL.Query().Get("path")))
+	if filePath == "." || filePath == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+	changes, err := core.FileHistoryIn(src.DB, filePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := fileHistoryJSON{Path: filePath, Changes: make([]fileChangeJSON, 0, len(changes)), Blame: []core.BlameLine{}}
+	for _, change := range changes {
+		out.Changes = append(out.Changes, fileChangeJSON{cellJSON: toCellJSON(change.Cell), Status: change.Status})
+	}
+
+	if cellID := strings.TrimSpace(r.URL.Query().Get("cell")); cellID != "" {
+		blame, err := core.BlameIn(src.DB, src.Store, cellID, filePath)
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "file not found in cell", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		out.CellID = cellID
+		out.Blame = blame
+	}
+	writeJSON(w, out)
+}
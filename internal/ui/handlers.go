@@ -5,36 +5,45 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/prit3010/converge/internal/core"
 	"github.com/prit3010/converge/internal/db"
 	"github.com/prit3010/converge/internal/diff"
 	"github.com/prit3010/converge/internal/llm"
 	"github.com/prit3010/converge/internal/snapshot"
+	"github.com/prit3010/converge/internal/store"
 )
 
 type cellJSON struct {
-	ID            string  `json:"id"`
-	Sequence      int     `json:"sequence"`
-	ParentID      *string `json:"parent_id"`
-	Timestamp     string  `json:"timestamp"`
-	Message       string  `json:"message"`
-	Source        string  `json:"source"`
-	Branch        string  `json:"branch"`
-	FilesAdded    int     `json:"files_added"`
-	FilesModified int     `json:"files_modified"`
-	FilesRemoved  int     `json:"files_removed"`
-	LinesAdded    int     `json:"lines_added"`
-	LinesRemoved  int     `json:"lines_removed"`
-	TotalLOC      int     `json:"total_loc"`
-	LOCDelta      int     `json:"loc_delta"`
-	TotalFiles    int     `json:"total_files"`
-	TestsPassed   *int    `json:"tests_passed"`
-	TestsFailed   *int    `json:"tests_failed"`
-	LintErrors    *int    `json:"lint_errors"`
-	TypeErrors    *int    `json:"type_errors"`
+	ID            string   `json:"id"`
+	Sequence      int      `json:"sequence"`
+	ParentID      *string  `json:"parent_id"`
+	Timestamp     string   `json:"timestamp"`
+	Message       string   `json:"message"`
+	Source        string   `json:"source"`
+	Branch        string   `json:"branch"`
+	FilesAdded    int      `json:"files_added"`
+	FilesModified int      `json:"files_modified"`
+	FilesRemoved  int      `json:"files_removed"`
+	LinesAdded    int      `json:"lines_added"`
+	LinesRemoved  int      `json:"lines_removed"`
+	TotalLOC      int      `json:"total_loc"`
+	LOCDelta      int      `json:"loc_delta"`
+	TotalFiles    int      `json:"total_files"`
+	TestsPassed   *int     `json:"tests_passed"`
+	TestsFailed   *int     `json:"tests_failed"`
+	LintErrors    *int     `json:"lint_errors"`
+	TypeErrors    *int     `json:"type_errors"`
+	EvalScope     *string  `json:"eval_scope"`
+	CoveragePct   *float64 `json:"coverage_pct"`
+	Score         *float64 `json:"score"`
+	Summary       *string  `json:"summary"`
+	Origin        *string  `json:"origin,omitempty"`
+	Tags          []string `json:"tags"`
 }
 
 type fileJSON struct {
@@ -42,9 +51,48 @@ type fileJSON struct {
 	Size int64  `json:"size"`
 }
 
+type evalProjectJSON struct {
+	Dir         string   `json:"dir"`
+	Types       []string `json:"types"`
+	TestsPassed *int     `json:"tests_passed"`
+	TestsFailed *int     `json:"tests_failed"`
+	LintErrors  *int     `json:"lint_errors"`
+	TypeErrors  *int     `json:"type_errors"`
+	Skipped     []string `json:"skipped"`
+}
+
+type fileCoverageJSON struct {
+	Path    string  `json:"path"`
+	Covered int     `json:"covered"`
+	Total   int     `json:"total"`
+	Pct     float64 `json:"pct"`
+}
+
 type cellDetailJSON struct {
 	cellJSON
-	Files []fileJSON `json:"files"`
+	Files         []fileJSON         `json:"files"`
+	EvalProjects  []evalProjectJSON  `json:"eval_projects"`
+	CoverageDelta *float64           `json:"coverage_delta"`
+	CoverageFiles []fileCoverageJSON `json:"coverage_files"`
+	Comparisons   []comparisonJSON   `json:"comparisons"`
+	Rankings      []*llm.RankResult  `json:"rankings"`
+	Notes         []db.Note          `json:"notes"`
+}
+
+type comparisonJSON struct {
+	CellA            string        `json:"cell_a"`
+	CellB            string        `json:"cell_b"`
+	Provider         string        `json:"provider"`
+	Model            string        `json:"model"`
+	Summary          string        `json:"summary"`
+	Winner           string        `json:"winner"`
+	WinnerReason     string        `json:"winner_reason"`
+	Highlights       []string      `json:"highlights"`
+	Risks            []string      `json:"risks"`
+	Files            []db.FileNote `json:"files"`
+	PromptTokens     *int          `json:"prompt_tokens"`
+	CompletionTokens *int          `json:"completion_tokens"`
+	CreatedAt        string        `json:"created_at"`
 }
 
 type branchJSON struct {
@@ -77,6 +125,7 @@ type uiSummaryJSON struct {
 	TotalBranches  int     `json:"total_branches"`
 	ActiveBranch   string  `json:"active_branch"`
 	WinnerCellID   string  `json:"winner_cell_id"`
+	WinnerBasis    string  `json:"winner_basis"`
 	BaselineCellID string  `json:"baseline_cell_id"`
 	PassRate       float64 `json:"pass_rate"`
 	ForkPoints     int     `json:"fork_points"`
@@ -106,9 +155,14 @@ func (s *Server) handleAPICells(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	tag := strings.TrimSpace(r.URL.Query().Get("tag"))
 	out := make([]cellJSON, 0, len(cells))
 	for _, c := range cells {
-		out = append(out, toCellJSON(c))
+		view := toCellJSON(c)
+		if tag != "" && !slices.Contains(view.Tags, tag) {
+			continue
+		}
+		out = append(out, view)
 	}
 	writeJSON(w, out)
 }
@@ -163,7 +217,118 @@ func (s *Server) handleAPICell(w http.ResponseWriter, r *http.Request) {
 		files = append(files, fileJSON{Path: m.Path, Size: m.Size})
 	}
 	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
-	writeJSON(w, cellDetailJSON{cellJSON: toCellJSON(*cell), Files: files})
+	projects, err := src.DB.ListEvalProjects(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	evalProjects := make([]evalProjectJSON, 0, len(projects))
+	for _, p := range projects {
+		evalProjects = append(evalProjects, toEvalProjectJSON(p))
+	}
+	fileCoverage, err := src.DB.ListFileCoverage(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	coverageFiles := make([]fileCoverageJSON, 0, len(fileCoverage))
+	for _, f := range fileCoverage {
+		pct := 0.0
+		if f.Total > 0 {
+			pct = float64(f.Covered) * 100 / float64(f.Total)
+		}
+		coverageFiles = append(coverageFiles, fileCoverageJSON{Path: f.Path, Covered: f.Covered, Total: f.Total, Pct: pct})
+	}
+	var coverageDelta *float64
+	if cell.CoveragePct != nil && cell.ParentID != nil {
+		if parent, err := src.DB.GetCell(*cell.ParentID); err == nil && parent.CoveragePct != nil {
+			d := *cell.CoveragePct - *parent.CoveragePct
+			coverageDelta = &d
+		}
+	}
+	stored, err := src.DB.ListComparisonsForCell(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	comparisons := make([]comparisonJSON, 0, len(stored))
+	for _, c := range stored {
+		comparisons = append(comparisons, toComparisonJSON(c))
+	}
+	rankings, err := src.DB.ListRankingsForCell(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	notes, err := src.DB.ListNotes(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, cellDetailJSON{
+		cellJSON:      toCellJSON(*cell),
+		Files:         files,
+		EvalProjects:  evalProjects,
+		CoverageDelta: coverageDelta,
+		CoverageFiles: coverageFiles,
+		Comparisons:   comparisons,
+		Rankings:      toRankingsJSON(rankings),
+		Notes:         notes,
+	})
+}
+
+func toComparisonJSON(c db.Comparison) comparisonJSON {
+	highlights, risks, files := c.Highlights, c.Risks, c.FileNotes
+	if highlights == nil {
+		highlights = []string{}
+	}
+	if risks == nil {
+		risks = []string{}
+	}
+	if files == nil {
+		files = []db.FileNote{}
+	}
+	return comparisonJSON{
+		CellA:            c.CellA,
+		CellB:            c.CellB,
+		Provider:         c.Provider,
+		Model:            c.Model,
+		Summary:          c.Summary,
+		Winner:           c.Winner,
+		WinnerReason:     c.WinnerReason,
+		Highlights:       highlights,
+		Risks:            risks,
+		Files:            files,
+		PromptTokens:     c.PromptTokens,
+		CompletionTokens: c.CompletionTokens,
+		CreatedAt:        c.CreatedAt,
+	}
+}
+
+func toEvalProjectJSON(p db.EvalProject) evalProjectJSON {
+	out := evalProjectJSON{
+		Dir:         p.Dir,
+		Types:       splitCSV(p.ProjectTypes),
+		TestsPassed: p.TestsPassed,
+		TestsFailed: p.TestsFailed,
+		LintErrors:  p.LintErrors,
+		TypeErrors:  p.TypeErrors,
+		Skipped:     []string{},
+	}
+	if p.EvalSkipped != nil {
+		out.Skipped = splitCSV(*p.EvalSkipped)
+	}
+	return out
+}
+
+func splitCSV(raw string) []string {
+	out := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
 func (s *Server) handleAPIDiff(w http.ResponseWriter, r *http.Request) {
@@ -175,17 +340,16 @@ func (s *Server) handleAPIDiff(w http.ResponseWriter, r *http.Request) {
 
 	cellA := r.PathValue("cellA")
 	cellB := r.PathValue("cellB")
-
-	manifestA, err := src.DB.GetManifest(cellA)
-	if err != nil {
-		http.Error(w, "cell A not found", http.StatusNotFound)
+	viewA, ok := s.cellView(w, src, cellA, "cell A")
+	if !ok {
 		return
 	}
-	manifestB, err := src.DB.GetManifest(cellB)
-	if err != nil {
-		http.Error(w, "cell B not found", http.StatusNotFound)
+	viewB, ok := s.cellView(w, src, cellB, "cell B")
+	if !ok {
 		return
 	}
+	blobs := store.Chain{viewA.Blobs, viewB.Blobs}
+	manifestA, manifestB := viewA.Manifest, viewB.Manifest
 
 	mapA := make(map[string]string, len(manifestA))
 	for _, e := range manifestA {
@@ -205,8 +369,8 @@ func (s *Server) handleAPIDiff(w http.ResponseWriter, r *http.Request) {
 		diffs = append(diffs, diffJSON{Path: p, Status: "removed"})
 	}
 	for _, p := range result.Modified {
-		oldData, errOld := src.Store.Read(mapA[p])
-		newData, errNew := src.Store.Read(mapB[p])
+		oldData, errOld := blobs.Read(mapA[p])
+		newData, errNew := blobs.Read(mapB[p])
 		patch := ""
 		if errOld == nil && errNew == nil && snapshot.IsText(oldData) && snapshot.IsText(newData) {
 			patch = diff.UnifiedDiff(p, string(oldData), string(newData))
@@ -216,6 +380,56 @@ func (s *Server) handleAPIDiff(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, diffs)
 }
 
+// cellView loads ref from src; plain cell IDs always resolve there. WORKTREE
+// and "<archive>:<cell>" refs that point outside src are loaded through the
+// service, so a diff or compare can span the working tree, the active
+// history, and archives.
+func (s *Server) cellView(w http.ResponseWriter, src *dataSource, ref, label string) (*core.CellView, bool) {
+	archiveID, cellID := core.SplitCellRef(ref)
+	srcArchive := ""
+	if src.ReadOnly {
+		srcArchive = src.ArchiveID
+	}
+	plain := !strings.Contains(ref, ":")
+	var view *core.CellView
+	var err error
+	if ref != core.WorktreeRef && (plain || archiveID == srcArchive) {
+		view, err = s.srcCellView(src, cellID)
+	} else {
+		view, err = s.svc.LoadCellView(ref)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, label+" not found", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return view, true
+}
+
+// prefixArchive addresses cellID in archiveID unless it already names an
+// archive.
+func prefixArchive(archiveID, cellID string) string {
+	if strings.Contains(cellID, ":") {
+		return cellID
+	}
+	return core.ArchiveCellRef(archiveID, cellID)
+}
+
+func (s *Server) srcCellView(src *dataSource, cellID string) (*core.CellView, error) {
+	cell, err := src.DB.GetCell(cellID)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := src.DB.GetManifest(cellID)
+	if err != nil {
+		return nil, err
+	}
+	return &core.CellView{Cell: *cell, Manifest: manifest, Blobs: src.Store}, nil
+}
+
 func (s *Server) handleAPIBranches(w http.ResponseWriter, r *http.Request) {
 	src, ok := s.dataSourceFromRequest(w, r)
 	if !ok {
@@ -269,8 +483,9 @@ func (s *Server) handleAPIUISummary(w http.ResponseWriter, r *http.Request) {
 
 	if len(cells) > 0 {
 		summary.BaselineCellID = cells[0].ID
-		if winner := pickWinnerCell(cells); winner != nil {
+		if winner, basis := core.PickWinner(cells); winner != nil {
 			summary.WinnerCellID = winner.ID
+			summary.WinnerBasis = basis
 		}
 	}
 
@@ -279,13 +494,14 @@ func (s *Server) handleAPIUISummary(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPICompare(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		CellA        string `json:"cell_a"`
-		CellB        string `json:"cell_b"`
-		Model        string `json:"model"`
-		MaxDiffLines int    `json:"max_diff_lines"`
-		Archive      string `json:"archive"`
-		ArchiveA     string `json:"archive_a"`
-		ArchiveB     string `json:"archive_b"`
+		CellA     string `json:"cell_a"`
+		CellB     string `json:"cell_b"`
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		Refresh   bool   `json:"refresh"`
+		Archive   string `json:"archive"`
+		ArchiveA  string `json:"archive_a"`
+		ArchiveB  string `json:"archive_b"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
@@ -310,11 +526,14 @@ func (s *Server) handleAPICompare(w http.ResponseWriter, r *http.Request) {
 		if archiveB != "" {
 			right = archiveIDFromQuery(archiveB)
 		}
+		archiveID = left
 		if left != right {
-			http.Error(w, "cross-archive compare is not supported", http.StatusBadRequest)
-			return
+			// Address each side explicitly and compare from the active
+			// history, which can load cells from any archive.
+			req.CellA = prefixArchive(left, req.CellA)
+			req.CellB = prefixArchive(right, req.CellB)
+			archiveID = "current"
 		}
-		archiveID = left
 	}
 
 	src, err := s.openDataSource(archiveID)
@@ -331,10 +550,25 @@ func (s *Server) handleAPICompare(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), apiCompareTimeout)
 	defer cancel()
 
-	comparer := llm.NewComparer(src.DB, src.Store)
-	result, err := comparer.Compare(ctx, req.CellA, req.CellB, llm.CompareOptions{
-		Model:        strings.TrimSpace(req.Model),
-		MaxDiffLines: req.MaxDiffLines,
+	viewA, ok := s.cellView(w, src, req.CellA, "cell A")
+	if !ok {
+		return
+	}
+	viewB, ok := s.cellView(w, src, req.CellB, "cell B")
+	if !ok {
+		return
+	}
+	comparer := llm.NewComparer(src.DB, src.Store, s.svc.Policy.LLM)
+	for _, view := range []*core.CellView{viewA, viewB} {
+		if view.External {
+			comparer.AddCell(view.Cell, view.Manifest, view.Blobs)
+		}
+	}
+	result, err := comparer.Compare(ctx, viewA.Cell.ID, viewB.Cell.ID, llm.CompareOptions{
+		Model:     strings.TrimSpace(req.Model),
+		MaxTokens: req.MaxTokens,
+		Refresh:   req.Refresh,
+		NoStore:   src.ReadOnly,
 	})
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
@@ -370,6 +604,7 @@ func toCellJSON(c db.Cell) cellJSON {
 		ID:            c.ID,
 		Sequence:      c.Sequence,
 		ParentID:      c.ParentID,
+		Origin:        c.Origin,
 		Timestamp:     c.Timestamp,
 		Message:       c.Message,
 		Source:        c.Source,
@@ -386,56 +621,24 @@ func toCellJSON(c db.Cell) cellJSON {
 		TestsFailed:   c.TestsFailed,
 		LintErrors:    c.LintErrors,
 		TypeErrors:    c.TypeErrors,
+		EvalScope:     c.EvalScope,
+		CoveragePct:   c.CoveragePct,
+		Score:         c.Score,
+		Summary:       c.Summary,
+		Tags:          cellTags(c),
 	}
 }
 
-func pickWinnerCell(cells []db.Cell) *db.Cell {
-	if len(cells) == 0 {
-		return nil
-	}
-
-	evaluated := make([]db.Cell, 0, len(cells))
-	for _, cell := range cells {
-		if cellHasEval(cell) {
-			evaluated = append(evaluated, cell)
-		}
-	}
-
-	// If there is no evaluation data at all, default to the latest attempt.
-	pool := evaluated
-	if len(pool) == 0 {
-		pool = cells
+func cellTags(c db.Cell) []string {
+	if c.Tags == nil {
+		return []string{}
 	}
-
-	best := pool[0]
-	for i := 1; i < len(pool); i += 1 {
-		if winnerPreferred(pool[i], best) {
-			best = pool[i]
-		}
-	}
-	return &best
+	return db.SplitTags(*c.Tags)
 }
 
-func winnerPreferred(candidate, current db.Cell) bool {
-	candidateFailed := ptrInt(candidate.TestsFailed)
-	currentFailed := ptrInt(current.TestsFailed)
-	if candidateFailed != currentFailed {
-		return candidateFailed < currentFailed
-	}
-
-	candidateLintType := ptrInt(candidate.LintErrors) + ptrInt(candidate.TypeErrors)
-	currentLintType := ptrInt(current.LintErrors) + ptrInt(current.TypeErrors)
-	if candidateLintType != currentLintType {
-		return candidateLintType < currentLintType
-	}
-
-	candidatePassed := ptrInt(candidate.TestsPassed)
-	currentPassed := ptrInt(current.TestsPassed)
-	if candidatePassed != currentPassed {
-		return candidatePassed > currentPassed
-	}
-
-	return candidate.Sequence > current.Sequence
+func pickWinnerCell(cells []db.Cell) *db.Cell {
+	winner, _ := core.PickWinner(cells)
+	return winner
 }
 
 func calculatePassRate(cells []db.Cell) float64 {
@@ -478,13 +681,6 @@ func countForkPoints(cells []db.Cell) int {
 	return forkPoints
 }
 
-func cellHasEval(cell db.Cell) bool {
-	return cell.TestsPassed != nil ||
-		cell.TestsFailed != nil ||
-		cell.LintErrors != nil ||
-		cell.TypeErrors != nil
-}
-
 func ptrInt(value *int) int {
 	if value == nil {
 		return 0
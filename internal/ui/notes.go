@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prit3010/converge/internal/db"
+)
+
+type noteRequest struct {
+	Body string  `json:"body"`
+	Path *string `json:"path"`
+	Line *int    `json:"line"`
+}
+
+func (s *Server) handleAPIAddNote(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.writableSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	id := r.PathValue("id")
+	if _, err := src.DB.GetCell(id); err != nil {
+		http.Error(w, "cell not found", http.StatusNotFound)
+		return
+	}
+	req, ok := decodeNoteRequest(w, r)
+	if !ok {
+		return
+	}
+	note := db.Note{CellID: id, Body: req.Body, Path: req.Path, Line: req.Line}
+	if note.Path != nil && strings.TrimSpace(*note.Path) == "" {
+		note.Path = nil
+	}
+	if note.Line != nil && (note.Path == nil || *note.Line <= 0) {
+		http.Error(w, "line requires a path and must be positive", http.StatusBadRequest)
+		return
+	}
+	added, err := src.DB.AddNote(note)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, added)
+}
+
+func (s *Server) handleAPIUpdateNote(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.writableSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	id, ok := noteIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeNoteRequest(w, r)
+	if !ok {
+		return
+	}
+	note, err := src.DB.UpdateNote(id, req.Body)
+	if err == db.ErrNotFound {
+		http.Error(w, "note not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, note)
+}
+
+func (s *Server) handleAPIDeleteNote(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.writableSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	id, ok := noteIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := src.DB.DeleteNote(id); err == db.ErrNotFound {
+		http.Error(w, "note not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writableSourceFromRequest is dataSourceFromRequest for endpoints that
+// write; archived histories are read-only.
+func (s *Server) writableSourceFromRequest(w http.ResponseWriter, r *http.Request) (*dataSource, bool) {
+	src, ok := s.dataSourceFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+	if src.ReadOnly {
+		src.Close()
+		http.Error(w, "archived history is read-only", http.StatusForbidden)
+		return nil, false
+	}
+	return src, true
+}
+
+func decodeNoteRequest(w http.ResponseWriter, r *http.Request) (noteRequest, bool) {
+	var req noteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		http.Error(w, "body is required", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+func noteIDFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid note id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
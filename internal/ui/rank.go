@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"net/http"
+
+	"github.com/prit3010/converge/internal/db"
+	"github.com/prit3010/converge/internal/llm"
+)
+
+const apiRankingsLimit = 10
+
+func (s *Server) handleAPIRankings(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.dataSourceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	defer src.Close()
+
+	rankings, err := src.DB.ListRankings(apiRankingsLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, toRankingsJSON(rankings))
+}
+
+func toRankingsJSON(rankings []db.Ranking) []*llm.RankResult {
+	out := make([]*llm.RankResult, 0, len(rankings))
+	for _, ranking := range rankings {
+		out = append(out, llm.ResultFromRanking(ranking))
+	}
+	return out
+}
@@ -59,8 +59,14 @@ func (s *Server) routes() {
 	s.mux.HandleFunc("GET /api/cell/{id}", s.handleAPICell)
 	s.mux.HandleFunc("GET /api/diff/{cellA}/{cellB}", s.handleAPIDiff)
 	s.mux.HandleFunc("GET /api/branches", s.handleAPIBranches)
+	s.mux.HandleFunc("GET /api/file-history", s.handleAPIFileHistory)
 	s.mux.HandleFunc("GET /api/ui/summary", s.handleAPIUISummary)
+	s.mux.HandleFunc("GET /api/bench", s.handleAPIBench)
+	s.mux.HandleFunc("GET /api/rankings", s.handleAPIRankings)
 	s.mux.HandleFunc("POST /api/compare", s.handleAPICompare)
+	s.mux.HandleFunc("POST /api/cell/{id}/notes", s.handleAPIAddNote)
+	s.mux.HandleFunc("PUT /api/notes/{id}", s.handleAPIUpdateNote)
+	s.mux.HandleFunc("DELETE /api/notes/{id}", s.handleAPIDeleteNote)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -4,16 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prit3010/converge/internal/config"
 	"github.com/prit3010/converge/internal/core"
 	"github.com/prit3010/converge/internal/db"
 	"github.com/prit3010/converge/internal/eval"
+	"github.com/prit3010/converge/internal/llm"
 	"github.com/prit3010/converge/internal/store"
 )
 
@@ -80,6 +84,209 @@ func TestAPICellsAndBranches(t *testing.T) {
 	}
 }
 
+func TestAPICellIncludesEvalProjects(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	cell, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "base", RunEval: false})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	skipped := "golangci-lint"
+	if err := svc.DB.ReplaceEvalProjects(cell.ID, []db.EvalProject{
+		{Dir: "services/api", ProjectTypes: "go", TestsPassed: intPtr(3), TestsFailed: intPtr(1), EvalSkipped: &skipped},
+		{Dir: "web", ProjectTypes: "node", TestsPassed: intPtr(5), TestsFailed: intPtr(0)},
+	}); err != nil {
+		t.Fatalf("replace eval projects: %v", err)
+	}
+
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/cell/"+cell.ID, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cell status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var detail cellDetailJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode cell json: %v", err)
+	}
+	if len(detail.EvalProjects) != 2 {
+		t.Fatalf("expected 2 eval projects, got %+v", detail.EvalProjects)
+	}
+	api := detail.EvalProjects[0]
+	if api.Dir != "services/api" || api.TestsFailed == nil || *api.TestsFailed != 1 {
+		t.Fatalf("unexpected api project: %+v", api)
+	}
+	if len(api.Skipped) != 1 || api.Skipped[0] != "golangci-lint" {
+		t.Fatalf("unexpected api skipped: %+v", api.Skipped)
+	}
+}
+
+func TestAPICellIncludesStoredComparisons(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	cellA, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "a", RunEval: false})
+	if err != nil {
+		t.Fatalf("create cell a: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("rewrite main.go: %v", err)
+	}
+	cellB, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "b", RunEval: false})
+	if err != nil {
+		t.Fatalf("create cell b: %v", err)
+	}
+	if err := svc.DB.UpsertComparison(db.Comparison{
+		CellA: cellA.ID, CellB: cellB.ID, Model: "gpt-test", PromptHash: "abc", Provider: "openai",
+		Summary: "B adds main.", Winner: cellB.ID, Highlights: []string{"adds main"}, PromptTokens: intPtr(120),
+	}); err != nil {
+		t.Fatalf("upsert comparison: %v", err)
+	}
+
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/cell/"+cellB.ID, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cell status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var detail cellDetailJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode cell json: %v", err)
+	}
+	if len(detail.Comparisons) != 1 {
+		t.Fatalf("expected 1 comparison, got %+v", detail.Comparisons)
+	}
+	got := detail.Comparisons[0]
+	if got.CellA != cellA.ID || got.Model != "gpt-test" || got.Winner != cellB.ID || got.PromptTokens == nil || *got.PromptTokens != 120 {
+		t.Fatalf("unexpected comparison: %+v", got)
+	}
+}
+
+func TestAPIRankingsAndCellRankings(t *testing.T) {
+	svc := newUITestService(t)
+	ids := make([]string, 0, 3)
+	for i, contents := range []string{"a\n", "b\n", "c\n"} {
+		if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.txt"), []byte(contents), 0o644); err != nil {
+			t.Fatalf("write main.txt: %v", err)
+		}
+		cell, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: fmt.Sprintf("attempt %d", i), RunEval: false})
+		if err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+		ids = append(ids, cell.ID)
+	}
+	if _, err := svc.DB.SaveRanking(db.Ranking{
+		Model: "gpt-test", PromptHash: "abc", Provider: "openai", Summary: "C leads.",
+		Entries: []db.RankingEntry{
+			{CellID: ids[2], Position: 1, LLMPosition: 1, Rationale: "shortest"},
+			{CellID: ids[1], Position: 2, LLMPosition: 2, Rationale: "fine"},
+		},
+	}); err != nil {
+		t.Fatalf("save ranking: %v", err)
+	}
+
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rankings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rankings status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var rankings []llm.RankResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &rankings); err != nil {
+		t.Fatalf("decode rankings: %v", err)
+	}
+	if len(rankings) != 1 || len(rankings[0].Entries) != 2 || rankings[0].Entries[0].CellID != ids[2] {
+		t.Fatalf("unexpected rankings: %+v", rankings)
+	}
+
+	for id, want := range map[string]int{ids[0]: 0, ids[1]: 1} {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cell/"+id, nil))
+		var detail cellDetailJSON
+		if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+			t.Fatalf("decode cell json: %v", err)
+		}
+		if len(detail.Rankings) != want {
+			t.Fatalf("cell %s: expected %d rankings, got %+v", id, want, detail.Rankings)
+		}
+	}
+}
+
+func TestAPIBenchReturnsSeriesPerMetric(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	first, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "first", RunEval: false})
+	if err != nil {
+		t.Fatalf("create first cell: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("rewrite main.go: %v", err)
+	}
+	second, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "second", RunEval: false})
+	if err != nil {
+		t.Fatalf("create second cell: %v", err)
+	}
+	if err := svc.DB.ReplaceBenchSamples(first.ID, []db.BenchSample{
+		{Name: "BenchmarkParse", Unit: "ns/op", Value: 100},
+		{Name: "BenchmarkParse", Unit: "ns/op", Value: 120},
+	}); err != nil {
+		t.Fatalf("replace first samples: %v", err)
+	}
+	if err := svc.DB.ReplaceBenchSamples(second.ID, []db.BenchSample{
+		{Name: "BenchmarkParse", Unit: "ns/op", Value: 80},
+		{Name: "p95_latency", Unit: "ms", Value: 12},
+	}); err != nil {
+		t.Fatalf("replace second samples: %v", err)
+	}
+
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/bench", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("bench status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var series []benchSeriesJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+		t.Fatalf("decode bench json: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series, got %+v", series)
+	}
+	parse := series[0]
+	if parse.Name != "BenchmarkParse" || len(parse.Points) != 2 {
+		t.Fatalf("unexpected parse series: %+v", parse)
+	}
+	if parse.Points[0].CellID != first.ID || parse.Points[0].Mean != 110 || parse.Points[0].N != 2 {
+		t.Fatalf("unexpected first point: %+v", parse.Points[0])
+	}
+	if parse.Points[1].CellID != second.ID || parse.Points[1].Mean != 80 {
+		t.Fatalf("unexpected second point: %+v", parse.Points[1])
+	}
+	if series[1].Name != "p95_latency" || len(series[1].Points) != 1 {
+		t.Fatalf("unexpected latency series: %+v", series[1])
+	}
+}
+
 func TestAPICompareWithoutKeyReturnsGracefulError(t *testing.T) {
 	t.Setenv("OPENAI_API_KEY", "")
 	svc := newUITestService(t)
@@ -206,20 +413,127 @@ func TestAPICellsArchiveSelectionIsolation(t *testing.T) {
 	}
 }
 
-func TestAPICompareRejectsCrossArchiveRequests(t *testing.T) {
+func TestAPICompareAcceptsCrossArchiveRequests(t *testing.T) {
+	var prompt string
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[len(req.Messages)-1].Content
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": `{"summary":"Archived file replaced.","winner":"tie","winner_reason":"same","highlights":[],"risks":[],"files":[]}`},
+		})
+	}))
+	defer llmServer.Close()
+
 	svc := newUITestService(t)
+	svc.Policy.LLM = config.LLMPolicy{Provider: config.LLMProviderOllama, BaseURL: llmServer.URL}
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if _, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "current", RunEval: false}); err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	archiveID := "a_20260302T130000Z_abcd1234"
+	createArchiveFixtureState(t, svc, archiveID, "archived.go", "archived cell")
 	server, err := NewServer(svc)
 	if err != nil {
 		t.Fatalf("new server: %v", err)
 	}
 
-	body := bytes.NewBufferString(`{"cell_a":"c_000001","cell_b":"c_000002","archive_a":"current","archive_b":"a_20260302T130000Z_abcd1234"}`)
+	body := bytes.NewBufferString(`{"cell_a":"c_000001","cell_b":"c_000001","archive_a":"` + archiveID + `","archive_b":"current"}`)
 	req := httptest.NewRequest(http.MethodPost, "/api/compare", body)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 	server.ServeHTTP(rec, req)
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400 for cross-archive compare, got %d body=%s", rec.Code, rec.Body.String())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for cross-archive compare, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(prompt, "Cell A: "+archiveID+":c_000001") || !strings.Contains(prompt, "Removed files: archived.go") {
+		t.Fatalf("expected the archived cell on side A of the prompt:\n%s", prompt)
+	}
+	stored, err := svc.DB.ListComparisonsForCell("c_000001")
+	if err != nil || len(stored) != 0 {
+		t.Fatalf("expected cross-archive compare not to be stored, got %+v (%v)", stored, err)
+	}
+
+	body = bytes.NewBufferString(`{"cell_a":"c_000001","cell_b":"c_000001","archive_a":"current","archive_b":"a_20990101T000000Z_missing0"}`)
+	req = httptest.NewRequest(http.MethodPost, "/api/compare", body)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown archive, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPIDiffAcrossArchives(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	if _, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "current", RunEval: false}); err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	archiveID := "a_20260302T130000Z_abcd1234"
+	createArchiveFixtureState(t, svc, archiveID, "archived.go", "archived cell")
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diff/"+archiveID+":c_000001/c_000001", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("diff status = %d body=%s", rec.Code, rec.Body.String())
+	}
+	var diffs []diffJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &diffs); err != nil {
+		t.Fatalf("decode diff: %v", err)
+	}
+	got := map[string]string{}
+	for _, d := range diffs {
+		got[d.Path] = d.Status
+	}
+	if got["archived.go"] != "removed" || got["main.go"] != "added" {
+		t.Fatalf("unexpected cross-archive diff %+v", diffs)
+	}
+}
+
+func TestAPIArchiveScopedDiffAndComparePlainIDs(t *testing.T) {
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"message": map[string]string{"role": "assistant", "content": `{"summary":"Same cell.","winner":"tie","winner_reason":"same","highlights":[],"risks":[],"files":[]}`},
+		})
+	}))
+	defer llmServer.Close()
+
+	svc := newUITestService(t)
+	svc.Policy.LLM = config.LLMPolicy{Provider: config.LLMProviderOllama, BaseURL: llmServer.URL}
+	archiveID := "a_20260302T130000Z_abcd1234"
+	createArchiveFixtureState(t, svc, archiveID, "archived.go", "archived cell")
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/diff/c_000001/c_000001?archive="+archiveID, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("archive-scoped diff status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	body := bytes.NewBufferString(`{"cell_a":"c_000001","cell_b":"c_000001","archive":"` + archiveID + `"}`)
+	req = httptest.NewRequest(http.MethodPost, "/api/compare", body)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("archive-scoped compare status = %d body=%s", rec.Code, rec.Body.String())
 	}
 }
 
@@ -350,6 +664,20 @@ func TestPickWinnerCellFallsBackToLatestWithoutEval(t *testing.T) {
 	}
 }
 
+func TestPickWinnerCellPrefersScore(t *testing.T) {
+	low := 40.0
+	high := 75.0
+	cells := []db.Cell{
+		{ID: "c_000001", Sequence: 1, TestsPassed: intPtr(10), TestsFailed: intPtr(0), Score: &low},
+		{ID: "c_000002", Sequence: 2, TestsPassed: intPtr(8), TestsFailed: intPtr(2), Score: &high},
+		{ID: "c_000003", Sequence: 3, TestsPassed: intPtr(12), TestsFailed: intPtr(0)},
+	}
+	winner := pickWinnerCell(cells)
+	if winner == nil || winner.ID != "c_000002" {
+		t.Fatalf("expected highest scored cell c_000002, got %+v", winner)
+	}
+}
+
 func intPtr(v int) *int {
 	return &v
 }
@@ -437,3 +765,140 @@ func newUITestService(t *testing.T) *core.Service {
 	objectStore := store.New(filepath.Join(projectDir, ".converge", "objects"))
 	return core.NewService(projectDir, database, objectStore, eval.NewRunner())
 }
+
+func TestAPICellsFiltersByTag(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	for _, opts := range []core.SnapOptions{{Message: "tagged", Tags: "auth,wip"}, {Message: "plain"}} {
+		if _, err := svc.CreateCell(context.Background(), opts); err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+	}
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cells?tag=auth", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cells status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var cells []cellJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &cells); err != nil {
+		t.Fatalf("decode cells json: %v", err)
+	}
+	if len(cells) != 1 || cells[0].Message != "tagged" {
+		t.Fatalf("expected only the tagged cell, got %+v", cells)
+	}
+	if strings.Join(cells[0].Tags, ",") != "auth,wip" {
+		t.Fatalf("unexpected tags %v", cells[0].Tags)
+	}
+}
+
+func TestAPINotesWriteEndpoints(t *testing.T) {
+	svc := newUITestService(t)
+	if err := os.WriteFile(filepath.Join(svc.ProjectDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write main.go: %v", err)
+	}
+	cell, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "base"})
+	if err != nil {
+		t.Fatalf("create cell: %v", err)
+	}
+	archiveID := "a_20260302T120000Z_deadbeef"
+	createArchiveFixtureState(t, svc, archiveID, "archived-main.go", "archived cell")
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/cell/"+cell.ID+"/notes", strings.NewReader(`{"body":"rejected: no tests","path":"main.go","line":1}`))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add note status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var added db.Note
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+		t.Fatalf("decode note: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/notes/%d", added.ID), strings.NewReader(`{"body":"rejected: no tests for auth"}`))
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update note status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/cell/"+cell.ID, nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	var detail cellDetailJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode cell detail: %v", err)
+	}
+	if len(detail.Notes) != 1 || detail.Notes[0].Body != "rejected: no tests for auth" {
+		t.Fatalf("expected edited note in cell detail, got %+v", detail.Notes)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/cell/c_000001/notes?archive="+archiveID, strings.NewReader(`{"body":"x"}`))
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected archived note write to be forbidden, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/notes/%d", added.ID), nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete note status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPIFileHistory(t *testing.T) {
+	svc := newUITestService(t)
+	target := filepath.Join(svc.ProjectDir, "main.go")
+	var cells []*db.Cell
+	for _, content := range []string{"package main\n", "package main\n\nfunc main() {}\n"} {
+		if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+			t.Fatalf("write main.go: %v", err)
+		}
+		cell, err := svc.CreateCell(context.Background(), core.SnapOptions{Message: "edit"})
+		if err != nil {
+			t.Fatalf("create cell: %v", err)
+		}
+		cells = append(cells, cell)
+	}
+	server, err := NewServer(svc)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/file-history?path=main.go&cell="+cells[1].ID, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("file history status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var history fileHistoryJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("decode file history: %v", err)
+	}
+	if len(history.Changes) != 2 || history.Changes[0].ID != cells[1].ID || history.Changes[1].Status != "added" {
+		t.Fatalf("unexpected changes: %+v", history.Changes)
+	}
+	if len(history.Blame) != 3 || history.Blame[0].CellID != cells[0].ID || history.Blame[2].CellID != cells[1].ID {
+		t.Fatalf("unexpected blame: %+v", history.Blame)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/file-history?path=missing.go&cell="+cells[1].ID, nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing file status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+}
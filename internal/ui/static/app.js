@@ -4,6 +4,7 @@
   const archiveSelectEl = document.getElementById("archive-select");
   const archiveModeBannerEl = document.getElementById("archive-mode-banner");
   const branchSelectEl = document.getElementById("branch-select");
+  const tagSelectEl = document.getElementById("tag-select");
   const zoomLevelEl = document.getElementById("zoom-level");
   const zoomInEl = document.getElementById("zoom-in");
   const zoomOutEl = document.getElementById("zoom-out");
@@ -21,6 +22,8 @@
   const compareASelectEl = document.getElementById("compare-a-select");
   const compareBSelectEl = document.getElementById("compare-b-select");
   const compareRunEl = document.getElementById("compare-run");
+  const benchPanelEl = document.getElementById("bench-panel");
+  const rankPanelEl = document.getElementById("rank-panel");
 
   const tabWinnerEl = document.getElementById("tab-winner");
   const tabLineageEl = document.getElementById("tab-lineage");
@@ -40,6 +43,8 @@
   let allBranches = [];
   let allArchives = [];
   let uiSummary = null;
+  let benchSeries = [];
+  let rankings = [];
   let compareStart = null;
   let didRunDefaultCompare = false;
   let selectedArchiveID = "current";
@@ -81,8 +86,11 @@
     renderArchiveMode();
     await loadData();
     renderBranchFilter();
+    renderTagFilter();
     renderWinnerCockpit();
     renderCompareSelectors();
+    renderBenchPanel();
+    renderRankPanel();
     renderGraph();
     await applyInitialWinnerState();
   }
@@ -264,8 +272,11 @@
     await loadData();
     renderArchiveMode();
     renderBranchFilter();
+    renderTagFilter();
     renderWinnerCockpit();
     renderCompareSelectors();
+    renderBenchPanel();
+    renderRankPanel();
     renderGraph();
     await applyInitialWinnerState();
   }
@@ -294,10 +305,12 @@
   }
 
   async function loadData() {
-    const [cellsResp, branchesResp, summaryResp] = await Promise.all([
+    const [cellsResp, branchesResp, summaryResp, benchResp, rankingsResp] = await Promise.all([
       fetch(withArchive("/api/cells")),
       fetch(withArchive("/api/branches")),
       fetch(withArchive("/api/ui/summary")),
+      fetch(withArchive("/api/bench")),
+      fetch(withArchive("/api/rankings")),
     ]);
 
     if (!cellsResp.ok) {
@@ -309,6 +322,8 @@
 
     allCells = await cellsResp.json();
     allBranches = await branchesResp.json();
+    benchSeries = benchResp.ok ? await benchResp.json() : [];
+    rankings = rankingsResp.ok ? await rankingsResp.json() : [];
 
     if (summaryResp.ok) {
       uiSummary = await summaryResp.json();
@@ -336,6 +351,7 @@
       total_branches: allBranches.length,
       active_branch: active?.name || "main",
       winner_cell_id: winner?.id || "",
+      winner_basis: winner?.score != null ? "score" : "heuristic",
       baseline_cell_id: baseline?.id || "",
       pass_rate: calculatePassRateClient(allCells),
       fork_points: countForkPointsClient(allCells),
@@ -364,6 +380,33 @@
     };
   }
 
+  function renderTagFilter() {
+    if (!tagSelectEl) {
+      return;
+    }
+    const previous = tagSelectEl.value;
+    const tags = [...new Set(allCells.flatMap((cell) => cell.tags || []))].sort();
+    tagSelectEl.innerHTML = "";
+
+    const allOpt = document.createElement("option");
+    allOpt.value = "";
+    allOpt.textContent = "All tags";
+    tagSelectEl.appendChild(allOpt);
+
+    for (const tag of tags) {
+      const opt = document.createElement("option");
+      opt.value = tag;
+      opt.textContent = tag;
+      tagSelectEl.appendChild(opt);
+    }
+    tagSelectEl.value = tags.includes(previous) ? previous : "";
+
+    tagSelectEl.onchange = () => {
+      compareStart = null;
+      renderGraph();
+    };
+  }
+
   function renderWinnerCockpit() {
     const winner = cellByID(uiSummary?.winner_cell_id);
     const baseline = cellByID(uiSummary?.baseline_cell_id);
@@ -378,10 +421,14 @@
     }
 
     winnerHeroEl.classList.remove("loading");
+    const basisLine =
+      uiSummary?.winner_basis === "score" && winner.score != null
+        ? `Ranked first by configured score (${formatScore(winner.score)}).`
+        : `Winner confidence: ${formatPercent(uiSummary?.pass_rate || 0)} pass reliability from evaluated runs.`;
     winnerHeroEl.innerHTML = `
       <strong>Best attempt: ${escapeHtml(winner.id)}</strong>
       ${escapeHtml(winner.message || "(no message)")}<br>
-      Winner confidence: ${formatPercent(uiSummary?.pass_rate || 0)} pass reliability from evaluated runs.
+      ${basisLine}
     `;
 
     winnerKpisEl.innerHTML = `
@@ -407,6 +454,111 @@
     quickCompareEl.disabled = !baseline || baseline.id === winner.id;
   }
 
+  function renderBenchPanel() {
+    if (!benchPanelEl) {
+      return;
+    }
+    if (!benchSeries.length) {
+      benchPanelEl.hidden = true;
+      benchPanelEl.innerHTML = "";
+      return;
+    }
+    benchPanelEl.hidden = false;
+    const rows = benchSeries
+      .map((series, index) => {
+        const latest = series.points[series.points.length - 1];
+        return `
+          <div class="bench-row">
+            <div class="bench-name" title="${escapeHtml(series.name)}">${escapeHtml(series.name)}</div>
+            <div class="bench-unit">${escapeHtml(series.unit || "")}</div>
+            ${sparkline(series, index)}
+            <div class="bench-latest">${formatMetric(latest.mean)}</div>
+          </div>
+        `;
+      })
+      .join("");
+    benchPanelEl.innerHTML = `<div class="bench-title">Benchmarks over cells</div>${rows}`;
+
+    benchPanelEl.querySelectorAll("[data-bench-cell]").forEach((pointEl) => {
+      pointEl.addEventListener("click", () => {
+        renderCellDetail(pointEl.getAttribute("data-bench-cell"));
+      });
+    });
+  }
+
+  // cellHeadline prefers the generated summary for compact labels, since
+  // watch and safety cells all share the same message.
+  function cellHeadline(cell) {
+    return cell.summary || cell.message || "";
+  }
+
+  function renderRankPanel() {
+    if (!rankPanelEl) {
+      return;
+    }
+    const latest = rankings[0];
+    if (!latest || !(latest.entries || []).length) {
+      rankPanelEl.hidden = true;
+      rankPanelEl.innerHTML = "";
+      return;
+    }
+    rankPanelEl.hidden = false;
+    const rows = latest.entries
+      .map(
+        (entry) => `
+          <div class="rank-row" data-rank-cell="${escapeHtml(entry.cell_id)}">
+            <div class="rank-pos">#${entry.position}</div>
+            <div class="rank-cell">${escapeHtml(entry.cell_id)}</div>
+            <div>${escapeHtml(entry.rationale || "")}</div>
+          </div>
+        `,
+      )
+      .join("");
+    const basis = latest.metric_basis ? ` · AI + ${escapeHtml(latest.metric_basis)}` : "";
+    rankPanelEl.innerHTML = `<div class="bench-title">Latest ranking · ${escapeHtml(latest.model || "")}${basis}</div>${rows}`;
+
+    rankPanelEl.querySelectorAll("[data-rank-cell]").forEach((rowEl) => {
+      rowEl.addEventListener("click", () => {
+        renderCellDetail(rowEl.getAttribute("data-rank-cell"));
+      });
+    });
+  }
+
+  function sparkline(series, index) {
+    const width = 160;
+    const height = 28;
+    const pad = 3;
+    const points = series.points;
+    const values = points.map((p) => p.mean);
+    const min = Math.min(...values);
+    const max = Math.max(...values);
+    const span = max - min || 1;
+    const step = points.length > 1 ? (width - pad * 2) / (points.length - 1) : 0;
+    const coords = points.map((p, i) => {
+      const x = points.length > 1 ? pad + i * step : width / 2;
+      const y = height - pad - ((p.mean - min) / span) * (height - pad * 2);
+      return { x, y, p };
+    });
+    const line = coords.map((c) => `${c.x.toFixed(1)},${c.y.toFixed(1)}`).join(" ");
+    const dots = coords
+      .map(
+        (c) =>
+          `<circle class="bench-point" cx="${c.x.toFixed(1)}" cy="${c.y.toFixed(1)}" r="2.5" data-bench-cell="${escapeHtml(
+            c.p.cell_id,
+          )}"><title>${escapeHtml(c.p.cell_id)}: ${formatMetric(c.p.mean)} (n=${c.p.n})</title></circle>`,
+      )
+      .join("");
+    return `<svg class="bench-spark" viewBox="0 0 ${width} ${height}" aria-label="trend ${index + 1}"><polyline points="${line}" />${dots}</svg>`;
+  }
+
+  function formatMetric(value) {
+    const abs = Math.abs(value);
+    if (abs >= 1e9) return `${(value / 1e9).toPrecision(3)}G`;
+    if (abs >= 1e6) return `${(value / 1e6).toPrecision(3)}M`;
+    if (abs >= 1e3) return `${(value / 1e3).toPrecision(3)}k`;
+    return `${Number(value.toPrecision(3))}`;
+  }
+
   function kpiCard(label, value, note) {
     return `
       <article class="kpi-card">
@@ -464,7 +616,7 @@
       .sort((a, b) => b.sequence - a.sequence)
       .map((cell) => ({
         value: cell.id,
-        label: `${cell.id} - ${truncate(cell.message || "(no message)", 32)}`,
+        label: `${cell.id} - ${truncate(cellHeadline(cell) || "(no message)", 32)}`,
       }));
 
     if (compareASelectEl) {
@@ -500,12 +652,16 @@
     return branchSelectEl.value.trim();
   }
 
+  function selectedTag() {
+    return tagSelectEl ? tagSelectEl.value.trim() : "";
+  }
+
   function filteredCells() {
     const branch = selectedBranch();
-    if (!branch) {
-      return [...allCells];
-    }
-    return allCells.filter((cell) => cell.branch === branch);
+    const tag = selectedTag();
+    return allCells.filter(
+      (cell) => (!branch || cell.branch === branch) && (!tag || (cell.tags || []).includes(tag)),
+    );
   }
 
   function renderGraph() {
@@ -685,7 +841,7 @@
       g.setAttribute("transform", `translate(${node.x}, ${node.y})`);
       g.setAttribute("tabindex", "0");
       g.setAttribute("role", "button");
-      g.setAttribute("aria-label", `${node.id} ${node.cell.message || ""}`);
+      g.setAttribute("aria-label", `${node.id} ${cellHeadline(node.cell)}`);
 
       g.addEventListener("pointerdown", (event) => onNodePointerDown(event, node.id));
       g.addEventListener("keydown", (event) => {
@@ -723,7 +879,7 @@
 
       g.appendChild(svgText(NODE_WIDTH - 41, 23, statusLabel(node.cell), "status-pill", "middle"));
       g.appendChild(svgText(26, 20, node.id, "id"));
-      const messageLines = wrapTextLines(node.cell.message || "(no message)", 28, 3);
+      const messageLines = wrapTextLines(cellHeadline(node.cell) || "(no message)", 28, 3);
       appendWrappedSvgText(g, 14, 45, messageLines, "message", 14);
       const wrappedOffset = (messageLines.length - 1) * 14;
       g.appendChild(svgText(14, 65 + wrappedOffset, `parent: ${node.cell.parent_id || "root"}`, "meta"));
@@ -1159,13 +1315,72 @@
     const files = cell.files
       .map(
         (file) =>
-          `<li><code>${escapeHtml(file.path)}</code> <span class="meta">(${formatBytes(file.size)})</span></li>`,
+          `<li><a href="#" class="file-history-link" data-path="${escapeHtml(file.path)}"><code>${escapeHtml(
+            file.path,
+          )}</code></a> <span class="meta">(${formatBytes(file.size)})</span></li>`,
+      )
+      .join("");
+    const evalProjects = cell.eval_projects || [];
+    const showProjects = evalProjects.length > 1 || (evalProjects.length === 1 && evalProjects[0].dir !== ".");
+    const projects = evalProjects
+      .map(
+        (project) =>
+          `<li><code>${escapeHtml(project.dir)}</code> <span class="meta">(${escapeHtml(
+            project.types.join(", "),
+          )})</span> ${evalSummary(project)}</li>`,
+      )
+      .join("");
+    const comparisons = (cell.comparisons || [])
+      .map(
+        (c) =>
+          `<li><strong>${escapeHtml(c.cell_a)} → ${escapeHtml(c.cell_b)}</strong> <span class="badge">${escapeHtml(
+            c.model,
+          )}</span> ${escapeHtml(c.winner ? `winner ${c.winner}` : "")}<br>${escapeHtml(c.summary || "")}</li>`,
+      )
+      .join("");
+    const cellRankings = (cell.rankings || [])
+      .map((ranking) => {
+        const entry = (ranking.entries || []).find((e) => e.cell_id === cell.id);
+        if (!entry) {
+          return "";
+        }
+        return `<li><strong>#${entry.position} of ${ranking.entries.length}</strong> <span class="badge">${escapeHtml(
+          ranking.model,
+        )}</span><br>${escapeHtml(entry.rationale || "")}</li>`;
+      })
+      .join("");
+    const notes = (cell.notes || [])
+      .map((note) => {
+        const anchor = note.path ? `<code>${escapeHtml(note.line ? `${note.path}:${note.line}` : note.path)}</code> ` : "";
+        const actions = archiveIsWritable()
+          ? ` <button type="button" class="tool-btn note-edit" data-note="${note.id}">Edit</button> <button type="button" class="tool-btn note-delete" data-note="${note.id}">Delete</button>`
+          : "";
+        return `<li data-note-body="${escapeHtml(note.body)}">${anchor}${escapeHtml(note.body)} <span class="meta">${escapeHtml(
+          note.updated_at,
+        )}</span>${actions}</li>`;
+      })
+      .join("");
+    const noteForm = archiveIsWritable()
+      ? `<textarea id="note-input" class="note-input" rows="2" placeholder="Why was this attempt kept or rejected?"></textarea>
+        <button type="button" class="action-btn" id="note-add">Add note</button>`
+      : "";
+    const coverageFiles = (cell.coverage_files || [])
+      .map(
+        (file) =>
+          `<li><code>${escapeHtml(file.path)}</code> <span class="meta">${file.pct.toFixed(1)}% (${file.covered}/${
+            file.total
+          })</span></li>`,
       )
       .join("");
 
     panelEl.innerHTML = `
       <h3>${escapeHtml(cell.id)}</h3>
       <div class="kv"><div class="k">Message</div><div class="v">${escapeHtml(cell.message || "")}</div></div>
+      ${
+        cell.summary
+          ? `<div class="kv"><div class="k">Summary</div><div class="v">${escapeHtml(cell.summary)}</div></div>`
+          : ""
+      }
       <div class="kv"><div class="k">Branch</div><div class="v">${escapeHtml(cell.branch)}</div></div>
       <div class="kv"><div class="k">Timestamp</div><div class="v">${escapeHtml(cell.timestamp)}</div></div>
       <div class="kv"><div class="k">Source</div><div class="v">${escapeHtml(cell.source)}</div></div>
@@ -1173,8 +1388,136 @@
         cell.loc_delta,
       )} total ${cell.total_loc}</div></div>
       <div class="kv"><div class="k">Eval</div><div class="v">${evalSummary(cell)}</div></div>
+      ${cell.score != null ? `<div class="kv"><div class="k">Score</div><div class="v">${formatScore(cell.score)}</div></div>` : ""}
+      ${
+        showProjects
+          ? `<div class="kv"><div class="k">Projects (${evalProjects.length})</div><div class="v"><ul>${projects}</ul></div></div>`
+          : ""
+      }
+      ${
+        cell.coverage_pct != null
+          ? `<div class="kv"><div class="k">Coverage</div><div class="v">${coverageSummary(cell)}${
+              coverageFiles ? `<ul>${coverageFiles}</ul>` : ""
+            }</div></div>`
+          : ""
+      }
+      ${
+        cellRankings
+          ? `<div class="kv"><div class="k">Rankings (${cell.rankings.length})</div><div class="v"><ul>${cellRankings}</ul></div></div>`
+          : ""
+      }
+      ${
+        comparisons
+          ? `<div class="kv"><div class="k">Comparisons (${cell.comparisons.length})</div><div class="v"><ul>${comparisons}</ul></div></div>`
+          : ""
+      }
+      ${
+        notes || noteForm
+          ? `<div class="kv"><div class="k">Notes (${(cell.notes || []).length})</div><div class="v">${
+              notes ? `<ul>${notes}</ul>` : ""
+            }${noteForm}</div></div>`
+          : ""
+      }
       <div class="kv"><div class="k">Tracked files (${cell.files.length})</div><div class="v"><ul>${files}</ul></div></div>
     `;
+    bindNoteActions(cell.id);
+    for (const link of panelEl.querySelectorAll(".file-history-link")) {
+      link.addEventListener("click", (event) => {
+        event.preventDefault();
+        renderFileHistory(link.dataset.path, cell.id);
+      });
+    }
+  }
+
+  async function renderFileHistory(path, cellID) {
+    panelEl.innerHTML = `<div class="placeholder">Loading history for <code>${escapeHtml(path)}</code>...</div>`;
+    const query = `path=${encodeURIComponent(path)}&cell=${encodeURIComponent(cellID)}`;
+    const resp = await fetch(withArchive(`/api/file-history?${query}`));
+    if (!resp.ok) {
+      panelEl.innerHTML = `<div class="placeholder">Unable to load history for ${escapeHtml(path)}: ${escapeHtml(
+        (await resp.text()) || resp.statusText,
+      )}</div>`;
+      return;
+    }
+
+    const history = await resp.json();
+    const changes = history.changes
+      .map(
+        (change) =>
+          `<li><a href="#" class="history-cell-link" data-cell="${escapeHtml(change.id)}"><strong>${escapeHtml(
+            change.id,
+          )}</strong></a> <span class="badge">${escapeHtml(change.status)}</span> ${escapeHtml(
+            change.message || "",
+          )} <span class="meta">${escapeHtml(change.source)} · ${escapeHtml(change.timestamp)}</span></li>`,
+      )
+      .join("");
+    let previous = "";
+    const blame = history.blame
+      .map((line) => {
+        const owner = line.cell_id === previous ? "" : `${line.cell_id} ${line.agent || "-"}`;
+        previous = line.cell_id;
+        return `<span class="meta">${escapeHtml(owner.padEnd(22))} ${String(line.line).padStart(4)}|</span> ${escapeHtml(
+          line.text,
+        )}`;
+      })
+      .join("\n");
+
+    panelEl.innerHTML = `
+      <h3><code>${escapeHtml(history.path)}</code></h3>
+      <div class="kv"><div class="k"><a href="#" class="history-cell-link" data-cell="${escapeHtml(
+        cellID,
+      )}">Back to ${escapeHtml(cellID)}</a></div></div>
+      <div class="kv"><div class="k">History (${history.changes.length})</div><div class="v"><ul>${changes}</ul></div></div>
+      <div class="kv"><div class="k">Blame at ${escapeHtml(cellID)}</div><div class="v"><pre class="diff-content">${blame}</pre></div></div>
+    `;
+    for (const link of panelEl.querySelectorAll(".history-cell-link")) {
+      link.addEventListener("click", (event) => {
+        event.preventDefault();
+        renderCellDetail(link.dataset.cell);
+      });
+    }
+  }
+
+  function archiveIsWritable() {
+    return !selectedArchiveID || selectedArchiveID === "current";
+  }
+
+  function bindNoteActions(cellID) {
+    document.getElementById("note-add")?.addEventListener("click", async () => {
+      const body = document.getElementById("note-input")?.value.trim();
+      if (body) {
+        await saveNote(`/api/cell/${encodeURIComponent(cellID)}/notes`, "POST", { body }, cellID);
+      }
+    });
+    for (const button of panelEl.querySelectorAll(".note-edit")) {
+      button.addEventListener("click", async () => {
+        const current = button.closest("li")?.dataset.noteBody || "";
+        const body = window.prompt("Edit note", current);
+        if (body && body.trim() && body.trim() !== current) {
+          await saveNote(`/api/notes/${button.dataset.note}`, "PUT", { body: body.trim() }, cellID);
+        }
+      });
+    }
+    for (const button of panelEl.querySelectorAll(".note-delete")) {
+      button.addEventListener("click", async () => {
+        if (window.confirm("Delete this note?")) {
+          await saveNote(`/api/notes/${button.dataset.note}`, "DELETE", null, cellID);
+        }
+      });
+    }
+  }
+
+  async function saveNote(path, method, payload, cellID) {
+    const resp = await fetch(path, {
+      method,
+      headers: payload ? { "Content-Type": "application/json" } : undefined,
+      body: payload ? JSON.stringify(payload) : undefined,
+    });
+    if (!resp.ok) {
+      window.alert(`Saving note failed: ${(await resp.text()) || resp.statusText}`);
+      return;
+    }
+    await renderCellDetail(cellID);
   }
 
   async function renderCompare(cellA, cellB) {
@@ -1215,12 +1558,15 @@
       <div id="ai-summary" class="placeholder">Generating AI summary...</div>
     `;
 
-    const aiEl = document.getElementById("ai-summary");
+    await loadAISummary(document.getElementById("ai-summary"), cellA, cellB, false);
+  }
+
+  async function loadAISummary(aiEl, cellA, cellB, refresh) {
     try {
       const compareResp = await fetch("/api/compare", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ cell_a: cellA, cell_b: cellB, archive: selectedArchiveID }),
+        body: JSON.stringify({ cell_a: cellA, cell_b: cellB, archive: selectedArchiveID, refresh }),
       });
       const result = await compareResp.json();
       if (!compareResp.ok || result.error) {
@@ -1231,16 +1577,41 @@
       }
 
       const highlights = (result.highlights || []).map((highlight) => `<li>${escapeHtml(highlight)}</li>`).join("");
+      const risks = (result.risks || []).map((risk) => `<li>${escapeHtml(risk)}</li>`).join("");
+      const fileNotes = (result.files || [])
+        .map((f) => `<li><strong>${escapeHtml(f.path)}</strong>: ${escapeHtml(f.note)}</li>`)
+        .join("");
       aiEl.innerHTML = `
         <h4>AI Summary</h4>
+        ${
+          result.provider
+            ? `<div class="badge">${escapeHtml(result.provider)}${result.model ? ` · ${escapeHtml(result.model)}` : ""}</div>`
+            : ""
+        }
+        ${
+          result.cached
+            ? `<div class="badge">stored ${escapeHtml(result.created_at || "")}</div> <button type="button" class="action-btn" id="ai-refresh">Re-run</button>`
+            : ""
+        }
         <p>${escapeHtml(result.summary || "")}</p>
         ${
           result.winner
-            ? `<div class="kv"><div class="k">Winner</div><div class="v">${escapeHtml(result.winner)}</div></div>`
+            ? `<div class="kv"><div class="k">Winner</div><div class="v">${escapeHtml(result.winner)}${
+                result.winner_reason ? ` — ${escapeHtml(result.winner_reason)}` : ""
+              }</div></div>`
             : ""
         }
         ${highlights ? `<ul>${highlights}</ul>` : ""}
+        ${risks ? `<div class="kv"><div class="k">Risks</div><div class="v"><ul>${risks}</ul></div></div>` : ""}
+        ${fileNotes ? `<div class="kv"><div class="k">Files</div><div class="v"><ul>${fileNotes}</ul></div></div>` : ""}
       `;
+      const refreshEl = document.getElementById("ai-refresh");
+      if (refreshEl) {
+        refreshEl.addEventListener("click", () => {
+          aiEl.innerHTML = "Re-running AI summary...";
+          loadAISummary(aiEl, cellA, cellB, true);
+        });
+      }
     } catch (_err) {
       aiEl.innerHTML = '<div class="badge bad">AI compare failed.</div>';
     }
@@ -1286,9 +1657,27 @@
     if (!parts.length) {
       return '<span class="badge">not requested</span>';
     }
+    if (cell.eval_scope === "changed") {
+      parts.push('<span class="badge warn" title="Only packages affected by this cell\'s diff were checked">changed scope</span>');
+    }
     return parts.join(" ");
   }
 
+  function formatScore(value) {
+    return `${Math.round(value * 100) / 100}`;
+  }
+
+  function coverageSummary(cell) {
+    let html = `<span class="badge">${cell.coverage_pct.toFixed(1)}%</span>`;
+    if (cell.coverage_delta != null) {
+      const delta = cell.coverage_delta;
+      const cls = delta > 0.05 ? "good" : delta < -0.05 ? "bad" : "";
+      const sign = delta >= 0 ? "+" : "";
+      html += ` <span class="badge ${cls}">${sign}${delta.toFixed(1)} vs parent</span>`;
+    }
+    return html;
+  }
+
   function svgText(x, y, text, cls, anchor) {
     const node = document.createElementNS(SVG_NS, "text");
     node.setAttribute("x", String(x));
@@ -1358,6 +1747,18 @@
       return null;
     }
 
+    const scored = cells.filter((cell) => cell.score != null);
+    if (scored.length) {
+      let best = scored[0];
+      for (let i = 1; i < scored.length; i += 1) {
+        const candidate = scored[i];
+        if (candidate.score > best.score || (candidate.score === best.score && winnerPreferredClient(candidate, best))) {
+          best = candidate;
+        }
+      }
+      return best;
+    }
+
     const evaluated = cells.filter(
       (cell) => cell.tests_passed != null || cell.tests_failed != null || cell.lint_errors != null || cell.type_errors != null,
     );